@@ -0,0 +1,131 @@
+package processor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// cacheFileName is the name of the cache of files without changes, written to the current directory
+const cacheFileName = ".unfuck-cache"
+
+// fileCache keeps hashes of files without changes in previous runs, so they are not parsed again.
+// the cache is valid for the options it was made with only, it is safe for concurrent use
+type fileCache struct {
+	Key   string                `json:"key"`
+	Files map[string]cacheEntry `json:"files"`
+	mu    sync.Mutex
+}
+
+// cacheEntry is a file without changes, its mtime and size are checked before the hash of the content
+type cacheEntry struct {
+	Hash    string    `json:"hash"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Package string    `json:"package,omitempty"` // hash of names declared in the package, with --preserve-declared
+}
+
+// loadCache reads the cache file, a missing file or a cache made with different options gives an empty cache.
+// a broken cache file is reported and discarded, it is rewritten at the end of the run
+func loadCache(path, key string, writers OutputWriters) (*fileCache, error) {
+	res := &fileCache{Key: key, Files: map[string]cacheEntry{}}
+	data, err := os.ReadFile(path) //nolint:gosec
+	if errors.Is(err, os.ErrNotExist) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read cache: %w", err)
+	}
+	var cached fileCache
+	if err := json.Unmarshal(data, &cached); err != nil {
+		writers.warnf("Warning: ignoring broken cache %s: %v\n", path, err)
+		return res, nil
+	}
+	if cached.Key == key && cached.Files != nil {
+		res.Files = cached.Files
+	}
+	return res, nil
+}
+
+// save writes the cache file
+func (c *fileCache) save(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("marshal cache: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("write cache: %w", err)
+	}
+	return nil
+}
+
+// unchanged checks if the file had no changes in previous runs, entries with different mtime or size are invalid,
+// as well as entries made with other names declared in the package, pkg is their hash
+func (c *fileCache) unchanged(path string, src []byte, pkg string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	c.mu.Lock()
+	entry, ok := c.Files[filepath.Clean(path)]
+	c.mu.Unlock()
+	if !ok || entry.Size != info.Size() || !entry.ModTime.Equal(info.ModTime()) || entry.Package != pkg {
+		return false
+	}
+	return entry.Hash == contentHash(src)
+}
+
+// update records the file without changes, pkg is the hash of names declared in its package if they matter
+func (c *fileCache) update(path string, src []byte, pkg string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	entry := cacheEntry{Hash: contentHash(src), Size: info.Size(), ModTime: info.ModTime(), Package: pkg}
+	c.mu.Lock()
+	c.Files[filepath.Clean(path)] = entry
+	c.mu.Unlock()
+}
+
+// contentHash returns the hex-encoded SHA256 of the content
+func contentHash(src []byte) string {
+	sum := sha256.Sum256(src)
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheKey returns the hash of options affecting the result, of the contents of word lists and of the version
+// of the tool. the patterns to process and options not changing results, like the log level, don't change it
+func cacheKey(opts Options) string {
+	opts.Run.Args.Patterns, opts.Diff.Args.Patterns, opts.Print.Args.Patterns = nil, nil, nil
+	opts.Check.Args.Patterns, opts.Compare.Args.Patterns, opts.Workspace.Args.Roots = nil, nil, nil
+	opts.Watch.Args.Dirs, opts.Since = nil, ""
+	opts.LogLevel, opts.Verbose, opts.Jobs, opts.Yes, opts.ConfirmThreshold = "", false, 0, false, 0
+	opts.NoColor, opts.ShowChurn, opts.ShowLocations, opts.RewriteLog, opts.PreviewLimit = false, false, false, "", 0
+
+	key := struct {
+		Options Options
+		Version string
+		Lists   []string
+	}{Options: opts, Version: buildVersion()}
+	for _, fileName := range slices.Concat(opts.ProperNouns, opts.WordsFile) {
+		if fileName == "" {
+			continue
+		}
+		list, _ := os.ReadFile(fileName) //nolint:gosec // word list from the options
+		key.Lists = append(key.Lists, contentHash(list))
+	}
+	data, err := json.Marshal(key)
+	if err != nil {
+		return ""
+	}
+	return contentHash(data)
+}
@@ -0,0 +1,128 @@
+package processor
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFileCache tests that files without changes in a previous run are skipped while their content is the same
+func TestFileCache(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	require.NoError(t, os.WriteFile("clean.go", []byte("package test\n\nfunc A() {\n\t// clean comment\n}\n"), 0o600))
+	require.NoError(t, os.WriteFile("dirty.go", []byte("package test\n\nfunc B() {\n\t// Some Comment\n}\n"), 0o600))
+
+	run := func(mode string, cache *fileCache) (*ProcessRequest, string) {
+		var stdoutBuf, stderrBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: mode, TitleCase: true, Cache: cache}
+		processPatterns([]string{"."}, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf, Level: slog.LevelDebug})
+		return req, stderrBuf.String()
+	}
+
+	cache, err := loadCache(cacheFileName, "key", OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+	require.NoError(t, err)
+	assert.Empty(t, cache.Files, "missing cache file gives empty cache")
+
+	req, log := run("diff", cache)
+	assert.Equal(t, 1, req.TotalChanges)
+	assert.NotContains(t, log, "Unchanged since the last run")
+	assert.Len(t, cache.Files, 1, "only the clean file is cached")
+	require.NoError(t, cache.save(cacheFileName))
+
+	t.Run("clean file skipped", func(t *testing.T) {
+		cache, err := loadCache(cacheFileName, "key", OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		require.NoError(t, err)
+		req, log := run("diff", cache)
+		assert.Equal(t, 1, req.TotalChanges)
+		assert.Contains(t, log, "Unchanged since the last run: clean.go")
+		assert.NotContains(t, log, "Unchanged since the last run: dirty.go")
+	})
+
+	t.Run("other options", func(t *testing.T) {
+		cache, err := loadCache(cacheFileName, "other key", OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		require.NoError(t, err)
+		assert.Empty(t, cache.Files)
+	})
+
+	t.Run("changed file", func(t *testing.T) {
+		require.NoError(t, os.WriteFile("clean.go", []byte("package test\n\nfunc A() {\n\t// New Comment\n}\n"), 0o600))
+		cache, err := loadCache(cacheFileName, "key", OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		require.NoError(t, err)
+		req, log := run("diff", cache)
+		assert.Equal(t, 2, req.TotalChanges)
+		assert.NotContains(t, log, "Unchanged since the last run")
+	})
+
+	t.Run("same size and mtime, different content", func(t *testing.T) {
+		cache, err := loadCache(cacheFileName, "key", OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		require.NoError(t, err)
+		entry := cache.Files["clean.go"]
+		entry.Hash = contentHash([]byte("something else"))
+		info, err := os.Stat("clean.go")
+		require.NoError(t, err)
+		entry.Size, entry.ModTime = info.Size(), info.ModTime()
+		cache.Files["clean.go"] = entry
+		req, _ := run("diff", cache)
+		assert.Equal(t, 2, req.TotalChanges)
+	})
+
+	t.Run("files updated in place are cached", func(t *testing.T) {
+		cache, err := loadCache(cacheFileName, "key", OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		require.NoError(t, err)
+		req, _ := run("inplace", cache)
+		assert.Equal(t, 2, req.FilesUpdated)
+		require.Len(t, cache.Files, 2)
+
+		req, log := run("diff", cache)
+		assert.Zero(t, req.TotalChanges)
+		assert.Equal(t, 2, strings.Count(log, "Unchanged since the last run"))
+	})
+
+	t.Run("broken cache file", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(cacheFileName, []byte("{"), 0o600))
+		var stderrBuf bytes.Buffer
+		cache, err := loadCache(cacheFileName, "key", OutputWriters{Stdout: io.Discard, Stderr: &stderrBuf})
+		require.NoError(t, err, "broken cache is discarded")
+		assert.Empty(t, cache.Files)
+		assert.Contains(t, stderrBuf.String(), "ignoring broken cache "+cacheFileName)
+	})
+
+	t.Run("declared names", func(t *testing.T) {
+		cache, err := loadCache(cacheFileName, "key", OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		require.NoError(t, err)
+		req := &ProcessRequest{PreserveDeclared: true}
+		src, err := os.ReadFile("clean.go")
+		require.NoError(t, err)
+		cache.update("clean.go", src, req.packageKey("clean.go"))
+		assert.True(t, cache.unchanged("clean.go", src, req.packageKey("clean.go")))
+
+		require.NoError(t, os.WriteFile("other.go", []byte("package test\n\nconst Added = 1\n"), 0o600))
+		req = &ProcessRequest{PreserveDeclared: true}
+		assert.False(t, cache.unchanged("clean.go", src, req.packageKey("clean.go")), "declarations of the package changed")
+		assert.Empty(t, (&ProcessRequest{}).packageKey("clean.go"), "declared names matter with the option only")
+		require.NoError(t, os.Remove("other.go"))
+	})
+
+	assert.Equal(t, cacheKey(Options{}), cacheKey(func() Options {
+		var opts Options
+		opts.Run.Args.Patterns = []string{"./..."}
+		return opts
+	}()), "patterns don't change the key")
+	assert.Equal(t, cacheKey(Options{}), cacheKey(Options{Since: "main"}), "revision of changed files doesn't change the key")
+	assert.NotEqual(t, cacheKey(Options{}), cacheKey(Options{Full: true}))
+	assert.Equal(t, cacheKey(Options{}), cacheKey(Options{LogLevel: "debug", Jobs: 4, NoColor: true}),
+		"options not changing results don't change the key")
+
+	require.NoError(t, os.WriteFile("words.txt", []byte("Kubernetes\n"), 0o600))
+	key := cacheKey(Options{WordsFile: []string{"words.txt"}})
+	assert.NotEqual(t, cacheKey(Options{}), key)
+	require.NoError(t, os.WriteFile("words.txt", []byte("Kubernetes\nPostgreSQL\n"), 0o600))
+	assert.NotEqual(t, key, cacheKey(Options{WordsFile: []string{"words.txt"}}), "contents of word lists change the key")
+}
@@ -0,0 +1,939 @@
+package processor
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/fatih/color"
+	"github.com/jessevdk/go-flags"
+)
+
+// Options holds command line options
+type Options struct {
+	Run struct {
+		Args struct {
+			Patterns []string `positional-arg-name:"FILE/PATTERN" description:"Files or patterns to process (default: current directory)"`
+		} `positional-args:"yes"`
+	} `command:"run" description:"Process files in-place (default)"`
+
+	Diff struct {
+		Args struct {
+			Patterns []string `positional-arg-name:"FILE/PATTERN" description:"Files or patterns to process (default: current directory)"`
+		} `positional-args:"yes"`
+	} `command:"diff" description:"Show diff without modifying files"`
+
+	Print struct {
+		Args struct {
+			Patterns []string `positional-arg-name:"FILE/PATTERN" description:"Files or patterns to process (default: current directory)"`
+		} `positional-args:"yes"`
+	} `command:"print" description:"Print processed content to stdout"`
+
+	Check struct {
+		Args struct {
+			Patterns []string `positional-arg-name:"FILE/PATTERN" description:"Files or patterns to process (default: current directory)"`
+		} `positional-args:"yes"`
+	} `command:"check" description:"List files with comments to fix and exit with non-zero code if any, without modifying files"`
+
+	Compare struct {
+		FullVsTitle bool `long:"full-vs-title" description:"Compare full lowercase mode with title case mode"`
+		Args        struct {
+			Patterns []string `positional-arg-name:"FILE/PATTERN" description:"Files or patterns to process (default: current directory)"`
+		} `positional-args:"yes"`
+	} `command:"compare" description:"Compare results of processing modes without modifying files"`
+
+	Workspace struct {
+		Args struct {
+			Roots []string `positional-arg-name:"DIR" description:"Workspace directories to search for Go modules (default: current directory)"`
+		} `positional-args:"yes"`
+	} `command:"workspace" description:"Process each Go module of the workspace separately, with a summary per module"`
+
+	Staged struct{} `command:"staged" description:"Process Go files staged for commit in the git repository"`
+
+	Watch struct {
+		Args struct {
+			Dirs []string `positional-arg-name:"DIR" description:"Directories to watch (default: current directory)"`
+		} `positional-args:"yes"`
+	} `command:"watch" description:"Watch directories and process Go files in place when they are saved"`
+
+	Title             bool     `long:"title" description:"Convert only the first character to lowercase, keep the rest unchanged (deprecated, now default behavior)"`
+	Full              bool     `long:"full" description:"Convert entire comment to lowercase, not just the first character"`
+	LeadingCaps       bool     `long:"normalize-leading-caps-only" description:"Convert only the leading run of ALL-CAPS words to lowercase, keep the rest unchanged"`
+	Capitalize        bool     `long:"capitalize" description:"Convert the first character to uppercase instead, the inverse of the default title mode"`
+	Trim              bool     `long:"trim" description:"Strip trailing whitespace from line comments"`
+	EnsureSpace       bool     `long:"ensure-space" description:"Insert a space after \"//\" of comments starting right after it, like \"//Comment\", directives are kept"`
+	Sentence          bool     `long:"sentence" description:"Convert the first character of every sentence, not only of the comment, like in title mode"`
+	GroupAware        bool     `long:"group-aware" description:"Convert the first character of the first line only in a group of consecutive // comments, keep the leading case of the following lines"`
+	Skip              []string `long:"skip" description:"Skip specified directories or files (can be used multiple times)"`
+	Include           []string `long:"include" description:"Process only files matching the pattern, like *_handler.go (can be used multiple times)"`
+	IgnoreGeneratedBy []string `long:"ignore-generated-by" description:"Skip files generated by the named generators, like moq or mockgen, comma-separated (can be used multiple times)"`
+	PackageName       string   `long:"package" description:"Process only files of the package with this name"`
+	NoSkipHidden      bool     `long:"no-skip-hidden" description:"Walk into hidden directories and files starting with a dot"`
+	MaxDepth          int      `long:"max-depth" default:"-1" description:"Walk at most N directory levels below the root (0 means only the root directory, -1 unlimited)"`
+	NewFilesOnly      bool     `long:"new-files-only" description:"Process only files added or untracked according to git status"`
+	Since             string   `long:"since" description:"Process only Go files changed by commits since this git revision, like main or HEAD~3"`
+	Dirty             bool     `long:"dirty" description:"Process only files modified in the working tree or untracked according to git status"`
+	Format            bool     `long:"fmt" description:"Run gofmt on processed files"`
+	Imports           bool     `long:"imports" description:"Tidy imports of processed files like goimports, after gofmt if --fmt is set"`
+	GofmtBin          string   `long:"gofmt-bin" description:"Format with \"gofmt -s\" of this binary for --fmt, instead of the built-in formatter"`
+	TabWidth          int      `long:"tabwidth" default:"8" description:"Tab width used to align the modified sources"`
+	UseSpaces         bool     `long:"use-spaces" description:"Indent and align the modified sources with spaces instead of tabs"`
+	Backup            bool     `long:"backup" description:"Create .bak backups of files that are modified"`
+	BackupExt         string   `long:"backup-ext" default:".bak" description:"Extension of backup files, starting with a dot"`
+	BackupDir         string   `long:"backup-dir" description:"Put backups under this directory, keeping their paths relative to the current directory, instead of next to the files"`
+	MinWords          int      `long:"min-words" description:"Only convert comments with at least this many words (0 means all)"`
+	MinUpperRun       int      `long:"min-upper-run" description:"Only convert comments with a run of at least this many uppercase letters, like \"// THIS IS\" (0 means all)"`
+	PreviewLimit      int      `long:"preview-limit" description:"Show diffs only for the first N changed files (0 means unlimited)"`
+	SideBySide        bool     `long:"side-by-side" description:"Show diffs as original and modified lines in two columns"`
+	LineEndings       string   `long:"line-endings" choice:"auto" choice:"lf" choice:"crlf" default:"auto" description:"Line endings of modified sources, auto keeps the dominant line ending of each file"`
+	Context           int      `long:"context" default:"3" description:"Unchanged lines shown around changes in diffs, 0 shows changed lines only"`
+	Lenient           bool     `long:"lenient" description:"Convert comments inside blocks of files with syntax errors by a line-based scan, instead of skipping them"`
+	DiffFormat        string   `long:"diff-format" choice:"simple" choice:"unified" default:"simple" description:"Format of diffs, unified diffs can be applied with patch"`
+	NoColor           bool     `long:"no-color" description:"Disable colorized output, also disabled by the NO_COLOR or CLICOLOR=0 environment variables"`
+	ShowChurn         bool     `long:"show-churn" description:"Show the total number of characters changed in the summary"`
+	RewriteLog        string   `long:"rewrite-log" description:"Append a timestamped line for each comment changed in place to this file"`
+	ShowLocations     bool     `long:"show-locations" description:"Print location and preview of each changed comment to stderr when files are updated in place"`
+	ConfirmThreshold  int      `long:"confirm-threshold" description:"Ask for confirmation before modifying more than N files in place (0 means never)"`
+	Yes               bool     `long:"yes" description:"Don't ask for confirmation, assume yes"`
+	PreCommit         bool     `long:"pre-commit" description:"Run as a pre-commit hook: process the given files in place and exit with non-zero code if any were modified"`
+	FailFast          bool     `long:"fail-fast" description:"Stop at the first file with changes and exit with non-zero code (diff, count and check modes)"`
+	Cache             bool     `long:"cache" description:"Skip files without changes in previous runs with the same options, tracked in the .unfuck-cache file"`
+	Jobs              int      `long:"jobs" description:"Number of files processed in parallel while walking directories (0 means the number of CPUs)"`
+	LogLevel          string   `long:"log-level" choice:"debug" choice:"info" choice:"warn" choice:"error" default:"info" description:"Minimal level of log messages to show"`
+	Verbose           bool     `long:"verbose" description:"Show debug messages, same as --log-level=debug"`
+	Version           bool     `short:"v" long:"version" description:"Show version information"`
+
+	DryRun       bool   `long:"dry" description:"Don't modify files, just show what would be changed"`
+	Output       string `long:"output" choice:"count" description:"Machine-readable output (count: print only the total number of changes)"`
+	ReportFormat string `long:"format" choice:"github" description:"Report comments to change in the given format (github: workflow annotations)"`
+	JSONLines    bool   `long:"jsonl" description:"Stream comments to change as JSON objects, one per line, without modifying files"`
+	JSON         bool   `long:"json" description:"Print comments to change as a JSON array and the summary as a JSON object, without modifying files"`
+
+	OnlyChanged      bool    `long:"only-changed" description:"In print mode, print only changed comments with their file names and line numbers"`
+	DensityReport    bool    `long:"density-report" description:"Report functions with too many comments per statement, without modifying files"`
+	DensityThreshold float64 `long:"density-threshold" default:"0.5" description:"Comments to statements ratio above which a function is reported"`
+	StyleReport      bool    `long:"style-report" description:"Report how in-function comments are cased, like lowercase-first or ALL CAPS, without modifying files"`
+	ExportComments   string  `long:"export-comments" description:"Write all in-function comments with metadata to a JSON file, without modifying files"`
+	Quarantine       string  `long:"quarantine" description:"Copy files with comments to convert into this directory for review, without modifying them"`
+
+	VerifyIdempotent    bool `long:"verify-idempotent" hidden:"true" description:"Verify that processing the result again makes no further changes"`
+	AssertDocsPreserved bool `long:"assert-docs-preserved" description:"Refuse to write files if any doc comment of a declaration was changed"`
+
+	BannerThreshold           float64  `long:"banner-threshold" default:"0.5" description:"Skip banner comments with a symbol ratio above this threshold (0 disables)"`
+	KeepPrefixes              []string `long:"keep-prefix" description:"Keep comments starting with this prefix unchanged, like the built-in TODO or FIXME (can be used multiple times)"`
+	NoKeepIndicators          bool     `long:"no-keep-indicators" description:"Convert comments starting with TODO, FIXME and other special indicators, including --keep-prefix ones, like any other comment"`
+	KeepPrefixOnly            bool     `long:"keep-prefix-only" description:"Keep only comments starting with --keep-prefix prefixes, instead of the built-in TODO, FIXME and others"`
+	DirectivePrefixes         []string `long:"directive-prefix" description:"Treat comments starting with this prefix as directives, keep the directive token (can be used multiple times)"`
+	ProperNouns               []string `long:"proper-nouns" description:"File with a newline-delimited list of proper nouns and terms, like product names, to keep in their canonical form (can be used multiple times)"`
+	WordsFile                 []string `long:"words-file" description:"Alias of --proper-nouns (can be used multiple times)"`
+	Acronyms                  []string `long:"acronyms" default:"HTTP,URL,JSON,XML,API,ID,CPU,GPU" description:"Acronyms kept anywhere in a comment in full mode when written as listed, comma-separated, empty to disable (can be used multiple times)"`
+	NormalizeDirectiveSpacing bool     `long:"normalize-directive-spacing" description:"Collapse spacing in two-part directive comments to \"//directive // comment\""`
+	PreserveExportedDocs      bool     `long:"preserve-exported-docs" description:"Keep comments of exported struct fields and interface methods unchanged"`
+	PreserveColonHeaders      bool     `long:"preserve-colon-headers" description:"Keep short comments ending with a colon, like \"// Steps:\", unchanged"`
+	DocComments               bool     `long:"doc-comments" description:"Process doc comments of functions, types, variables and constants too, keeping the leading declared name"`
+	DocSlash                  bool     `long:"doc-slash" description:"Process doc-annotation comments starting with \"///\" or \"//!\" like regular comments"`
+	PreserveDeclared          bool     `long:"preserve-declared" description:"Keep words matching types, functions, variables, constants, fields and parameters declared in the package unchanged"`
+	PreserveSingleCaps        bool     `long:"preserve-single-caps" description:"Keep standalone single uppercase letters, like math variables in \"// P(X)\", unchanged"`
+	StripObvious              bool     `long:"strip-obvious" description:"Remove standalone in-function comments stating the obvious, like \"// Return the result\""`
+	ObviousPatterns           []string `long:"obvious-pattern" description:"Regular expression matching an obvious comment for --strip-obvious, replaces the default patterns (can be used multiple times)"`
+}
+
+// OutputWriters holds writers for stdout and stderr, and the level of log messages to write
+type OutputWriters struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	Level  slog.Level // minimal level of log messages, info by default
+}
+
+// debugf writes a debug message to stderr, shown only with the debug level
+func (w OutputWriters) debugf(format string, args ...any) {
+	w.logf(slog.LevelDebug, w.Stderr, format, args...)
+}
+
+// infof writes an informational status message, like "Updated: file.go", to stdout
+func (w OutputWriters) infof(format string, args ...any) {
+	w.logf(slog.LevelInfo, w.Stdout, format, args...)
+}
+
+// warnf writes a warning to stderr
+func (w OutputWriters) warnf(format string, args ...any) {
+	w.logf(slog.LevelWarn, w.Stderr, format, args...)
+}
+
+// errorf writes an error message to stderr
+func (w OutputWriters) errorf(format string, args ...any) {
+	w.logf(slog.LevelError, w.Stderr, format, args...)
+}
+
+// logf writes the message if its level is not below the writers level
+func (w OutputWriters) logf(level slog.Level, out io.Writer, format string, args ...any) {
+	if level < w.Level {
+		return
+	}
+	fmt.Fprintf(out, format, args...)
+}
+
+// DefaultWriters returns the standard output writers (os.Stdout, os.Stderr)
+func DefaultWriters() OutputWriters {
+	return OutputWriters{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+// Define custom errors for special exit cases
+var (
+	ErrVersionRequested = errors.New("version info requested")
+	ErrHelpRequested    = errors.New("help requested")
+	ErrParsingFailed    = errors.New("parsing failed")
+)
+
+// Main runs the unfuck-ai-comments command with the process arguments, it exits the process on errors
+func Main() {
+	// use default writers (os.Stdout, os.Stderr)
+	writers := DefaultWriters()
+
+	// parse command line options
+	opts, p, err := parseCommandLineOptions(writers)
+
+	// handle special exit cases
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrVersionRequested) || errors.Is(err, ErrHelpRequested):
+			os.Exit(0)
+		case errors.Is(err, ErrParsingFailed):
+			os.Exit(1)
+		default:
+			writers.errorf("Error: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// set the level of log messages
+	if err := writers.Level.UnmarshalText([]byte(opts.LogLevel)); err != nil {
+		writers.errorf("Error: %s\n", err)
+		os.Exit(1)
+	}
+	if opts.Verbose {
+		writers.Level = slog.LevelDebug
+	}
+
+	// color package disables colors for non-terminal output itself, the flag and environment override it
+	color.NoColor = noColor(opts.NoColor, color.NoColor, os.LookupEnv)
+
+	failed, err := runCommand(opts, p, os.Args[1:], writers)
+	if err != nil {
+		writers.errorf("Error: %s\n", err)
+		os.Exit(1)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// runCommand processes files selected by the command and options parsed from args. returns true if the run failed
+// and has to exit with non-zero code, like check mode finding comments to fix, and an error for invalid options
+func runCommand(opts Options, p *flags.Parser, args []string, writers OutputWriters) (bool, error) {
+	result, err := resolveMode(opts, p)
+	if err != nil {
+		return false, err
+	}
+	req, err := newProcessRequest(opts, result.Mode)
+	if err != nil {
+		return false, err
+	}
+
+	// rewrite log accumulates history across runs, so it is opened for appending
+	if opts.RewriteLog != "" {
+		logFile, err := os.OpenFile(opts.RewriteLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return false, fmt.Errorf("open rewrite log: %w", err)
+		}
+		defer func() { _ = logFile.Close() }()
+		req.RewriteLog = logFile
+	}
+
+	// files without changes are remembered between runs made with the same options
+	if opts.Cache {
+		if req.Cache, err = loadCache(cacheFileName, cacheKey(opts), writers); err != nil {
+			return false, err
+		}
+	}
+
+	toProcess, err := filesToProcess(opts, result)
+	if err != nil {
+		return false, err
+	}
+	moduleRequest := moduleRequestFunc(opts, args, result.Mode, &req)
+
+	if !confirmInplaceRun(opts, result, toProcess, &req, moduleRequest, writers) {
+		return true, nil
+	}
+
+	// process all patterns and print summary
+	switch {
+	case result.Workspace:
+		processWorkspace(toProcess, &req, moduleRequest, writers)
+	case result.Watch:
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		watchDirs(ctx, toProcess, &req, watchInterval, watchDebounce, writers)
+		cancel()
+	default:
+		processPatterns(toProcess, &req, writers)
+	}
+
+	if req.Cache != nil {
+		if err := req.Cache.save(cacheFileName); err != nil {
+			writers.errorf("Error: %s\n", err)
+		}
+	}
+	if result.Mode == "export" {
+		if err := writeCommentsExport(opts.ExportComments, req.ExportedComments); err != nil {
+			return false, err
+		}
+	}
+	return req.failed(), nil
+}
+
+// confirmInplaceRun asks for confirmation of in-place runs modifying more files than --confirm-threshold,
+// as broad patterns may rewrite a lot of files by accident, see confirmLargeRun. files of all workspace modules
+// are counted together. returns false to abort the run
+func confirmInplaceRun(opts Options, result ProcessingResult, toProcess []string, req *ProcessRequest,
+	moduleRequest func(dir string) (ProcessRequest, error), writers OutputWriters) bool {
+	if result.Mode != "inplace" || opts.ConfirmThreshold <= 0 || opts.Yes || result.Watch {
+		return true
+	}
+	prePass := patternsPrePass(toProcess)
+	if result.Workspace {
+		prePass = func(r *ProcessRequest, w OutputWriters) { processWorkspace(toProcess, r, moduleRequest, w) }
+	}
+	stat, err := os.Stdin.Stat()
+	interactive := err == nil && stat.Mode()&os.ModeCharDevice != 0
+	return confirmLargeRun(prePass, req, opts.ConfirmThreshold, os.Stdin, interactive, writers)
+}
+
+// outputModeOption is an option selecting an output mode without file writes, which replaces the mode of the command
+type outputModeOption struct {
+	mode string
+	flag string
+}
+
+// outputModeOptions returns output modes selected by the options
+func outputModeOptions(opts Options) []outputModeOption {
+	all := []struct {
+		set bool
+		outputModeOption
+	}{
+		{opts.Output == "count", outputModeOption{"count", "--output"}},
+		{opts.ReportFormat == "github", outputModeOption{"github", "--format"}},
+		{opts.JSONLines, outputModeOption{"jsonl", "--jsonl"}},
+		{opts.JSON, outputModeOption{"json", "--json"}},
+		{opts.DensityReport, outputModeOption{"density", "--density-report"}},
+		{opts.StyleReport, outputModeOption{"style", "--style-report"}},
+		{opts.ExportComments != "", outputModeOption{"export", "--export-comments"}},
+		{opts.Quarantine != "", outputModeOption{"quarantine", "--quarantine"}},
+		{opts.VerifyIdempotent, outputModeOption{"verify", "--verify-idempotent"}},
+	}
+	var res []outputModeOption
+	for _, o := range all {
+		if o.set {
+			res = append(res, o.outputModeOption)
+		}
+	}
+	return res
+}
+
+// resolveMode returns the processing mode and patterns of the command, with the output mode selected by options.
+// conflicting commands and options are rejected, instead of one of them silently winning
+func resolveMode(opts Options, p *flags.Parser) (ProcessingResult, error) {
+	result := determineProcessingMode(opts, p)
+	if err := checkCommandOptions(opts, p, result); err != nil {
+		return result, err
+	}
+
+	selected := outputModeOptions(opts)
+	switch {
+	case len(selected) == 0:
+		return result, nil
+	case len(selected) > 1:
+		return result, fmt.Errorf("%s and %s can't be used together", selected[0].flag, selected[1].flag)
+	}
+
+	// output modes report changes of the files to process, commands with their own output don't allow them.
+	// github annotations report what check fails on, so check allows them
+	commands := []string{"run", "diff", "staged", "workspace"}
+	if selected[0].mode == "github" {
+		commands = append(commands, "check")
+	}
+	if p.Active != nil && !slices.Contains(commands, p.Active.Name) {
+		return result, fmt.Errorf("%s can't be used with the %s command", selected[0].flag, p.Active.Name)
+	}
+	result.Mode = selected[0].mode
+	return result, nil
+}
+
+// checkCommandOptions returns an error if options contradict the command or each other
+func checkCommandOptions(opts Options, p *flags.Parser, result ProcessingResult) error {
+	// pre-commit hook rewrites the files it gets, modes without file writes contradict it
+	if err := checkPreCommit(opts, p); err != nil {
+		return err
+	}
+
+	// compare mode needs to know what to compare
+	if result.Mode == "compare" && !opts.Compare.FullVsTitle {
+		return errors.New("no comparison selected, use --full-vs-title")
+	}
+
+	switch {
+	case result.Watch && (opts.Since != "" || opts.DryRun):
+		return errors.New("--since and --dry can't be used with the watch command")
+	case (result.Staged || result.Workspace) && opts.Since != "":
+		return errors.New("--since can't be used with the staged and workspace commands")
+	case opts.DryRun && p.Active != nil && !slices.Contains([]string{"run", "staged", "workspace"}, p.Active.Name):
+		return fmt.Errorf("--dry can't be used with the %s command", p.Active.Name)
+	}
+	return nil
+}
+
+// filesToProcess returns patterns to process: files staged for commit, files changed since the revision,
+// or patterns of the command, the current directory by default. nothing staged or changed means nothing to process
+func filesToProcess(opts Options, result ProcessingResult) ([]string, error) {
+	switch {
+	case result.Staged:
+		return gitStagedFiles(".")
+	case opts.Since != "":
+		files, err := gitChangedFiles(".", opts.Since)
+		if err != nil {
+			return nil, err
+		}
+		return filesInPatterns(files, result.Patterns)
+	}
+	return patterns(result.Patterns), nil
+}
+
+// filesInPatterns returns the files selected by any of the patterns, keeping their order. a recursive pattern
+// selects files under its directory, other patterns the Go files they match, like files of a directory
+// or a glob. all files are returned if there are no patterns, malformed patterns are rejected
+func filesInPatterns(files, patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		return files, nil
+	}
+
+	selected := map[string]bool{}
+	var dirs []string // absolute directories of recursive patterns
+	for _, pattern := range patterns {
+		if isRecursivePattern(pattern) {
+			if dir, err := filepath.Abs(extractDirectoryFromPattern(pattern)); err == nil {
+				dirs = append(dirs, dir)
+			}
+			continue
+		}
+		matches, err := findGoFilesFromPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range matches {
+			selected[filepath.Clean(file)] = true
+		}
+	}
+
+	var res []string
+	for _, file := range files {
+		if selected[filepath.Clean(file)] || slices.ContainsFunc(dirs, func(dir string) bool { return inDir(dir, file) }) {
+			res = append(res, file)
+		}
+	}
+	return res, nil
+}
+
+// inDir checks if the file is under the absolute directory
+func inDir(dir, file string) bool {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, abs)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// moduleRequestFunc returns the function making the request of a workspace module. each module has its own
+// config file, the command line args are applied over it. the rewrite log and the cache are shared with the request
+// of the run, the cache only if the module options are the same
+func moduleRequestFunc(opts Options, args []string, mode string, req *ProcessRequest) func(dir string) (ProcessRequest, error) {
+	return func(dir string) (ProcessRequest, error) {
+		modOpts, err := moduleOptions(dir, args)
+		if err != nil {
+			return ProcessRequest{}, err
+		}
+		modReq, err := newProcessRequest(modOpts, mode)
+		if err != nil {
+			return ProcessRequest{}, err
+		}
+		modReq.RewriteLog = req.RewriteLog
+		if cacheKey(modOpts) == cacheKey(opts) {
+			modReq.Cache = req.Cache // remembered results are valid for the same options only
+		}
+		return modReq, nil
+	}
+}
+
+// checkPreCommit returns an error if --pre-commit is combined with a command other than run,
+// with a dry run or with an output mode not modifying files
+func checkPreCommit(opts Options, p *flags.Parser) error {
+	if !opts.PreCommit {
+		return nil
+	}
+	if p.Active != nil && p.Active.Name != "run" {
+		return fmt.Errorf("--pre-commit can't be used with the %s command", p.Active.Name)
+	}
+	if opts.DryRun {
+		return errors.New("--pre-commit can't be used with --dry")
+	}
+	if selected := outputModeOptions(opts); len(selected) > 0 {
+		return fmt.Errorf("--pre-commit can't be used with %s", selected[0].flag)
+	}
+	return nil
+}
+
+// confirmLargeRun counts files the in-place run would modify with a dry pre-pass and asks for confirmation
+// if there are more than threshold of them. non-interactive runs are aborted. returns false to abort the run.
+// the pre-pass processes files with a copy of the request in count mode
+func confirmLargeRun(prePass func(req *ProcessRequest, writers OutputWriters), req *ProcessRequest, threshold int,
+	in io.Reader, interactive bool, writers OutputWriters) bool {
+	dryReq := *req
+	dryReq.OutputMode, dryReq.FailFast = "count", false
+	dryReq.FilesAnalyzed, dryReq.FilesUpdated, dryReq.TotalChanges, dryReq.CharsChanged = 0, 0, 0, 0
+	dryReq.FilesFailed = 0
+	prePass(&dryReq, OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+	if dryReq.FilesUpdated <= threshold {
+		return true
+	}
+
+	if !interactive {
+		writers.errorf("Error: %d files would be modified, more than confirmation threshold %d, use --yes to confirm\n",
+			dryReq.FilesUpdated, threshold)
+		return false
+	}
+	fmt.Fprintf(writers.Stderr, "%d files would be modified, continue? [y/N] ", dryReq.FilesUpdated)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	writers.errorf("Aborted\n")
+	return false
+}
+
+// patternsPrePass returns the pre-pass of confirmLargeRun processing the patterns
+func patternsPrePass(args []string) func(req *ProcessRequest, writers OutputWriters) {
+	return func(req *ProcessRequest, writers OutputWriters) {
+		for _, pattern := range args {
+			if pattern == stdinPattern {
+				continue // stdin can be read only once and never modifies files
+			}
+			processPattern(pattern, req, writers)
+		}
+	}
+}
+
+// newProcessRequest validates the options and creates the process request with them,
+// the output mode is decided by the caller
+func newProcessRequest(opts Options, mode string) (ProcessRequest, error) {
+	if err := validateOptions(opts); err != nil {
+		return ProcessRequest{}, err
+	}
+
+	// load the lists of proper nouns and terms to preserve, both are kept in their canonical form
+	properNouns, err := loadWordLists(slices.Concat(opts.ProperNouns, opts.WordsFile)...)
+	if err != nil {
+		return ProcessRequest{}, err
+	}
+
+	// banners of generators to skip
+	var generatedBy []*regexp.Regexp
+	if len(opts.IgnoreGeneratedBy) > 0 {
+		if generatedBy, err = generatorPatterns(splitList(opts.IgnoreGeneratedBy)); err != nil {
+			return ProcessRequest{}, err
+		}
+	}
+
+	newFiles, err := statusFiles(opts)
+	if err != nil {
+		return ProcessRequest{}, err
+	}
+
+	// compile patterns of obvious comments to remove
+	var obviousPatterns []*regexp.Regexp
+	if opts.StripObvious {
+		if obviousPatterns, err = compileObviousPatterns(opts.ObviousPatterns); err != nil {
+			return ProcessRequest{}, err
+		}
+	}
+
+	var maxDepth *int
+	if opts.MaxDepth >= 0 {
+		maxDepth = &opts.MaxDepth
+	}
+
+	// process files by a worker per CPU unless set explicitly
+	jobs := opts.Jobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+
+	// quarantine copies are made inside the walked directories, don't pick them up
+	skipPatterns := slices.Clone(opts.Skip)
+	if opts.Quarantine != "" {
+		skipPatterns = append(skipPatterns, filepath.Clean(opts.Quarantine))
+	}
+
+	return ProcessRequest{
+		OutputMode:       mode,
+		TitleCase:        !opts.Full, // title case is default, full resets it
+		LeadingCapsOnly:  opts.LeadingCaps,
+		Capitalize:       opts.Capitalize,
+		GroupAware:       opts.GroupAware,
+		Sentence:         opts.Sentence,
+		Trim:             opts.Trim,
+		EnsureSpace:      opts.EnsureSpace,
+		Format:           opts.Format,
+		GofmtBin:         opts.GofmtBin,
+		Imports:          opts.Imports,
+		Lenient:          opts.Lenient,
+		TabWidth:         opts.TabWidth,
+		UseSpaces:        opts.UseSpaces,
+		SkipPatterns:     skipPatterns,
+		IncludePatterns:  opts.Include,
+		Backup:           opts.Backup,
+		BackupExt:        opts.BackupExt,
+		BackupDir:        opts.BackupDir,
+		MinWords:         opts.MinWords,
+		MinUpperRun:      opts.MinUpperRun,
+		DensityThreshold: opts.DensityThreshold,
+		PreviewLimit:     opts.PreviewLimit,
+		SideBySide:       opts.SideBySide,
+		DiffFormat:       opts.DiffFormat,
+		DiffContext:      opts.Context,
+		LineEndings:      opts.LineEndings,
+		OnlyChanged:      opts.OnlyChanged,
+		ShowChurn:        opts.ShowChurn,
+		ShowLocations:    opts.ShowLocations,
+		PreCommit:        opts.PreCommit,
+		FailFast:         opts.FailFast,
+		Jobs:             jobs,
+		PackageName:      opts.PackageName,
+		SkipHidden:       !opts.NoSkipHidden, // hidden files are skipped by default
+		NewFiles:         newFiles,
+		GeneratedBy:      generatedBy,
+		QuarantineDir:    opts.Quarantine,
+		MaxDepth:         maxDepth,
+
+		BannerThreshold:           opts.BannerThreshold,
+		NormalizeDirectiveSpacing: opts.NormalizeDirectiveSpacing,
+		PreserveExportedDocs:      opts.PreserveExportedDocs,
+		PreserveColonHeaders:      opts.PreserveColonHeaders,
+		PreserveSingleCaps:        opts.PreserveSingleCaps,
+		PreserveDeclared:          opts.PreserveDeclared,
+		DocComments:               opts.DocComments,
+		DocSlash:                  opts.DocSlash,
+		AssertDocsPreserved:       opts.AssertDocsPreserved,
+		ProperNouns:               properNouns,
+		Acronyms:                  acronymsPattern(splitList(opts.Acronyms)),
+		ObviousPatterns:           obviousPatterns,
+		DirectivePrefixes:         opts.DirectivePrefixes,
+		KeepPrefixes:              opts.KeepPrefixes,
+		KeepPrefixOnly:            opts.KeepPrefixOnly,
+		NoKeepIndicators:          opts.NoKeepIndicators,
+	}, nil
+}
+
+// validateOptions returns an error if option values are invalid or options can't be used together
+func validateOptions(opts Options) error {
+	// backups with a wrong extension could be mistaken for sources
+	if err := validateBackupExt(opts.BackupExt); err != nil {
+		return err
+	}
+
+	switch {
+	// capitalizing is the inverse of other modes, combining them makes no sense
+	case opts.Capitalize && (opts.Full || opts.Title || opts.LeadingCaps):
+		return errors.New("--capitalize can't be used with --full, --title or --normalize-leading-caps-only")
+	// sentences are converted only in title case, other modes ignore sentence boundaries
+	case opts.Sentence && (opts.Full || opts.LeadingCaps):
+		return errors.New("--sentence can't be used with --full or --normalize-leading-caps-only")
+	case opts.MaxDepth < -1:
+		return errors.New("--max-depth can't be below -1, -1 means unlimited")
+	// negative context would make hunks end before they start
+	case opts.Context < 0:
+		return errors.New("--context can't be negative")
+	// converted doc comments would always fail the check
+	case opts.DocComments && opts.AssertDocsPreserved:
+		return errors.New("--doc-comments can't be used with --assert-docs-preserved")
+	}
+	return nil
+}
+
+// statusFiles returns absolute paths of new or modified files according to git status, to limit processing to them.
+// with both options files of either kind are returned, nil means all files are processed
+func statusFiles(opts Options) (map[string]bool, error) {
+	var statusMatchers []func(string) bool
+	if opts.NewFilesOnly {
+		statusMatchers = append(statusMatchers, gitAdded)
+	}
+	if opts.Dirty {
+		statusMatchers = append(statusMatchers, gitModified)
+	}
+	if len(statusMatchers) == 0 {
+		return nil, nil
+	}
+	return gitStatusFiles(".", statusMatchers...)
+}
+
+// parseCommandLineOptions parses command line arguments and returns options
+func parseCommandLineOptions(writers OutputWriters) (Options, *flags.Parser, error) {
+	var opts Options
+	p := flags.NewParser(&opts, flags.Default)
+	p.LongDescription = "Convert in-function comments to lowercase while preserving comments outside functions"
+
+	// check for standalone --version/-v flag before regular parsing
+	if os.Getenv("GO_FLAGS_COMPLETION") == "" && len(os.Args) == 2 && (os.Args[1] == "-v" || os.Args[1] == "--version") {
+		showVersionInfo(writers.Stdout)
+		return opts, p, ErrVersionRequested
+	}
+
+	// defaults from the config file are set before parsing, so command line flags override them
+	wd, err := os.Getwd()
+	if err != nil {
+		writers.errorf("Error: get current directory: %s\n", err)
+		return opts, p, ErrParsingFailed
+	}
+	if err := loadConfig(wd, &opts); err != nil {
+		writers.errorf("Error: %s\n", err)
+		return opts, p, ErrParsingFailed
+	}
+
+	// handle parsing errors
+	if _, err := p.Parse(); err != nil {
+		var flagsErr *flags.Error
+		if errors.As(err, &flagsErr) && errors.Is(flagsErr.Type, flags.ErrHelp) {
+			return opts, p, ErrHelpRequested
+		}
+
+		writers.errorf("Error: %s\n", err)
+		return opts, p, ErrParsingFailed
+	}
+	overrideConfigCase(&opts, p)
+
+	// display version information if requested through the regular option
+	if os.Getenv("GO_FLAGS_COMPLETION") == "" && opts.Version {
+		showVersionInfo(writers.Stdout)
+		return opts, p, ErrVersionRequested
+	}
+
+	return opts, p, nil
+}
+
+// showVersionInfo displays the version information from Go's build info
+func showVersionInfo(w io.Writer) {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		version := info.Main.Version
+		if version == "" {
+			version = "dev"
+		}
+		fmt.Fprintf(w, "unfuck-ai-comments %s\n", version)
+	} else {
+		fmt.Fprintln(w, "unfuck-ai-comments (version unknown)")
+	}
+}
+
+// buildVersion returns the version of the binary with its VCS revision, empty if unknown
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	res := info.Main.Version
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" || s.Key == "vcs.modified" {
+			res += " " + s.Value
+		}
+	}
+	return res
+}
+
+// ProcessingResult holds the result of determining the processing mode
+type ProcessingResult struct {
+	Mode      string
+	Patterns  []string
+	Workspace bool // patterns are workspace roots to search for modules
+	Staged    bool // files staged in git are processed, the current directory is not a default
+	Watch     bool // patterns are directories to watch for saved files
+}
+
+// determineProcessingMode figures out the processing mode and file patterns
+func determineProcessingMode(opts Options, p *flags.Parser) ProcessingResult {
+	// workspace command supports dry run with its own roots
+	if p.Active != nil && p.Active.Name == "workspace" {
+		mode := "inplace"
+		if opts.DryRun {
+			mode = "diff"
+		}
+		return ProcessingResult{Mode: mode, Patterns: opts.Workspace.Args.Roots, Workspace: true}
+	}
+
+	// watch command always processes files in place
+	if p.Active != nil && p.Active.Name == "watch" {
+		return ProcessingResult{Mode: "inplace", Patterns: opts.Watch.Args.Dirs, Watch: true}
+	}
+
+	// staged command supports dry run, files to process are found later
+	if p.Active != nil && p.Active.Name == "staged" {
+		mode := "inplace"
+		if opts.DryRun {
+			mode = "diff"
+		}
+		return ProcessingResult{Mode: mode, Staged: true}
+	}
+
+	// if dry run is enabled, return diff mode with run patterns
+	if opts.DryRun {
+		return ProcessingResult{
+			Mode:     "diff",
+			Patterns: opts.Run.Args.Patterns,
+		}
+	}
+
+	// get processing mode and patterns based on active command
+	if p.Active != nil {
+		switch p.Active.Name {
+		case "run":
+			return ProcessingResult{
+				Mode:     "inplace",
+				Patterns: opts.Run.Args.Patterns,
+			}
+		case "diff":
+			return ProcessingResult{
+				Mode:     "diff",
+				Patterns: opts.Diff.Args.Patterns,
+			}
+		case "print":
+			return ProcessingResult{
+				Mode:     "print",
+				Patterns: opts.Print.Args.Patterns,
+			}
+		case "check":
+			return ProcessingResult{
+				Mode:     "check",
+				Patterns: opts.Check.Args.Patterns,
+			}
+		case "compare":
+			return ProcessingResult{
+				Mode:     "compare",
+				Patterns: opts.Compare.Args.Patterns,
+			}
+		}
+	}
+
+	// default to inplace mode
+	return ProcessingResult{
+		Mode:     "inplace",
+		Patterns: nil,
+	}
+}
+
+// patterns to process, defaulting to current directory
+func patterns(p []string) []string {
+	res := p
+	if len(res) == 0 {
+		res = []string{"."}
+	}
+	return res
+}
+
+// splitList splits comma-separated values of a repeatable option, like "a,b" or "a" "b", dropping empty values
+func splitList(values []string) []string {
+	var res []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				res = append(res, item)
+			}
+		}
+	}
+	return res
+}
+
+// acronymsPattern returns the pattern matching a word written exactly as one of the acronyms, with an optional
+// plural "s" like in "IDs". "Id" or "api" don't match. nil is returned for no acronyms
+func acronymsPattern(acronyms []string) *regexp.Regexp {
+	if len(acronyms) == 0 {
+		return nil
+	}
+	quoted := make([]string, 0, len(acronyms))
+	for _, a := range acronyms {
+		quoted = append(quoted, regexp.QuoteMeta(a))
+	}
+	return regexp.MustCompile(`^(?:` + strings.Join(quoted, "|") + `)s?$`)
+}
+
+// loadWordLists reads words of all the lists, skipping empty file names
+func loadWordLists(fileNames ...string) ([]string, error) {
+	var res []string
+	for _, fileName := range fileNames {
+		if fileName == "" {
+			continue
+		}
+		words, err := loadWordList(fileName)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, words...)
+	}
+	return res, nil
+}
+
+// loadWordList reads a newline-delimited list of words from a file, skipping empty lines and # comments
+func loadWordList(fileName string) ([]string, error) {
+	data, err := os.ReadFile(fileName) //nolint:gosec // file name comes from the command line
+	if err != nil {
+		return nil, fmt.Errorf("read word list %s: %w", fileName, err)
+	}
+
+	var res []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		res = append(res, line)
+	}
+	return res, nil
+}
+
+// noColor decides if colors are disabled by the flag or the environment: NO_COLOR set to any value
+// or CLICOLOR=0 disable colors, CLICOLOR_FORCE set to anything but 0 enables them even for non-terminal output.
+// the flag and NO_COLOR take precedence, the detected setting is kept if none of them is set
+func noColor(flag, detected bool, lookupEnv func(string) (string, bool)) bool {
+	if _, ok := lookupEnv("NO_COLOR"); ok || flag {
+		return true
+	}
+	if force, ok := lookupEnv("CLICOLOR_FORCE"); ok && force != "" && force != "0" {
+		return false
+	}
+	if cli, ok := lookupEnv("CLICOLOR"); ok && cli == "0" {
+		return true
+	}
+	return detected
+}
@@ -0,0 +1,919 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/jessevdk/go-flags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCLIInvocation tests the CLI by simulating command line invocation
+// This tests the whole process without calling main() directly
+func TestCLIInvocation(t *testing.T) {
+
+	// helper function to remove whitespace for comparison
+	removeWhitespace := func(s string) string {
+		re := regexp.MustCompile(`\s+`)
+		return re.ReplaceAllString(s, "")
+	}
+
+	// create a temporary directory for test files
+	tempDir := t.TempDir()
+
+	// create a test file
+	testFile := filepath.Join(tempDir, "cli_test_file.go")
+	content := `package test
+func TestFunc() {
+	// THIS is a comment that should be CONVERTED
+}`
+	err := os.WriteFile(testFile, []byte(content), 0o600)
+	require.NoError(t, err, "Failed to write test file")
+
+	// change to temp dir to simulate CLI environment
+	t.Chdir(tempDir)
+
+	t.Run("inplace mode", func(t *testing.T) {
+		// reset test file
+		err := os.WriteFile("cli_test_file.go", []byte(content), 0o600)
+		require.NoError(t, err, "Failed to reset test file")
+
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process file directly using the processfile function
+		processFile("cli_test_file.go", &ProcessRequest{OutputMode: "inplace"}, writers)
+
+		// verify output
+		output := stdoutBuf.String()
+		assert.Contains(t, output, "Updated:", "Should show update message")
+
+		// check file was modified
+		modifiedContent, err := os.ReadFile("cli_test_file.go")
+		require.NoError(t, err, "Failed to read modified file")
+
+		expectedContent := `package test
+func TestFunc() {
+	// this is a comment that should be converted
+}`
+
+		// compare normalized content (removing line breaks and whitespace)
+		assert.Equal(t, removeWhitespace(expectedContent), removeWhitespace(string(modifiedContent)),
+			"File content doesn't match expected")
+	})
+
+	t.Run("diff mode", func(t *testing.T) {
+		// reset test file
+		err := os.WriteFile("cli_test_file.go", []byte(content), 0o600)
+		require.NoError(t, err, "Failed to reset test file")
+
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process file directly in diff mode
+		processFile("cli_test_file.go", &ProcessRequest{OutputMode: "diff"}, writers)
+
+		// verify diff output contains lowercase conversion
+		output := stdoutBuf.String()
+		assert.True(t, strings.Contains(output, "THIS") && strings.Contains(output, "this"),
+			"Diff should show comment conversion")
+
+		// file should not be modified in diff mode
+		unmodifiedContent, err := os.ReadFile("cli_test_file.go")
+		require.NoError(t, err, "Failed to read file")
+		assert.Equal(t, content, string(unmodifiedContent),
+			"File should not be modified in diff mode")
+	})
+
+	t.Run("print mode", func(t *testing.T) {
+		// reset test file
+		err := os.WriteFile("cli_test_file.go", []byte(content), 0o600)
+		require.NoError(t, err, "Failed to reset test file")
+
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process file directly in print mode
+		processFile("cli_test_file.go", &ProcessRequest{OutputMode: "print"}, writers)
+
+		// verify printed output
+		output := stdoutBuf.String()
+		assert.Contains(t, output, "// this is a comment",
+			"Output should contain modified comment")
+
+		// file should not be modified in print mode
+		unmodifiedContent, err := os.ReadFile("cli_test_file.go")
+		require.NoError(t, err, "Failed to read file")
+		assert.Equal(t, content, string(unmodifiedContent),
+			"File should not be modified in print mode")
+	})
+}
+
+// TestMainFunctionMock creates a mock version of main to test all branches
+func TestMainFunctionMock(t *testing.T) {
+	// create a temporary directory for test files
+	tempDir := t.TempDir()
+
+	// save current directory
+	currentDir, err := os.Getwd()
+	require.NoError(t, err, "Failed to get working directory")
+
+	// change to temp dir
+	t.Chdir(tempDir)
+
+	// ensure we restore the working directory after the test
+	defer func() {
+		t.Chdir(currentDir)
+	}()
+
+	// create a test file with comments
+	testFile := filepath.Join(tempDir, "mock_test.go")
+	content := `package test
+func Test() {
+	// THIS SHOULD be converted
+}`
+	err = os.WriteFile(testFile, []byte(content), 0o600)
+	require.NoError(t, err, "Failed to write test file")
+
+	// mock version of main
+	mockMain := func(outputMode string, dryRun, showHelp, noColor bool, patterns []string) string {
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// set color setting
+		color.NoColor = noColor
+
+		// if dry-run is set, override output mode to diff
+		if dryRun {
+			outputMode = "diff"
+		}
+
+		// show help if requested
+		if showHelp {
+			fmt.Fprintf(writers.Stdout, "unfuck-ai-comments - Convert in-function comments to lowercase\n")
+			fmt.Fprintf(writers.Stdout, "\nUsage:\n")
+			fmt.Fprintf(writers.Stdout, "  unfuck-ai-comments [options] [file/pattern...]\n")
+			fmt.Fprintf(writers.Stdout, "\nOptions:\n")
+			fmt.Fprintf(writers.Stdout, "-output (inplace|print|diff) - Output mode\n")
+			fmt.Fprintf(writers.Stdout, "-dry-run - Don't modify files, just show what would be changed\n")
+			fmt.Fprintf(writers.Stdout, "-help - Show usage information\n")
+			fmt.Fprintf(writers.Stdout, "-no-color - Disable colorized output\n")
+			fmt.Fprintf(writers.Stdout, "\nExamples:\n")
+			fmt.Fprintf(writers.Stdout, "  unfuck-ai-comments                       # Process all .go files in current directory\n")
+			return "help displayed"
+		}
+
+		// if no patterns specified, use current directory
+		if len(patterns) == 0 {
+			patterns = []string{"."}
+		} else {
+			// convert absolute paths to relative within the tempDir
+			for i, p := range patterns {
+				if filepath.IsAbs(p) {
+					// use relative paths to ensure we stay within the tempDir
+					rel, err := filepath.Rel(tempDir, p)
+					if err == nil {
+						patterns[i] = rel
+					}
+				}
+			}
+		}
+
+		// process each pattern
+		for _, pattern := range patterns {
+			req := ProcessRequest{OutputMode: outputMode, TitleCase: false, Format: false, SkipPatterns: []string{}}
+			processPattern(pattern, &req, writers)
+		}
+
+		return stdoutBuf.String()
+	}
+
+	// test cases
+	tests := []struct {
+		name       string
+		outputMode string
+		dryRun     bool
+		showHelp   bool
+		noColor    bool
+		patterns   []string
+		verify     func(string)
+	}{
+		{
+			name:     "help flag",
+			showHelp: true,
+			verify: func(output string) {
+				assert.Equal(t, "help displayed", output, "Help should be displayed")
+			},
+		},
+		{
+			name:     "dry run flag",
+			dryRun:   true,
+			patterns: []string{"mock_test.go"},
+			verify: func(output string) {
+				assert.Contains(t, output, "---", "Dry run should show diff")
+				assert.Contains(t, output, "+++", "Dry run should show diff")
+			},
+		},
+		{
+			name:       "no color flag",
+			noColor:    true,
+			outputMode: "diff",
+			patterns:   []string{"mock_test.go"},
+			verify: func(output string) {
+				assert.True(t, color.NoColor, "NoColor should be true")
+			},
+		},
+		{
+			name:       "default directory",
+			outputMode: "inplace",
+			patterns:   []string{},
+			verify: func(output string) {
+				// this might be empty if no .go files in current dir, or might show files processed
+				// just ensuring it doesn't crash
+			},
+		},
+		{
+			name:       "explicit file",
+			outputMode: "inplace",
+			patterns:   []string{"mock_test.go"},
+			verify: func(output string) {
+				assert.Contains(t, output, "Updated:", "Should report file was updated")
+			},
+		},
+	}
+
+	// run test cases
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// reset color setting
+			color.NoColor = false
+
+			// reset the test file if needed
+			if !tc.showHelp {
+				err := os.WriteFile("mock_test.go", []byte(content), 0o600)
+				require.NoError(t, err, "Failed to reset test file")
+			}
+
+			// run mock main
+			output := mockMain(tc.outputMode, tc.dryRun, tc.showHelp, tc.noColor, tc.patterns)
+
+			// verify output
+			tc.verify(output)
+		})
+	}
+}
+
+// TestBackupFlagPropagation tests that the backup flag is properly passed from Options to ProcessRequest
+func TestBackupFlagPropagation(t *testing.T) {
+	t.Run("backup flag should be properly passed to ProcessRequest", func(t *testing.T) {
+		// create mock options with backup flag enabled
+		opts := Options{
+			Backup: true,
+		}
+
+		// create a process request using the options
+		req := ProcessRequest{
+			OutputMode:   "inplace",
+			TitleCase:    !opts.Full, // title case is default, full resets it
+			Format:       opts.Format,
+			SkipPatterns: opts.Skip,
+			Backup:       opts.Backup,
+		}
+
+		// verify the backup flag was properly passed
+		assert.True(t, req.Backup, "Backup flag should be passed from Options to ProcessRequest")
+
+		// create mock options with backup flag disabled
+		opts = Options{
+			Backup: false,
+		}
+
+		// create a process request using the options
+		req = ProcessRequest{
+			OutputMode:   "inplace",
+			TitleCase:    !opts.Full,
+			Format:       opts.Format,
+			SkipPatterns: opts.Skip,
+			Backup:       opts.Backup,
+		}
+
+		// verify the backup flag was properly passed
+		assert.False(t, req.Backup, "Backup flag should be properly passed as false from Options to ProcessRequest")
+	})
+}
+
+// TestModeSelection tests the mode selection logic
+func TestModeSelection(t *testing.T) {
+	// test the logic using determineProcessingMode directly
+	t.Run("dry run sets diff mode", func(t *testing.T) {
+		opts := Options{
+			DryRun: true,
+			Run: struct {
+				Args struct {
+					Patterns []string `positional-arg-name:"FILE/PATTERN" description:"Files or patterns to process (default: current directory)"`
+				} `positional-args:"yes"`
+			}{
+				Args: struct {
+					Patterns []string `positional-arg-name:"FILE/PATTERN" description:"Files or patterns to process (default: current directory)"`
+				}{
+					Patterns: []string{"file.go"},
+				},
+			},
+		}
+
+		p := flags.NewParser(&opts, flags.Default)
+		result := determineProcessingMode(opts, p)
+
+		assert.Equal(t, "diff", result.Mode, "Dry run should set mode to diff")
+		assert.Equal(t, []string{"file.go"}, result.Patterns, "Patterns should be properly passed")
+	})
+
+	t.Run("explicit modes via commands", func(t *testing.T) {
+		// test each command mode
+		commandModes := map[string]string{
+			"run":   "inplace",
+			"diff":  "diff",
+			"print": "print",
+			"check": "check",
+		}
+
+		for cmdName, expectedMode := range commandModes {
+			t.Run(cmdName+" command", func(t *testing.T) {
+				opts := Options{}
+				p := flags.NewParser(&opts, flags.Default)
+
+				// simulate command selection
+				cmd := p.Find(cmdName)
+				require.NotNil(t, cmd, "Command should exist")
+				p.Active = cmd
+
+				// set test pattern
+				switch cmdName {
+				case "run":
+					opts.Run.Args.Patterns = []string{"file.go"}
+				case "diff":
+					opts.Diff.Args.Patterns = []string{"file.go"}
+				case "print":
+					opts.Print.Args.Patterns = []string{"file.go"}
+				case "check":
+					opts.Check.Args.Patterns = []string{"file.go"}
+				}
+
+				result := determineProcessingMode(opts, p)
+
+				assert.Equal(t, expectedMode, result.Mode,
+					"Command '%s' should set mode to '%s'", cmdName, expectedMode)
+				assert.Equal(t, []string{"file.go"}, result.Patterns,
+					"Patterns should be properly passed")
+			})
+		}
+	})
+}
+
+// TestNoColor tests disabling and forcing colors by the flag and the environment
+func TestNoColor(t *testing.T) {
+	tbl := []struct {
+		name           string
+		flag, detected bool
+		env            map[string]string
+		expected       bool
+	}{
+		{name: "nothing set, terminal", expected: false},
+		{name: "nothing set, not a terminal", detected: true, expected: true},
+		{name: "flag", flag: true, expected: true},
+		{name: "NO_COLOR", env: map[string]string{"NO_COLOR": "1"}, expected: true},
+		{name: "empty NO_COLOR", env: map[string]string{"NO_COLOR": ""}, expected: true},
+		{name: "CLICOLOR=0", env: map[string]string{"CLICOLOR": "0"}, expected: true},
+		{name: "CLICOLOR=1", env: map[string]string{"CLICOLOR": "1"}, expected: false},
+		{name: "CLICOLOR=1, not a terminal", detected: true, env: map[string]string{"CLICOLOR": "1"}, expected: true},
+		{name: "CLICOLOR_FORCE", detected: true, env: map[string]string{"CLICOLOR_FORCE": "1"}, expected: false},
+		{name: "CLICOLOR_FORCE=0", detected: true, env: map[string]string{"CLICOLOR_FORCE": "0"}, expected: true},
+		{name: "CLICOLOR_FORCE over CLICOLOR=0", env: map[string]string{"CLICOLOR_FORCE": "1", "CLICOLOR": "0"}, expected: false},
+		{name: "NO_COLOR over CLICOLOR_FORCE", env: map[string]string{"CLICOLOR_FORCE": "1", "NO_COLOR": "1"}, expected: true},
+		{name: "flag over CLICOLOR_FORCE", flag: true, env: map[string]string{"CLICOLOR_FORCE": "1"}, expected: true},
+	}
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			lookupEnv := func(key string) (string, bool) {
+				v, ok := tt.env[key]
+				return v, ok
+			}
+			assert.Equal(t, tt.expected, noColor(tt.flag, tt.detected, lookupEnv))
+		})
+	}
+}
+
+// TestParseCommandLineOptions tests the command line option parsing logic
+func TestParseCommandLineOptions(t *testing.T) {
+	// save the original os.Args
+	oldArgs := os.Args
+	defer func() {
+		os.Args = oldArgs
+	}()
+
+	t.Run("basic parsing", func(t *testing.T) {
+		// create buffer for capturing output
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// set up minimal command line
+		os.Args = []string{"unfuck-ai-comments", "run", "file.go"}
+
+		// parse options
+		opts, p, err := parseCommandLineOptions(writers)
+
+		// verify no error was returned
+		require.NoError(t, err, "Should parse without error")
+
+		// verify correct values
+		assert.Equal(t, []string{"file.go"}, opts.Run.Args.Patterns, "Should capture file pattern")
+		assert.NotNil(t, p, "Parser should not be nil")
+		assert.False(t, opts.Full, "Full flag should default to false")
+		assert.False(t, opts.Version, "Version flag should default to false")
+	})
+
+	t.Run("version flag as standalone", func(t *testing.T) {
+		// create buffer for capturing output
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// set up --version flag
+		os.Args = []string{"unfuck-ai-comments", "--version"}
+
+		// parse options
+		_, _, err := parseCommandLineOptions(writers)
+
+		// verify version error was returned
+		require.ErrorIs(t, err, ErrVersionRequested, "Should return version requested error")
+
+		// verify version info was printed
+		assert.Contains(t, stdoutBuf.String(), "unfuck-ai-comments", "Version info should be printed")
+	})
+
+	t.Run("full flag", func(t *testing.T) {
+		// create buffer for capturing output
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// set up command with --full flag
+		os.Args = []string{"unfuck-ai-comments", "--full", "run", "file.go"}
+
+		// parse options
+		opts, _, err := parseCommandLineOptions(writers)
+
+		// verify no error was returned
+		require.NoError(t, err, "Should parse without error")
+
+		// verify correct values
+		assert.True(t, opts.Full, "Full flag should be set to true")
+	})
+
+	t.Run("no-color flag", func(t *testing.T) {
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+
+		os.Args = []string{"unfuck-ai-comments", "--no-color", "diff", "file.go"}
+		opts, _, err := parseCommandLineOptions(writers)
+		require.NoError(t, err)
+		assert.True(t, opts.NoColor)
+
+		os.Args = []string{"unfuck-ai-comments", "diff", "file.go"}
+		opts, _, err = parseCommandLineOptions(writers)
+		require.NoError(t, err)
+		assert.False(t, opts.NoColor, "colors are not disabled by default")
+	})
+
+	t.Run("help flag", func(t *testing.T) {
+		// create buffer for capturing output
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// set up --help flag
+		os.Args = []string{"unfuck-ai-comments", "--help"}
+
+		// parse options
+		_, _, err := parseCommandLineOptions(writers)
+
+		// verify help error was returned
+		assert.ErrorIs(t, err, ErrHelpRequested, "Should return help requested error")
+	})
+
+	t.Run("invalid flag", func(t *testing.T) {
+		// create buffer for capturing output
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// set up invalid flag
+		os.Args = []string{"unfuck-ai-comments", "--nonexistent-flag"}
+
+		// parse options
+		_, _, err := parseCommandLineOptions(writers)
+
+		// verify parsing failed error was returned
+		require.ErrorIs(t, err, ErrParsingFailed, "Should return parsing failed error")
+		assert.Contains(t, stderrBuf.String(), "Error:", "Should print error message")
+	})
+}
+
+// TestOutputWriters tests the OutputWriters functionality
+func TestOutputWriters(t *testing.T) {
+	t.Run("default writers", func(t *testing.T) {
+		writers := DefaultWriters()
+		assert.Equal(t, os.Stdout, writers.Stdout, "Default stdout should be os.Stdout")
+		assert.Equal(t, os.Stderr, writers.Stderr, "Default stderr should be os.Stderr")
+	})
+
+	t.Run("custom writers", func(t *testing.T) {
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// write to the writers
+		fmt.Fprint(writers.Stdout, "test stdout")
+		fmt.Fprint(writers.Stderr, "test stderr")
+
+		// verify content
+		assert.Equal(t, "test stdout", stdoutBuf.String(), "Should capture stdout content")
+		assert.Equal(t, "test stderr", stderrBuf.String(), "Should capture stderr content")
+	})
+}
+
+// TestGoGenerateInvocation tests the self-formatting "run --fmt a.go b.go" invocation used by go:generate
+func TestGoGenerateInvocation(t *testing.T) {
+	tempDir := t.TempDir()
+	unformatted := "package test\n\nfunc Example(  ) {\n    // This Comment\n    x:=1\n    _ = x\n}\n"
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		err := os.WriteFile(filepath.Join(tempDir, name), []byte(unformatted), 0o600)
+		require.NoError(t, err)
+	}
+	t.Chdir(tempDir)
+
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"unfuck-ai-comments", "run", "--fmt", "a.go", "b.go"}
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+	opts, p, err := parseCommandLineOptions(writers)
+	require.NoError(t, err)
+
+	result := determineProcessingMode(opts, p)
+	assert.Equal(t, "inplace", result.Mode)
+	assert.Equal(t, []string{"a.go", "b.go"}, result.Patterns)
+
+	req := ProcessRequest{OutputMode: result.Mode, TitleCase: !opts.Full, Format: opts.Format}
+	for _, pattern := range patterns(result.Patterns) {
+		processPattern(pattern, &req, writers)
+	}
+
+	assert.Equal(t, 2, req.FilesAnalyzed, "only the explicit files should be analyzed")
+	assert.Equal(t, 2, req.FilesUpdated)
+	for _, name := range []string{"a.go", "b.go"} {
+		res, err := os.ReadFile(name)
+		require.NoError(t, err)
+		assert.Contains(t, string(res), "func Example() {\n\t// this Comment\n\tx := 1", "%s should be converted and formatted", name)
+	}
+	res, err := os.ReadFile("c.go")
+	require.NoError(t, err)
+	assert.Equal(t, unformatted, string(res), "c.go should not be touched")
+}
+
+// TestMaxDepth tests limiting the depth of the directory walk
+func TestMaxDepth(t *testing.T) {
+	content := "package test\n\nfunc Example() {\n\t// Some Comment\n}\n"
+	tempDir := t.TempDir()
+	files := []string{"root.go", filepath.Join("a", "a.go"), filepath.Join("a", "b", "b.go"),
+		filepath.Join("a", "b", "c", "c.go")}
+
+	tbl := []struct {
+		depth     int
+		processed int
+	}{
+		{depth: -1, processed: 4},
+		{depth: 0, processed: 1},
+		{depth: 1, processed: 2},
+		{depth: 2, processed: 3},
+		{depth: 10, processed: 4},
+	}
+
+	for _, tt := range tbl {
+		t.Run(fmt.Sprintf("depth %d", tt.depth), func(t *testing.T) {
+			for _, file := range files {
+				path := filepath.Join(tempDir, file)
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+				require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+			}
+
+			var stdoutBuf, stderrBuf bytes.Buffer
+			req, err := newProcessRequest(Options{MaxDepth: tt.depth, BackupExt: defaultBackupExt}, "inplace")
+			require.NoError(t, err)
+			processPattern(tempDir+"/...", &req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+			assert.Equal(t, tt.processed, req.FilesAnalyzed)
+
+			for i, file := range files {
+				res, err := os.ReadFile(filepath.Join(tempDir, file))
+				require.NoError(t, err)
+				if i < tt.processed {
+					assert.Contains(t, string(res), "// some Comment", file)
+					continue
+				}
+				assert.Contains(t, string(res), "// Some Comment", file+" should not be touched")
+			}
+		})
+	}
+
+	t.Run("option", func(t *testing.T) {
+		var opts Options
+		_, err := flags.NewParser(&opts, flags.Default).ParseArgs([]string{"run"})
+		require.NoError(t, err)
+		req, err := newProcessRequest(opts, "inplace")
+		require.NoError(t, err)
+		assert.Nil(t, req.MaxDepth, "unlimited by default")
+
+		req, err = newProcessRequest(Options{MaxDepth: 0, BackupExt: defaultBackupExt}, "inplace")
+		require.NoError(t, err)
+		require.NotNil(t, req.MaxDepth)
+		assert.Equal(t, 0, *req.MaxDepth, "the option and the request have the same meaning")
+
+		_, err = newProcessRequest(Options{MaxDepth: -2, BackupExt: defaultBackupExt}, "inplace")
+		require.EqualError(t, err, "--max-depth can't be below -1, -1 means unlimited")
+	})
+}
+
+// TestLogLevels tests filtering of log messages by level
+func TestLogLevels(t *testing.T) {
+	tbl := []struct {
+		level             slog.Level
+		debug, info, warn bool
+	}{
+		{level: slog.LevelDebug, debug: true, info: true, warn: true},
+		{level: slog.LevelInfo, info: true, warn: true},
+		{level: slog.LevelWarn, warn: true},
+		{level: slog.LevelError},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			var stdoutBuf, stderrBuf bytes.Buffer
+			writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf, Level: tt.level}
+			writers.debugf("debug %d\n", 1)
+			writers.infof("info %d\n", 2)
+			writers.warnf("warn %d\n", 3)
+			writers.errorf("error %d\n", 4)
+
+			assert.Equal(t, tt.debug, strings.Contains(stderrBuf.String(), "debug 1\n"))
+			assert.Equal(t, tt.info, strings.Contains(stdoutBuf.String(), "info 2\n"))
+			assert.Equal(t, tt.warn, strings.Contains(stderrBuf.String(), "warn 3\n"))
+			assert.Contains(t, stderrBuf.String(), "error 4\n", "errors are always shown")
+		})
+	}
+
+	t.Run("skip notices and status messages", func(t *testing.T) {
+		tempDir := t.TempDir()
+		genFile := filepath.Join(tempDir, "gen.go")
+		file := filepath.Join(tempDir, "file.go")
+		require.NoError(t, os.WriteFile(genFile, []byte("// Code generated by tool. DO NOT EDIT.\n\npackage test\n"), 0o600))
+		require.NoError(t, os.WriteFile(file, []byte("package test\n\nfunc f() {\n\t// Some Comment\n}\n"), 0o600))
+
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+		processPatterns([]string{tempDir}, &ProcessRequest{OutputMode: "inplace"}, writers)
+		assert.Contains(t, stdoutBuf.String(), "Updated: "+file)
+		assert.Empty(t, stderrBuf.String(), "no debug messages by default")
+
+		require.NoError(t, os.WriteFile(file, []byte("package test\n\nfunc f() {\n\t// Some Comment\n}\n"), 0o600))
+		stdoutBuf.Reset()
+		writers.Level = slog.LevelDebug
+		processPatterns([]string{tempDir}, &ProcessRequest{OutputMode: "inplace"}, writers)
+		assert.Contains(t, stderrBuf.String(), "Skipping generated file: "+genFile)
+
+		require.NoError(t, os.WriteFile(file, []byte("package test\n\nfunc f() {\n\t// Some Comment\n}\n"), 0o600))
+		stdoutBuf.Reset()
+		writers.Level = slog.LevelWarn
+		processPatterns([]string{tempDir}, &ProcessRequest{OutputMode: "inplace"}, writers)
+		assert.NotContains(t, stdoutBuf.String(), "Updated:")
+		assert.Contains(t, stdoutBuf.String(), "1 files updated", "summary is part of the output, not a log message")
+	})
+}
+
+// TestPreCommit tests the pre-commit hook invocation with a list of file names
+func TestPreCommit(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	require.NoError(t, os.WriteFile("a.go", []byte("package test\n\nfunc A() {\n\t// Some Comment\n}\n"), 0o600))
+	require.NoError(t, os.WriteFile("b.go", []byte("package test\n\nfunc B() {\n\t// clean comment\n}\n"), 0o600))
+	require.NoError(t, os.WriteFile("c.go", []byte("package test\n\nfunc C() {\n\t// Not Passed\n}\n"), 0o600))
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+	req := &ProcessRequest{OutputMode: "inplace", TitleCase: true, PreCommit: true}
+	processPatterns([]string{"a.go", "b.go"}, req, writers)
+	assert.True(t, req.failed(), "modified files should fail the hook")
+	assert.Equal(t, 1, req.FilesUpdated)
+
+	res, err := os.ReadFile("a.go")
+	require.NoError(t, err)
+	assert.Contains(t, string(res), "// some Comment", "passed file is rewritten")
+	res, err = os.ReadFile("c.go")
+	require.NoError(t, err)
+	assert.Contains(t, string(res), "// Not Passed", "only passed files are processed")
+
+	// second run over the rewritten files passes
+	req = &ProcessRequest{OutputMode: "inplace", TitleCase: true, PreCommit: true}
+	processPatterns([]string{"a.go", "b.go"}, req, writers)
+	assert.False(t, req.failed())
+
+	// without the pre-commit flag changes are not a failure
+	req = &ProcessRequest{OutputMode: "inplace", TitleCase: true}
+	processPatterns([]string{"c.go"}, req, writers)
+	assert.False(t, req.failed())
+
+	t.Run("conflicting options", func(t *testing.T) {
+		tbl := []struct {
+			args []string
+			err  string
+		}{
+			{[]string{"run", "--pre-commit", "a.go"}, ""},
+			{[]string{"run", "--dry", "--pre-commit", "a.go"}, "--pre-commit can't be used with --dry"},
+			{[]string{"check", "--pre-commit", "a.go"}, "--pre-commit can't be used with the check command"},
+			{[]string{"diff", "--pre-commit", "a.go"}, "--pre-commit can't be used with the diff command"},
+			{[]string{"run", "--json", "--pre-commit", "a.go"}, "--pre-commit can't be used with --json"},
+			{[]string{"run", "--output=count", "--pre-commit", "a.go"}, "--pre-commit can't be used with --output"},
+		}
+		for _, tc := range tbl {
+			t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+				var opts Options
+				p := flags.NewParser(&opts, flags.Default&^flags.PrintErrors)
+				_, err := p.ParseArgs(tc.args)
+				require.NoError(t, err)
+				err = checkPreCommit(opts, p)
+				if tc.err == "" {
+					require.NoError(t, err)
+					return
+				}
+				require.EqualError(t, err, tc.err)
+			})
+		}
+	})
+}
+
+// TestResolveMode tests that output modes replace the mode of the command and conflicting options are rejected
+func TestResolveMode(t *testing.T) {
+	tbl := []struct {
+		args []string
+		mode string
+		err  string
+	}{
+		{args: []string{"run", "a.go"}, mode: "inplace"},
+		{args: []string{"run", "--dry", "a.go"}, mode: "diff"},
+		{args: []string{"run", "--json", "a.go"}, mode: "json"},
+		{args: []string{"diff", "--output=count", "a.go"}, mode: "count"},
+		{args: []string{"staged", "--format=github"}, mode: "github"},
+		{args: []string{"check", "--format=github", "a.go"}, mode: "github"},
+		{args: []string{"check", "--output=count", "a.go"}, err: "--output can't be used with the check command"},
+		{args: []string{"workspace", "--style-report"}, mode: "style"},
+		{args: []string{"run", "--dry", "--verify-idempotent", "a.go"}, mode: "verify"},
+		{args: []string{"run", "--json", "--jsonl", "a.go"}, err: "--jsonl and --json can't be used together"},
+		{args: []string{"run", "--output=count", "--quarantine", "q", "a.go"}, err: "--output and --quarantine can't be used together"},
+		{args: []string{"check", "--json", "a.go"}, err: "--json can't be used with the check command"},
+		{args: []string{"print", "--density-report", "a.go"}, err: "--density-report can't be used with the print command"},
+		{args: []string{"compare", "--full-vs-title", "--jsonl", "a.go"}, err: "--jsonl can't be used with the compare command"},
+		{args: []string{"watch", "--json"}, err: "--json can't be used with the watch command"},
+		{args: []string{"check", "--dry", "a.go"}, err: "--dry can't be used with the check command"},
+		{args: []string{"print", "--dry", "a.go"}, err: "--dry can't be used with the print command"},
+		{args: []string{"compare", "a.go"}, err: "no comparison selected, use --full-vs-title"},
+		{args: []string{"watch", "--dry"}, err: "--since and --dry can't be used with the watch command"},
+		{args: []string{"staged", "--since", "main"}, err: "--since can't be used with the staged and workspace commands"},
+		{args: []string{"check", "--pre-commit", "a.go"}, err: "--pre-commit can't be used with the check command"},
+	}
+	for _, tc := range tbl {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			var opts Options
+			p := flags.NewParser(&opts, flags.Default&^flags.PrintErrors)
+			_, err := p.ParseArgs(tc.args)
+			require.NoError(t, err)
+			res, err := resolveMode(opts, p)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.mode, res.Mode)
+		})
+	}
+}
+
+// TestConfirmLargeRun tests asking for confirmation before modifying many files
+func TestConfirmLargeRun(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "package test\n\nfunc Example() {\n\t// Some Comment\n}\n"
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o600))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "clean.go"), []byte("package test\n"), 0o600))
+	args := []string{tempDir}
+
+	tbl := []struct {
+		name        string
+		threshold   int
+		input       string
+		interactive bool
+		confirmed   bool
+		stderr      string
+	}{
+		{name: "below threshold", threshold: 3, confirmed: true},
+		{name: "non-interactive aborts", threshold: 2,
+			stderr: "Error: 3 files would be modified, more than confirmation threshold 2, use --yes to confirm\n"},
+		{name: "answer no", threshold: 2, input: "n\n", interactive: true,
+			stderr: "3 files would be modified, continue? [y/N] Aborted\n"},
+		{name: "empty answer", threshold: 2, input: "", interactive: true,
+			stderr: "3 files would be modified, continue? [y/N] Aborted\n"},
+		{name: "answer yes", threshold: 2, input: "Y\n", interactive: true, confirmed: true,
+			stderr: "3 files would be modified, continue? [y/N] "},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdoutBuf, stderrBuf bytes.Buffer
+			writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+			req := &ProcessRequest{OutputMode: "inplace", TitleCase: true}
+			confirmed := confirmLargeRun(patternsPrePass(args), req, tt.threshold, strings.NewReader(tt.input), tt.interactive,
+				writers)
+			assert.Equal(t, tt.confirmed, confirmed)
+			assert.Equal(t, tt.stderr, stderrBuf.String())
+			assert.Empty(t, stdoutBuf.String())
+			assert.Equal(t, 0, req.FilesAnalyzed, "pre-pass doesn't change the request statistics")
+
+			res, err := os.ReadFile(filepath.Join(tempDir, "a.go"))
+			require.NoError(t, err)
+			assert.Equal(t, content, string(res), "pre-pass doesn't modify files")
+		})
+	}
+
+	t.Run("workspace modules counted together", func(t *testing.T) {
+		root := t.TempDir()
+		for _, module := range []string{"svc1", "svc2"} {
+			require.NoError(t, os.MkdirAll(filepath.Join(root, module), 0o750))
+			require.NoError(t, os.WriteFile(filepath.Join(root, module, "go.mod"), []byte("module "+module+"\n"), 0o600))
+			for _, name := range []string{"a.go", "b.go"} {
+				require.NoError(t, os.WriteFile(filepath.Join(root, module, name), []byte(content), 0o600))
+			}
+		}
+		moduleRequest := func(string) (ProcessRequest, error) {
+			return ProcessRequest{OutputMode: "inplace", TitleCase: true}, nil
+		}
+		prePass := func(r *ProcessRequest, w OutputWriters) { processWorkspace([]string{root}, r, moduleRequest, w) }
+
+		var stderrBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "inplace", TitleCase: true}
+		confirmed := confirmLargeRun(prePass, req, 3, strings.NewReader(""), false, OutputWriters{Stdout: io.Discard, Stderr: &stderrBuf})
+		assert.False(t, confirmed)
+		assert.Contains(t, stderrBuf.String(), "Error: 4 files would be modified, more than confirmation threshold 3")
+
+		res, err := os.ReadFile(filepath.Join(root, "svc1", "a.go"))
+		require.NoError(t, err)
+		assert.Equal(t, content, string(res), "module requests run in the mode of the pre-pass")
+	})
+}
@@ -0,0 +1,90 @@
+package processor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jessevdk/go-flags"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileNames are names of the config file with default options, searched from the current directory up
+var configFileNames = []string{".unfuck.yml", ".unfuck-ai-comments.yml"}
+
+// fileConfig is the content of the config file with default options
+type fileConfig struct {
+	Full   bool     `yaml:"full"`
+	Title  bool     `yaml:"title"`
+	Fmt    bool     `yaml:"fmt"`
+	Backup bool     `yaml:"backup"`
+	Skip   []string `yaml:"skip"`
+}
+
+// loadConfig sets options from the nearest config file in the directory or its parents, if any
+func loadConfig(dir string, opts *Options) error {
+	fileName := findConfigFile(dir)
+	if fileName == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(fileName) //nolint:gosec // config file is searched in the current directory and its parents
+	if err != nil {
+		return fmt.Errorf("read config %s: %w", fileName, err)
+	}
+	var cfg fileConfig
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true) // typos in keys should not be silently ignored
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config %s: %w", fileName, err)
+	}
+
+	if cfg.Full && cfg.Title {
+		return fmt.Errorf("config %s: full and title can't be both set", fileName)
+	}
+
+	opts.Full, opts.Title, opts.Format, opts.Backup, opts.Skip = cfg.Full, cfg.Title, cfg.Fmt, cfg.Backup, cfg.Skip
+	return nil
+}
+
+// overrideConfigCase switches full mode set by the config file back to title mode if --title is given
+// on the command line without --full, command line flags override the config
+func overrideConfigCase(opts *Options, p *flags.Parser) {
+	if p.FindOptionByLongName("title").IsSet() && !p.FindOptionByLongName("full").IsSet() {
+		opts.Full = false
+	}
+}
+
+// moduleOptions returns options of a workspace module: defaults from the nearest config file
+// of the module root or its parents, overridden by the command line arguments
+func moduleOptions(dir string, args []string) (Options, error) {
+	var opts Options
+	if err := loadConfig(dir, &opts); err != nil {
+		return opts, err
+	}
+	p := flags.NewParser(&opts, flags.Default&^flags.PrintErrors)
+	if _, err := p.ParseArgs(args); err != nil {
+		return opts, fmt.Errorf("parse command line: %w", err)
+	}
+	overrideConfigCase(&opts, p)
+	return opts, nil
+}
+
+// findConfigFile returns the config file in the directory or the nearest parent, empty string if not found
+func findConfigFile(dir string) string {
+	for {
+		for _, name := range configFileNames {
+			if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+				return filepath.Join(dir, name)
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
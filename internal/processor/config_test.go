@@ -0,0 +1,116 @@
+package processor
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConfigFile tests loading default options from the config file, overridden by command line flags
+func TestConfigFile(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+
+	root := t.TempDir()
+	sub := filepath.Join(root, "pkg", "sub")
+	require.NoError(t, os.MkdirAll(sub, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".unfuck.yml"),
+		[]byte("full: true\nbackup: true\nskip:\n  - vendor\n  - gen\n"), 0o600))
+	t.Chdir(sub)
+
+	t.Run("config from parent directory", func(t *testing.T) {
+		var stderrBuf bytes.Buffer
+		os.Args = []string{"unfuck-ai-comments", "run"}
+		opts, _, err := parseCommandLineOptions(OutputWriters{Stdout: io.Discard, Stderr: &stderrBuf})
+		require.NoError(t, err, stderrBuf.String())
+		assert.True(t, opts.Full)
+		assert.True(t, opts.Backup)
+		assert.False(t, opts.Format)
+		assert.Equal(t, []string{"vendor", "gen"}, opts.Skip)
+	})
+
+	t.Run("command line overrides config", func(t *testing.T) {
+		os.Args = []string{"unfuck-ai-comments", "--skip", "mocks", "--fmt", "run"}
+		opts, _, err := parseCommandLineOptions(OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"mocks"}, opts.Skip)
+		assert.True(t, opts.Format)
+		assert.True(t, opts.Full)
+	})
+
+	t.Run("title on command line overrides full in config", func(t *testing.T) {
+		os.Args = []string{"unfuck-ai-comments", "--title", "run"}
+		opts, _, err := parseCommandLineOptions(OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		require.NoError(t, err)
+		assert.False(t, opts.Full)
+		req, err := newProcessRequest(opts, "inplace")
+		require.NoError(t, err)
+		assert.True(t, req.TitleCase)
+
+		os.Args = []string{"unfuck-ai-comments", "--title", "--full", "run"}
+		opts, _, err = parseCommandLineOptions(OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		require.NoError(t, err)
+		assert.True(t, opts.Full, "full given on command line too")
+
+		modOpts, err := moduleOptions(sub, []string{"--title", "run"})
+		require.NoError(t, err)
+		assert.False(t, modOpts.Full, "workspace modules apply the command line the same way")
+	})
+
+	t.Run("title in config", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(sub, ".unfuck.yml"), []byte("title: true\n"), 0o600))
+		defer func() { require.NoError(t, os.Remove(filepath.Join(sub, ".unfuck.yml"))) }()
+		os.Args = []string{"unfuck-ai-comments", "run"}
+		opts, _, err := parseCommandLineOptions(OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		require.NoError(t, err)
+		assert.False(t, opts.Full)
+
+		os.Args = []string{"unfuck-ai-comments", "--full", "run"}
+		opts, _, err = parseCommandLineOptions(OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		require.NoError(t, err)
+		assert.True(t, opts.Full, "command line overrides config")
+
+		require.NoError(t, os.WriteFile(filepath.Join(sub, ".unfuck.yml"), []byte("title: true\nfull: true\n"), 0o600))
+		var stderrBuf bytes.Buffer
+		os.Args = []string{"unfuck-ai-comments", "run"}
+		_, _, err = parseCommandLineOptions(OutputWriters{Stdout: io.Discard, Stderr: &stderrBuf})
+		require.ErrorIs(t, err, ErrParsingFailed)
+		assert.Contains(t, stderrBuf.String(), "full and title can't be both set")
+	})
+
+	t.Run("nearest config wins", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(sub, ".unfuck-ai-comments.yml"), []byte("fmt: true\n"), 0o600))
+		defer func() { require.NoError(t, os.Remove(filepath.Join(sub, ".unfuck-ai-comments.yml"))) }()
+		os.Args = []string{"unfuck-ai-comments", "run"}
+		opts, _, err := parseCommandLineOptions(OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		require.NoError(t, err)
+		assert.True(t, opts.Format)
+		assert.False(t, opts.Full)
+		assert.Empty(t, opts.Skip)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(sub, ".unfuck.yml"), []byte("ful: true\n"), 0o600))
+		defer func() { require.NoError(t, os.Remove(filepath.Join(sub, ".unfuck.yml"))) }()
+		var stderrBuf bytes.Buffer
+		os.Args = []string{"unfuck-ai-comments", "run"}
+		_, _, err := parseCommandLineOptions(OutputWriters{Stdout: io.Discard, Stderr: &stderrBuf})
+		require.ErrorIs(t, err, ErrParsingFailed)
+		assert.Contains(t, stderrBuf.String(), "Error: parse config "+filepath.Join(sub, ".unfuck.yml"))
+		assert.Contains(t, stderrBuf.String(), "field ful not found")
+	})
+
+	t.Run("empty config", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(sub, ".unfuck.yml"), nil, 0o600))
+		defer func() { require.NoError(t, os.Remove(filepath.Join(sub, ".unfuck.yml"))) }()
+		os.Args = []string{"unfuck-ai-comments", "run"}
+		opts, _, err := parseCommandLineOptions(OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		require.NoError(t, err)
+		assert.False(t, opts.Full)
+	})
+}
@@ -0,0 +1,1270 @@
+package processor
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// processComments processes all comments in the file
+// returns the list of changes made
+func processComments(fset *token.FileSet, node *ast.File, req *ProcessRequest) []Change {
+	var changes []Change
+	var removedLines []int
+
+	var exportedDocs map[*ast.CommentGroup]bool
+	if req.PreserveExportedDocs {
+		exportedDocs = exportedFieldComments(node)
+	}
+
+	if req.PreserveDeclared {
+		req.declared = req.packageDeclarations(fset.File(node.Pos()).Name(), node)
+	}
+
+	var declDocs map[*ast.CommentGroup][]string
+	if req.DocComments {
+		declDocs = declarationDocs(node)
+	}
+
+	comments := node.Comments[:0]
+	for _, commentGroup := range node.Comments {
+		// never touch the cgo preamble, it is C code compiled along with the file
+		if isCgoPreamble(commentGroup, node) {
+			comments = append(comments, commentGroup)
+			continue
+		}
+
+		// drop obvious comments, like "// Return the result" above "return res"
+		if isObviousComment(commentGroup, node, fset, req.ObviousPatterns) {
+			comment := commentGroup.List[0]
+			pos := fset.Position(comment.Pos())
+			changes = append(changes, Change{File: pos.Filename, Line: pos.Line, Column: pos.Column, Before: comment.Text})
+			removedLines = append(removedLines, pos.Line)
+			continue
+		}
+		comments = append(comments, commentGroup)
+
+		// public API docs of exported fields and methods are kept as is
+		if exportedDocs[commentGroup] {
+			continue
+		}
+
+		names, isDeclDoc := declDocs[commentGroup]
+		changes = append(changes, processCommentGroup(fset, node, commentGroup, names, isDeclDoc, req)...)
+	}
+	node.Comments = comments
+
+	// merge lines of removed comments with the following lines, so the printer doesn't leave empty lines.
+	// merged from the bottom up to keep the numbers of lines not merged yet
+	for _, line := range slices.Backward(removedLines) {
+		fset.File(node.Pos()).MergeLine(line)
+	}
+	return changes
+}
+
+// processCommentGroup converts comments of the group inside functions, structs and const/var blocks,
+// or the doc comment of declarations with the names, and returns the changes made
+func processCommentGroup(fset *token.FileSet, node *ast.File, group *ast.CommentGroup, names []string, isDeclDoc bool,
+	req *ProcessRequest) []Change {
+	var changes []Change
+	for i, comment := range group.List {
+		if skipComment(comment, node, isDeclDoc, req) {
+			continue
+		}
+
+		// check if comment is inside a function, struct, or const/var block
+		if !isDeclDoc && !isCommentInsideFunctionOrStruct(node, comment) {
+			continue
+		}
+
+		// process the comment text
+		orig := comment.Text
+		var processed string
+		if isDeclDoc {
+			processed = convertDocComment(orig, names, req)
+		} else {
+			processed = convertComment(orig, req)
+		}
+		// following lines of a "//" group continue the paragraph started by the first line
+		if req.GroupAware && i > 0 && strings.HasPrefix(orig, "//") {
+			processed = keepLeadingCase(orig, processed)
+		}
+		if orig != processed {
+			comment.Text = processed
+			pos := fset.Position(comment.Pos())
+			changes = append(changes, Change{File: pos.Filename, Line: pos.Line, Column: pos.Column,
+				Before: orig, After: processed})
+		}
+	}
+	return changes
+}
+
+// skipComment checks if the comment is kept as is regardless of its place: build constraints,
+// "IdentifierName is..." docs, and comments with too few words or without shouting if those are required
+func skipComment(comment *ast.Comment, node *ast.File, isDeclDoc bool, req *ProcessRequest) bool {
+	// skip documentation comments that follow the Go standard "IdentifierName is..." pattern,
+	// doc comments of declarations keep only the leading name if processed
+	if !isDeclDoc && isIdentifierDocComment(comment, node) {
+		return true
+	}
+	return skipCommentText(comment.Text, req)
+}
+
+// skipCommentText checks if the comment is kept as is by its text alone, so it applies to files processed
+// line by line too: build constraints, and comments with too few words or without shouting if those are required
+func skipCommentText(text string, req *ProcessRequest) bool {
+	switch {
+	// build constraints are never touched, wherever they are
+	case isBuildConstraint(text):
+		return true
+	// skip short comments, those are usually intentional labels
+	case req.MinWords > 0 && commentWordCount(text) < req.MinWords:
+		return true
+	// skip comments without shouting if only all-caps comments should be converted
+	case req.MinUpperRun > 0 && maxUpperRun(text) < req.MinUpperRun:
+		return true
+	}
+	return false
+}
+
+// defaultObviousPatterns are patterns of comments stating the obvious, like "// Initialize the variable"
+var defaultObviousPatterns = []string{
+	`(?i)^(initialize|init|create|declare|define) (the |a |an )?(new )?\w+( variable)?\.?$`,
+	`(?i)^return (the )?(result|value|error|err|response)\.?$`,
+	`(?i)^(increment|decrement) (the )?(counter|index|count|i)\.?$`,
+	`(?i)^(check|handle) (for |the )?(error|err)s?\.?$`,
+	`(?i)^(loop|iterate) (through|over) (the |all )?\w+\.?$`,
+}
+
+// compileObviousPatterns compiles patterns of obvious comments, the default patterns are used if none provided
+func compileObviousPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		patterns = defaultObviousPatterns
+	}
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid obvious comment pattern %q: %w", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// isObviousComment checks if a comment group is a single standalone line comment in a function body matching
+// any of the obvious comment patterns. trailing comments after code are never obvious, comments of struct fields
+// and var/const blocks describe declarations and are never removed
+func isObviousComment(group *ast.CommentGroup, file *ast.File, fset *token.FileSet, patterns []*regexp.Regexp) bool {
+	if len(patterns) == 0 || len(group.List) != 1 {
+		return false
+	}
+	comment := group.List[0]
+	if !strings.HasPrefix(comment.Text, "//") || commentScope(file, comment) != "function" {
+		return false
+	}
+
+	content := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+	if !slices.ContainsFunc(patterns, func(re *regexp.Regexp) bool { return re.MatchString(content) }) {
+		return false
+	}
+
+	return !isTrailingComment(comment, file, fset)
+}
+
+// isTrailingComment checks if the comment follows code on the same line, like "x := 1 // comment"
+func isTrailingComment(comment *ast.Comment, file *ast.File, fset *token.FileSet) bool {
+	line := fset.Position(comment.Pos()).Line
+	var trailing bool
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil || trailing {
+			return false
+		}
+		if n.End() <= comment.Pos() && fset.Position(n.End()).Line == line {
+			trailing = true
+		}
+		return n.Pos() <= comment.Pos() // only nodes starting before the comment can end before it
+	})
+	return trailing
+}
+
+// exportedFieldComments returns doc and line comments of exported struct fields and interface methods
+func exportedFieldComments(file *ast.File) map[*ast.CommentGroup]bool {
+	res := map[*ast.CommentGroup]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		field, ok := n.(*ast.Field)
+		if !ok || !slices.ContainsFunc(field.Names, func(name *ast.Ident) bool { return name.IsExported() }) {
+			return true
+		}
+		for _, group := range []*ast.CommentGroup{field.Doc, field.Comment} {
+			if group != nil {
+				res[group] = true
+			}
+		}
+		return true
+	})
+	return res
+}
+
+// maxUpperRun returns the length of the longest run of consecutive uppercase letters in a comment
+func maxUpperRun(comment string) int {
+	var res, run int
+	for _, r := range comment {
+		if !unicode.IsUpper(r) {
+			run = 0
+			continue
+		}
+		run++
+		res = max(res, run)
+	}
+	return res
+}
+
+// isCgoPreamble checks if a comment group is the cgo preamble attached to the import "C" declaration
+func isCgoPreamble(group *ast.CommentGroup, file *ast.File) bool {
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			importSpec, ok := spec.(*ast.ImportSpec)
+			if !ok || importSpec.Path == nil || importSpec.Path.Value != `"C"` {
+				continue
+			}
+			if group == importSpec.Doc || (group == genDecl.Doc && len(genDecl.Specs) == 1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// commentWordCount returns the number of words in a comment, not counting the comment markers
+func commentWordCount(comment string) int {
+	content := strings.TrimPrefix(comment, "//")
+	if strings.HasPrefix(comment, "/*") {
+		content = strings.TrimSuffix(strings.TrimPrefix(comment, "/*"), "*/")
+	}
+	return len(strings.Fields(content))
+}
+
+// isBuildConstraint checks if a comment is a build constraint line, like "//go:build linux" or "// +build linux"
+func isBuildConstraint(comment string) bool {
+	return strings.HasPrefix(comment, "//go:build") || strings.HasPrefix(comment, "// +build")
+}
+
+// declarationDocs returns doc comments of functions, methods, types, variables and constants
+// with names of the documented declarations
+func declarationDocs(file *ast.File) map[*ast.CommentGroup][]string {
+	res := map[*ast.CommentGroup][]string{}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				res[d.Doc] = []string{d.Name.Name}
+			}
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			var declNames []string
+			for _, spec := range d.Specs {
+				var specNames []string
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					specNames = []string{s.Name.Name}
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						specNames = append(specNames, name.Name)
+					}
+				}
+				if doc := specDoc(spec); doc != nil {
+					res[doc] = specNames
+				}
+				declNames = append(declNames, specNames...)
+			}
+			if d.Doc != nil {
+				res[d.Doc] = declNames
+			}
+		}
+	}
+	return res
+}
+
+// specDoc returns the doc comment of a type or value spec, nil if none
+func specDoc(spec ast.Spec) *ast.CommentGroup {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		return s.Doc
+	case *ast.ValueSpec:
+		return s.Doc
+	}
+	return nil
+}
+
+// convertDocComment converts a declaration doc comment, keeping the leading word if it is one of
+// the declared names, like "Config" in "// Config holds settings". "Deprecated:" lines are kept as is,
+// tools recognize them by the exact prefix, so are indented code blocks and labels introducing them,
+// like "Example:". block comments are converted line by line, the leading name is looked up after
+// the "*" decoration of javadoc-style comments
+func convertDocComment(comment string, names []string, req *ProcessRequest) string {
+	marker := comment[:2] // "//" or "/*"
+	trimmed := strings.TrimLeftFunc(comment[2:], unicode.IsSpace)
+	if marker == "/*" {
+		trimmed = strings.TrimLeftFunc(comment[2:], func(r rune) bool { return r == '*' || unicode.IsSpace(r) })
+	}
+	if marker == "//" && isKeptDocLine(comment[2:]) {
+		return comment
+	}
+
+	res := convertComment(comment, req)
+	for _, name := range names {
+		if rest, ok := strings.CutPrefix(trimmed, name); ok && (rest == "" || !isIdentRune(rest)) {
+			lead := comment[:len(comment)-len(rest)]
+			res = lead + strings.TrimPrefix(convertComment(marker+rest, req), marker)
+			break
+		}
+	}
+	if marker == "/*" {
+		res = keepDocLines(comment, res)
+	}
+	return res
+}
+
+// keepDocLines restores lines of a block comment kept in doc comments, like "Deprecated:" lines or code blocks
+// indented after the "*" decoration. the converted comment is processed line by line and has the same lines
+// as the original one
+func keepDocLines(original, converted string) string {
+	origLines, convLines := strings.Split(original, "\n"), strings.Split(converted, "\n")
+	if len(origLines) != len(convLines) {
+		return converted
+	}
+	for i, line := range origLines {
+		text := strings.TrimPrefix(line, "/*")
+		if decoration := blockStarRe.FindString(text); decoration != "" {
+			text = text[len(decoration):] // javadoc-style lines are indented after the "*"
+		}
+		if isKeptDocLine(text) {
+			convLines[i] = line
+		}
+	}
+	return strings.Join(convLines, "\n")
+}
+
+// docLabelRe matches a doc comment line with a single word label, like "Example:" introducing a code block
+var docLabelRe = regexp.MustCompile(`^\s*[A-Z]\w*:\s*$`)
+
+// isKeptDocLine checks if a line of a doc comment, without the comment marker, is kept as is: a "Deprecated:"
+// line, a line of an indented code block, like "\tcfg := NewConfig()", or a label like "Example:"
+func isKeptDocLine(text string) bool {
+	if strings.HasPrefix(text, "\t") || strings.HasPrefix(text, "  ") {
+		return strings.TrimSpace(text) != ""
+	}
+	return strings.HasPrefix(strings.TrimLeftFunc(text, unicode.IsSpace), "Deprecated:") || docLabelRe.MatchString(text)
+}
+
+// keepLeadingCase restores the case of the first letter of the original comment in the converted one,
+// the rest of the conversion is kept
+func keepLeadingCase(original, converted string) string {
+	i, j := strings.IndexFunc(original, unicode.IsLetter), strings.IndexFunc(converted, unicode.IsLetter)
+	if i < 0 || j < 0 {
+		return converted
+	}
+	orig, _ := utf8.DecodeRuneInString(original[i:])
+	conv, size := utf8.DecodeRuneInString(converted[j:])
+	if orig == conv || unicode.ToLower(orig) != unicode.ToLower(conv) {
+		return converted
+	}
+	return converted[:j] + string(orig) + converted[j+size:]
+}
+
+// isIdentRune checks if the content starts with a rune allowed in identifiers
+func isIdentRune(content string) bool {
+	r, _ := utf8.DecodeRuneInString(content)
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// isIdentifierDocComment checks if a comment is a Go documentation comment
+// that follows the standard "IdentifierName is..." pattern typically used for documenting
+// constants, variables, functions, and types
+func isIdentifierDocComment(comment *ast.Comment, file *ast.File) bool {
+	commentText := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+
+	// check for both const and var declarations
+	for _, decl := range file.Decls {
+		if genDecl, ok := decl.(*ast.GenDecl); ok && (genDecl.Tok == token.CONST || genDecl.Tok == token.VAR) {
+			// check each specification
+			for _, spec := range genDecl.Specs {
+				if valueSpec, ok := spec.(*ast.ValueSpec); ok && len(valueSpec.Names) > 0 {
+					// check if comment follows the pattern "IdentName is..."
+					for _, name := range valueSpec.Names {
+						if strings.HasPrefix(commentText, name.Name+" ") {
+							return true
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// isCommentInsideFunctionOrStruct checks if a comment is inside a function declaration, struct or interface
+// declaration, var block, or const block
+func isCommentInsideFunctionOrStruct(file *ast.File, comment *ast.Comment) bool {
+	return commentScope(file, comment) != ""
+}
+
+// commentScope returns the kind of the outermost node containing the comment: "function", "struct", "interface",
+// "var" or "const". it returns an empty string for comments outside of functions, structs, interfaces and var/const blocks
+func commentScope(file *ast.File, comment *ast.Comment) string {
+	commentPos := comment.Pos()
+
+	// find if comment is inside a function, struct, interface, var block, or const block
+	var scope string
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil || scope != "" {
+			return false
+		}
+		scope = nodeScope(n, commentPos)
+		return scope == "" // stop traversal once found
+	})
+
+	return scope
+}
+
+// nodeScope returns the kind of the node if the position is inside its body, braces or parentheses:
+// "function", "struct", "interface", "var" or "const". it returns an empty string otherwise
+func nodeScope(n ast.Node, pos token.Pos) string {
+	inside := func(start, end token.Pos) bool { return start <= pos && pos <= end }
+	switch node := n.(type) {
+	case *ast.FuncDecl:
+		// check if comment is inside function body
+		if node.Body != nil && inside(node.Body.Lbrace, node.Body.Rbrace) {
+			return "function"
+		}
+	case *ast.FuncLit:
+		// check if comment is inside function literal body, e.g. in a package level var
+		if node.Body != nil && inside(node.Body.Lbrace, node.Body.Rbrace) {
+			return "function"
+		}
+	case *ast.StructType:
+		// check if comment is inside struct definition (between braces)
+		if node.Fields != nil && inside(node.Fields.Opening, node.Fields.Closing) {
+			return "struct"
+		}
+	case *ast.InterfaceType:
+		// check if comment is inside interface definition (between braces)
+		if node.Methods != nil && inside(node.Methods.Opening, node.Methods.Closing) {
+			return "interface"
+		}
+	case *ast.GenDecl:
+		// variable and constant declarations in blocks, between parentheses
+		if (node.Tok == token.VAR || node.Tok == token.CONST) && node.Lparen != token.NoPos && node.Rparen != token.NoPos &&
+			inside(node.Lparen, node.Rparen) {
+			return node.Tok.String()
+		}
+	}
+	return ""
+}
+
+// specialIndicators that should be preserved in comments
+var specialIndicators = []string{
+	"TODO", "FIXME", "HACK", "XXX", "NOTE", "BUG", "IDEA", "OPTIMIZE",
+	"REVIEW", "TEMP", "DEBUG", "NB", "WARNING", "DEPRECATED", "NOTICE",
+}
+
+// hasSpecialIndicator checks if a comment starts with a special indicator
+func hasSpecialIndicator(content string) bool {
+	return hasIndicator(content, specialIndicators)
+}
+
+// hasIndicator checks if a comment starts with any of the indicators
+func hasIndicator(content string, indicators []string) bool {
+	trimmedContent := strings.TrimSpace(content)
+	for _, indicator := range indicators {
+		if strings.HasPrefix(trimmedContent, indicator) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSpecialIndicator checks if a comment starts with a special indicator or a custom prefix to keep,
+// only custom prefixes are checked if they replace the built-in indicators, none if preservation is disabled
+func (r *ProcessRequest) hasSpecialIndicator(content string) bool {
+	if r.NoKeepIndicators {
+		return false
+	}
+	if !r.KeepPrefixOnly && hasSpecialIndicator(content) {
+		return true
+	}
+	return hasIndicator(content, r.KeepPrefixes)
+}
+
+// cgoDirectives are C preprocessor prefixes used in cgo preamble comments
+var cgoDirectives = []string{
+	"#include", "#cgo", "#define", "#undef", "#if", "#ifdef", "#ifndef", "#else", "#elif", "#endif", "#pragma",
+}
+
+// isGoDirective checks if a comment content is a go directive, like "//go:generate" or "//go:noinline"
+func isGoDirective(content string) bool {
+	return strings.HasPrefix(content, "go:")
+}
+
+// isLineDirective checks if a comment content is a //line directive, like "//line file.go:10"
+func isLineDirective(content string) bool {
+	return strings.HasPrefix(content, "line ")
+}
+
+// isWantDirective checks if a comment content is an analysistest expectation, like `// want "unused variable"`
+func isWantDirective(content string) bool {
+	rest, ok := strings.CutPrefix(strings.TrimLeftFunc(content, unicode.IsSpace), "want ")
+	if !ok {
+		return false
+	}
+	rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+	return strings.HasPrefix(rest, `"`) || strings.HasPrefix(rest, "`")
+}
+
+// isCgoExport checks if a comment content is a cgo export directive, like "//export FuncName"
+func isCgoExport(content string) bool {
+	return strings.HasPrefix(content, "export ")
+}
+
+// isCgoDirective checks if a comment content is a C preprocessor line, like "// #include <stdio.h>"
+func isCgoDirective(content string) bool {
+	trimmedContent := strings.TrimSpace(content)
+	for _, directive := range cgoDirectives {
+		if trimmedContent == directive || strings.HasPrefix(trimmedContent, directive+" ") ||
+			strings.HasPrefix(trimmedContent, directive+"<") || strings.HasPrefix(trimmedContent, directive+"\"") {
+			return true
+		}
+	}
+	return false
+}
+
+// processLineComment handles single line comments (// style)
+// it gets the content after "//" and processes it
+func processLineComment(content string, req *ProcessRequest) string {
+	// trailing whitespace is invisible noise, only the content after "//" is trimmed
+	if req.Trim {
+		content = strings.TrimRightFunc(content, unicode.IsSpace)
+	}
+
+	// "//Comment" gets a space after the marker, directives like "//nolint" have none on purpose
+	if req.EnsureSpace && isMissingSpace(content, req.DirectivePrefixes) {
+		content = " " + content
+	}
+
+	if keepLineComment(content, req) {
+		return "//" + content
+	}
+
+	// doc generators use "///" and "//!" for documentation, even in function bodies,
+	// they are kept unless requested, and the extra marker is kept in any case
+	if strings.HasPrefix(content, "/") || strings.HasPrefix(content, "!") {
+		if !req.DocSlash {
+			return "//" + content
+		}
+		return "//" + content[:1] + processCommentPart(content[1:], getCommentIdentifiers(content[1:]), req)
+	}
+
+	// custom directive prefixes like "sqlc:" keep the directive token, only the rest is processed
+	if token, rest, ok := splitDirectivePrefix(content, req.DirectivePrefixes); ok {
+		return "//" + token + processCommentPart(rest, getCommentIdentifiers(rest), req)
+	}
+
+	// directives like "//counterfeiter:generate . Store" are kept as a whole, unless explained after "//",
+	// custom directive prefixes above keep only the directive token
+	if isWordDirective(content) && indexOutsideURLs(content, "//") < 0 {
+		return "//" + content
+	}
+
+	if res, ok := processDoubleComment(content, req); ok {
+		return res
+	}
+
+	// for normal comments, process the entire content
+	return "//" + processCommentPart(content, getCommentIdentifiers(content), req)
+}
+
+// keepLineComment checks if the line comment content is kept unchanged: comments starting with special indicators,
+// go, line and cgo directives affecting compilation, and analysistest expectations matching diagnostics by exact text
+func keepLineComment(content string, req *ProcessRequest) bool {
+	return req.hasSpecialIndicator(content) || isGoDirective(content) || isLineDirective(content) ||
+		isCgoDirective(content) || isCgoExport(content) || isWantDirective(content)
+}
+
+// processDoubleComment handles double comment format like "nolint:gosec // using math/rand is acceptable for tests"
+// by finding the second "//" and processing the second part only. returns false if there is no second "//"
+func processDoubleComment(content string, req *ProcessRequest) (string, bool) {
+	// try to find different formats of technical comments, "//" of URLs like "https://example.com" is not a separator
+	for _, sep := range []string{" // ", "//", " //"} {
+		if idx := indexOutsideURLs(content, sep); idx >= 0 {
+			// for the first part (typically a directive like "nolint:gosec"), leave it unchanged
+			firstPart := content[:idx]
+			secondPart := content[idx+len(sep):]
+
+			// collapse spacing around the inner marker to "directive // comment" if requested,
+			// directives have no space after the leading "//", so regular comments are not affected
+			if req.NormalizeDirectiveSpacing && firstPart != "" && !unicode.IsSpace(rune(firstPart[0])) {
+				firstPart, sep = strings.TrimRightFunc(firstPart, unicode.IsSpace), " // "
+				secondPart = strings.TrimLeftFunc(secondPart, unicode.IsSpace)
+			}
+
+			// lint rule names in the explanation of a lint directive, like "G304" or "SA1000", keep their case
+			identifiers := getCommentIdentifiers(secondPart)
+			if isLintDirective(firstPart) {
+				identifiers = append(identifiers, lintRuleRe.FindAllString(secondPart, -1)...)
+			}
+
+			// process the second part (actual comment) according to the rules
+			return "//" + firstPart + sep + processCommentPart(secondPart, identifiers, req), true
+		}
+	}
+	return "", false
+}
+
+// directiveTokenRe matches the leading token of directives written right after "//", like "go:generate" or "nolint:gosec"
+var directiveTokenRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*:`)
+
+// isWordDirective checks if a comment content is a "word:" directive written right after "//",
+// like "go:embed", "counterfeiter:generate" or "nolint:gosec". "// hello:world" with a space is a comment
+func isWordDirective(content string) bool {
+	return directiveTokenRe.MatchString(content)
+}
+
+// bareDirectives are directives without a colon, written right after "//", like "//nolint" or "//export Name"
+var bareDirectives = []string{"nolint", "export", "extern", "line", "want"}
+
+// isMissingSpace checks if a line comment content starts with a letter or digit right after "//", like "//Comment",
+// and is not a directive. comments starting with other symbols, like "///" or "//---", are not missing a space
+func isMissingSpace(content string, directivePrefixes []string) bool {
+	r, _ := utf8.DecodeRuneInString(content)
+	if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+		return false
+	}
+	if isWordDirective(content) {
+		return false
+	}
+	firstWord := strings.FieldsFunc(content, func(r rune) bool { return unicode.IsSpace(r) || r == ',' })[0]
+	if slices.Contains(bareDirectives, firstWord) {
+		return false
+	}
+	return !slices.ContainsFunc(directivePrefixes, func(p string) bool { return p != "" && strings.HasPrefix(content, p) })
+}
+
+// lintRuleRe matches names of lint rules, like "G304" of gosec or "SA1000" and "ST1003" of staticcheck
+var lintRuleRe = regexp.MustCompile(`\b[A-Z]{1,3}\d{2,4}\b`)
+
+// isLintDirective checks if the directive part of a comment suppresses linters, like "nolint:gosec" or "lint:ignore"
+func isLintDirective(directive string) bool {
+	directive = strings.TrimSpace(directive)
+	return strings.HasPrefix(directive, "nolint") || strings.HasPrefix(directive, "lint:")
+}
+
+// maxColonHeaderWords is the maximum number of words in a comment to be treated as a section header
+const maxColonHeaderWords = 5
+
+// isColonHeader checks if a comment content is a short phrase ending with a colon, like "Steps:" or "Note the following:"
+func isColonHeader(content string) bool {
+	trimmed := strings.TrimSpace(content)
+	return strings.HasSuffix(trimmed, ":") && len(strings.Fields(trimmed)) <= maxColonHeaderWords
+}
+
+// lowercaseLeadingCaps converts the leading run of all-caps words, like "THIS RETURNS" in
+// "THIS RETURNS the userID", to lowercase and stops at the first word with lowercase letters.
+// a single all-caps word is kept, as it is usually an abbreviation like "HTTP"
+func lowercaseLeadingCaps(content string) string {
+	isShouting := func(word string) bool {
+		hasLetter := false
+		for _, r := range word {
+			if unicode.IsLower(r) || r == '_' || unicode.IsDigit(r) {
+				return false // identifiers like MAX_SIZE or H264 are not shouting
+			}
+			hasLetter = hasLetter || unicode.IsLetter(r)
+		}
+		return hasLetter
+	}
+
+	// find the end of the leading run of all-caps words
+	var words, runEnd int
+	for i := 0; i < len(content); {
+		start := i + len(content[i:]) - len(strings.TrimLeftFunc(content[i:], unicode.IsSpace))
+		end := len(content)
+		if idx := strings.IndexFunc(content[start:], unicode.IsSpace); idx >= 0 {
+			end = start + idx
+		}
+		if start == end || !isShouting(content[start:end]) {
+			break
+		}
+		words++
+		runEnd, i = end, end
+	}
+
+	if words < 2 {
+		return content
+	}
+	return strings.ToLower(content[:runEnd]) + content[runEnd:]
+}
+
+// splitDirectivePrefix splits a comment content starting with one of the directive prefixes
+// into the directive token (with leading whitespace) and the rest of the comment, false if it doesn't start
+// with any of them
+func splitDirectivePrefix(content string, prefixes []string) (string, string, bool) {
+	trimmed := strings.TrimLeftFunc(content, unicode.IsSpace)
+	for _, prefix := range prefixes {
+		if prefix == "" || !strings.HasPrefix(trimmed, prefix) {
+			continue
+		}
+		tokenEnd := len(content) - len(trimmed)
+		if idx := strings.IndexFunc(trimmed, unicode.IsSpace); idx >= 0 {
+			tokenEnd += idx
+		} else {
+			tokenEnd = len(content)
+		}
+		return content[:tokenEnd], content[tokenEnd:], true
+	}
+	return "", "", false
+}
+
+// urlRe matches URLs, like https://Example.com/API, up to the next whitespace
+var urlRe = regexp.MustCompile(`(?i)\bhttps?://\S+`)
+
+// indexOutsideURLs returns the index of the first occurrence of sep in the content outside of URLs, -1 if none
+func indexOutsideURLs(content, sep string) int {
+	urls := urlRe.FindAllStringIndex(content, -1)
+	for start := 0; start < len(content); {
+		idx := strings.Index(content[start:], sep)
+		if idx < 0 {
+			return -1
+		}
+		idx += start
+		if !slices.ContainsFunc(urls, func(loc []int) bool { return loc[0] <= idx && idx < loc[1] }) {
+			return idx
+		}
+		start = idx + 1
+	}
+	return -1
+}
+
+// quotedRe matches double-quoted spans, like "OK", an unterminated quote matches nothing
+var quotedRe = regexp.MustCompile(`"[^"]*"`)
+
+// restoreURLs puts URLs of the original content back into the converted one, they are matched in the same order
+// regardless of case, so URLs keep their host and path as is while the prose around them is converted
+func restoreURLs(original, converted string) string {
+	return restoreMatches(urlRe, original, converted)
+}
+
+// restoreQuoted puts double-quoted spans of the original content back into the converted one,
+// quoted text often refers to an exact value, like "OK" returned on success
+func restoreQuoted(original, converted string) string {
+	return restoreMatches(quotedRe, original, converted)
+}
+
+// restoreMatches replaces matches of re in the converted content with the matches of the original, in order
+func restoreMatches(re *regexp.Regexp, original, converted string) string {
+	orig := re.FindAllString(original, -1)
+	if len(orig) == 0 {
+		return converted
+	}
+	i := 0
+	return re.ReplaceAllStringFunc(converted, func(match string) string {
+		if i >= len(orig) {
+			return match
+		}
+		i++
+		return orig[i-1]
+	})
+}
+
+// wordRe matches a word of letters, digits and underscores
+var wordRe = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+
+// restoreAcronyms puts back the words of the original matching the acronyms pattern, like "HTTP",
+// the converted content has the same words as the original, in a different case
+func restoreAcronyms(acronyms *regexp.Regexp, original, converted string) string {
+	words := wordRe.FindAllString(original, -1)
+	i := 0
+	return wordRe.ReplaceAllStringFunc(converted, func(word string) string {
+		if i >= len(words) {
+			return word
+		}
+		i++
+		if orig := words[i-1]; strings.EqualFold(orig, word) && acronyms.MatchString(orig) {
+			return orig
+		}
+		return word
+	})
+}
+
+// sentenceEndRe matches the end of a sentence, like ". ", "! " or "? "
+var sentenceEndRe = regexp.MustCompile(`[.!?]+\s+`)
+
+// splitSentences splits the content after sentence ends, each part keeps its punctuation and following spaces.
+// dots of abbreviations like "e.g." and punctuation inside URLs don't end a sentence
+func splitSentences(content string) []string {
+	urls := urlRe.FindAllStringIndex(content, -1)
+	var res []string
+	start := 0
+	for _, loc := range sentenceEndRe.FindAllStringIndex(content, -1) {
+		if slices.ContainsFunc(urls, func(url []int) bool { return url[0] <= loc[0] && loc[0] < url[1] }) {
+			continue
+		}
+		words := strings.Fields(content[start:loc[0]])
+		if len(words) == 0 || strings.Contains(words[len(words)-1], ".") {
+			continue
+		}
+		res = append(res, content[start:loc[1]])
+		start = loc[1]
+	}
+	return append(res, content[start:])
+}
+
+// structTagRe matches struct tag fragments, like json:"userID"
+var structTagRe = regexp.MustCompile(`\w+:"[^"]*"`)
+
+// listMarkerRe matches a leading list marker, like "- ", "* ", "1. ", "2) " or "a) "
+var listMarkerRe = regexp.MustCompile(`^(?:[-*]|\d+[.)]|[a-zA-Z]\))\s+`)
+
+// processCommentPart handles the processing of a single comment part
+func processCommentPart(content string, identifiers []string, req *ProcessRequest) string {
+	if keepCommentPart(content, req) {
+		return content
+	}
+
+	// every sentence is converted like a separate comment, sentences starting with indicators are kept
+	if req.Sentence && req.TitleCase && !req.LeadingCapsOnly {
+		if sentences := splitSentences(content); len(sentences) > 1 {
+			single := *req
+			single.Sentence = false
+			var res strings.Builder
+			for _, s := range sentences {
+				if req.hasSpecialIndicator(s) {
+					res.WriteString(s)
+					continue
+				}
+				res.WriteString(processCommentPart(s, identifiers, &single))
+			}
+			return res.String()
+		}
+	}
+
+	if req.LeadingCapsOnly {
+		return restoreQuoted(content, restoreURLs(content, lowercaseLeadingCaps(content)))
+	}
+
+	if !req.TitleCase && !req.Capitalize {
+		return lowercaseCommentPart(content, identifiers, req)
+	}
+	return convertFirstChar(content, identifiers, req)
+}
+
+// keepCommentPart checks if the comment part is kept as is in any mode: banners, struct tags and,
+// if requested, section headers
+func keepCommentPart(content string, req *ProcessRequest) bool {
+	switch {
+	// leave banners like "===== Section =====" alone, lowercasing them is pointless
+	case req.BannerThreshold > 0 && isBannerComment(content, req.BannerThreshold):
+		return true
+	// comments echoing struct tags, like `json:"userID" validate:"required"`, are preserved verbatim
+	case structTagRe.MatchString(content):
+		return true
+	// section headers like "Steps:" are capitalized intentionally
+	case req.PreserveColonHeaders && isColonHeader(content):
+		return true
+	}
+	return false
+}
+
+// lowercaseCommentPart converts the entire comment part to lowercase, restoring identifiers, proper nouns,
+// declared names, acronyms, single capitals if requested, quoted strings and URLs
+func lowercaseCommentPart(content string, identifiers []string, req *ProcessRequest) string {
+	res := strings.ToLower(content)
+	var acronyms []string
+	for _, id := range identifiers {
+		// acronyms like "X1" are restored as whole words only, "box1" is not an acronym
+		if isDigitAcronym(id) {
+			acronyms = append(acronyms, id)
+			continue
+		}
+		res = strings.ReplaceAll(res, strings.ToLower(id), id)
+	}
+	res = restoreWords(res, slices.Concat(acronyms, req.ProperNouns, req.declaredWords(content)))
+	if req.Acronyms != nil {
+		res = restoreAcronyms(req.Acronyms, content, res)
+	}
+	if req.PreserveSingleCaps {
+		res = restoreSingleCaps(content, res)
+	}
+	return restoreQuoted(content, restoreURLs(content, res))
+}
+
+// convertFirstChar converts only the first non-whitespace character of the comment part for title case,
+// to uppercase if capitalizing. list markers are skipped, and the first word is kept if it is an abbreviation,
+// an identifier, a proper noun or a declared name
+func convertFirstChar(content string, identifiers []string, req *ProcessRequest) string {
+	leadingWhitespace := ""
+	remainingContent := content
+	for i, r := range content {
+		if !unicode.IsSpace(r) {
+			leadingWhitespace = content[:i]
+			remainingContent = content[i:]
+			break
+		}
+	}
+
+	// list items like "- Do the thing" or "1. First step" keep the marker, the first letter after it is converted
+	if marker := listMarkerRe.FindString(remainingContent); marker != "" {
+		leadingWhitespace += marker
+		remainingContent = remainingContent[len(marker):]
+	}
+
+	// nothing to convert in empty or whitespace only comments, like "//   "
+	if strings.TrimSpace(remainingContent) == "" {
+		return content
+	}
+
+	// a comment starting with a URL, like "// HTTPS://Example.com/API", has nothing to convert
+	if loc := urlRe.FindStringIndex(remainingContent); loc != nil && loc[0] == 0 {
+		return content
+	}
+
+	if keepFirstWord(remainingContent, identifiers, req) {
+		return content
+	}
+
+	// otherwise convert first character to lowercase, or uppercase if capitalizing
+	// use rune to properly handle multi-byte Unicode characters
+	runes := []rune(remainingContent)
+	firstRune := unicode.ToLower(runes[0])
+	if req.Capitalize {
+		firstRune = unicode.ToUpper(runes[0])
+	}
+	return leadingWhitespace + string(firstRune) + string(runes[1:])
+}
+
+// keepFirstWord checks if the first word of the content is kept with its case: all uppercase abbreviations
+// like AI or CPU, single capitals if requested, identifiers, proper nouns and declared names
+func keepFirstWord(content string, identifiers []string, req *ProcessRequest) bool {
+	// check if the first word is all uppercase (for abbreviations like AI, CPU)
+	firstWordRuneCount := 0
+	isAllUppercase := true
+	// find the end of the first word in bytes for identifier comparison
+	firstWordByteEnd := 0
+	byteIndex := 0
+	for _, r := range content {
+		runeSize := utf8.RuneLen(r)
+		if unicode.IsSpace(r) || !unicode.IsLetter(r) {
+			firstWordByteEnd = byteIndex
+			break
+		}
+		if !unicode.IsUpper(r) {
+			isAllUppercase = false
+		}
+		firstWordRuneCount++
+		byteIndex += runeSize
+	}
+	// if we reached the end without finding a word boundary
+	if firstWordByteEnd == 0 {
+		firstWordByteEnd = len(content)
+	}
+
+	// if first word is all uppercase and at least 2 characters, preserve it. acronyms with digits,
+	// like "S3" or "IPv6", are among identifiers and preserved below
+	if isAllUppercase && firstWordRuneCount >= 2 {
+		return true
+	}
+
+	// a single capital letter may be a variable, like in "P(X) is zero"
+	if req.PreserveSingleCaps && isSingleCap([]rune(content), 0) {
+		return true
+	}
+
+	// check if the first word is in identifiers and preserve it, identifiers with digits like "G304"
+	// are longer than the first word, so they are compared with the whole first field
+	firstWord := content[:firstWordByteEnd]
+	firstField := strings.TrimRight(strings.Fields(content)[0], ",.:;")
+	for _, id := range append(identifiers, req.ProperNouns...) {
+		if strings.EqualFold(id, firstWord) || id == firstField {
+			return true
+		}
+	}
+	return req.declared[firstWord] || req.declared[firstField]
+}
+
+// declaredNames returns names of types, functions, methods, variables and constants declared in the files,
+// including fields, interface methods, receivers, type and function parameters, results and local variables
+func declaredNames(files []*ast.File) map[string]bool {
+	res := map[string]bool{}
+	add := func(exprs ...ast.Expr) {
+		for _, expr := range exprs {
+			if ident, ok := expr.(*ast.Ident); ok && ident.Name != "_" {
+				res[ident.Name] = true
+			}
+		}
+	}
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch d := n.(type) {
+			case *ast.FuncDecl:
+				add(d.Name)
+			case *ast.TypeSpec:
+				add(d.Name)
+			case *ast.ValueSpec:
+				for _, name := range d.Names {
+					add(name)
+				}
+			case *ast.Field: // struct fields, interface methods, receivers, type and function parameters and results
+				for _, name := range d.Names {
+					add(name)
+				}
+			case *ast.AssignStmt:
+				if d.Tok == token.DEFINE {
+					add(d.Lhs...)
+				}
+			case *ast.RangeStmt:
+				if d.Tok == token.DEFINE {
+					add(d.Key, d.Value)
+				}
+			}
+			return true
+		})
+	}
+	return res
+}
+
+// restoreSingleCaps restores standalone single uppercase letters of the original content in the lowercased one
+func restoreSingleCaps(original, lowered string) string {
+	origRunes, res := []rune(original), []rune(lowered)
+	if len(origRunes) != len(res) {
+		return lowered // not a rune to rune conversion, positions don't match
+	}
+	for i := range origRunes {
+		if isSingleCap(origRunes, i) {
+			res[i] = origRunes[i]
+		}
+	}
+	return string(res)
+}
+
+// isSingleCap checks if the rune at i is a standalone uppercase letter, like a math variable in "P(X)".
+// "A" and "I" starting a sentence are words, not variables
+func isSingleCap(runes []rune, i int) bool {
+	isWordRune := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' }
+	if !unicode.IsUpper(runes[i]) || (i > 0 && isWordRune(runes[i-1])) || (i+1 < len(runes) && isWordRune(runes[i+1])) {
+		return false
+	}
+	if runes[i] != 'A' && runes[i] != 'I' {
+		return true
+	}
+	prev := i - 1
+	for prev >= 0 && unicode.IsSpace(runes[prev]) {
+		prev--
+	}
+	return prev >= 0 && !strings.ContainsRune(".!?", runes[prev])
+}
+
+// restoreWords replaces every whole word matching one of the given words case-insensitively
+// with the canonical form of this word, e.g. "postgres" with "Postgres"
+func restoreWords(content string, words []string) string {
+	if len(words) == 0 {
+		return content
+	}
+
+	isWordRune := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' }
+	var res strings.Builder
+	runes := []rune(content)
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			res.WriteRune(runes[i])
+			i++
+			continue
+		}
+		start := i
+		for i < len(runes) && isWordRune(runes[i]) {
+			i++
+		}
+		word := string(runes[start:i])
+		for _, w := range words {
+			if strings.EqualFold(w, word) {
+				word = w
+				break
+			}
+		}
+		res.WriteString(word)
+	}
+	return res.String()
+}
+
+// isBannerComment checks if a comment content is a banner or ascii-art, like "===== Section =====" or "*** Note ***".
+// it is a banner if it has at least bannerMinSymbols symbols and their ratio to all non-space characters
+// is above the threshold, or if it contains a run of at least three repeated symbols. short code like "i++"
+// or "a != b" is not a banner
+func isBannerComment(content string, threshold float64) bool {
+	var symbols, total, run int
+	var prev rune
+	for _, r := range content {
+		if unicode.IsSpace(r) {
+			prev = 0
+			continue
+		}
+		total++
+		if !unicode.IsPunct(r) && !unicode.IsSymbol(r) {
+			prev = 0
+			continue
+		}
+		symbols++
+		if r == prev {
+			run++
+		} else {
+			run = 1
+		}
+		prev = r
+		if run >= 3 && r != '.' { // ellipsis is a normal punctuation, not a banner
+			return true
+		}
+	}
+	return symbols >= bannerMinSymbols && float64(symbols)/float64(total) > threshold
+}
+
+// bannerMinSymbols is the number of symbols a comment needs to be a banner by the ratio of symbols
+const bannerMinSymbols = 5
+
+// getCommentIdentifiers extracts identifiers from a comment
+// identifiers are words with either pascal case or camel case
+func getCommentIdentifiers(content string) []string {
+	isPascalCase := func(s string) bool {
+		// pascal case requires uppercase first letter, at least one more uppercase letter
+		// followed by lowercase, and no consecutive uppercase letters
+		runes := []rune(s)
+		if len(runes) < 2 || !unicode.IsUpper(runes[0]) {
+			return false
+		}
+
+		foundSecondUpper := false
+		for i := 1; i < len(runes); i++ {
+			// check for consecutive uppercase letters (which invalidates pascal case)
+			if unicode.IsUpper(runes[i]) && i > 0 && unicode.IsUpper(runes[i-1]) {
+				return false
+			}
+
+			// valid pattern: uppercase followed by lowercase
+			if unicode.IsUpper(runes[i]) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				foundSecondUpper = true
+			}
+		}
+
+		return foundSecondUpper
+	}
+
+	isCamelCase := func(s string) bool {
+		// if at least one uppercase letter is found, and it's not the first character, it's camel case
+		for i, r := range s {
+			if i == 0 && unicode.IsUpper(r) {
+				return false
+			}
+			if unicode.IsUpper(r) && i > 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	words := strings.Fields(content)
+	var identifiers []string
+	for _, word := range words {
+		if isPascalCase(word) || isCamelCase(word) {
+			identifiers = append(identifiers, word)
+			continue
+		}
+		if acronym := strings.Trim(word, "(),.:;"); isDigitAcronym(acronym) {
+			identifiers = append(identifiers, acronym)
+		}
+	}
+	return identifiers
+}
+
+// isDigitAcronym checks if the word is an acronym with digits, like "UTF8", "S3" or "IPv6". such acronyms start
+// with an uppercase letter and have letters and digits only, either without lowercase letters or with at least
+// two uppercase ones
+func isDigitAcronym(s string) bool {
+	runes := []rune(s)
+	if len(runes) < 2 || !unicode.IsUpper(runes[0]) {
+		return false
+	}
+	var digits, upper, lower int
+	for _, r := range runes {
+		switch {
+		case unicode.IsDigit(r):
+			digits++
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		default:
+			return false
+		}
+	}
+	return digits > 0 && (lower == 0 || upper >= 2)
+}
+
+// convertComment converts a comment according to the request settings, preserving the comment markers
+func convertComment(comment string, req *ProcessRequest) string {
+	if strings.HasPrefix(comment, "//") {
+		content := strings.TrimPrefix(comment, "//")
+		return processLineComment(content, req)
+	}
+	if strings.HasPrefix(comment, "/*") && strings.HasSuffix(comment, "*/") && len(comment) >= len("/**/") {
+		content := strings.TrimSuffix(strings.TrimPrefix(comment, "/*"), "*/")
+		return "/*" + processBlockComment(content, req) + "*/"
+	}
+	return comment
+}
+
+// blockStarRe matches the "*" decoration of a javadoc-style block comment line with a single space after it
+var blockStarRe = regexp.MustCompile(`^\s*\*+ ?`)
+
+// blockDecorationRe matches the leading decoration of a block comment line, like " * " in javadoc-style comments
+var blockDecorationRe = regexp.MustCompile(`^\s*(?:\*+\s*)?`)
+
+// processBlockComment handles the content of block comments (/* */ style) line by line,
+// each line is processed like a line comment, with the leading "*" decoration kept
+func processBlockComment(content string, req *ProcessRequest) string {
+	// "/*line file.go:10*/" is a line directive
+	if isLineDirective(content) {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		decoration := blockDecorationRe.FindString(line)
+		text := line[len(decoration):]
+		if strings.TrimSpace(text) == "" || req.hasSpecialIndicator(text) {
+			continue
+		}
+		lines[i] = decoration + processCommentPart(text, getCommentIdentifiers(text), req)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// convertCommentToLowercase converts a comment to lowercase, preserving the comment markers
+// If comment starts with a special indicator like TODO, FIXME, etc. it remains unchanged
+func convertCommentToLowercase(comment string) string {
+	return convertComment(comment, &ProcessRequest{TitleCase: false})
+}
+
+// convertCommentToTitleCase converts only the first character of a comment to lowercase,
+// If comment starts with a special indicator like TODO, FIXME, etc. it remains unchanged
+func convertCommentToTitleCase(comment string) string {
+	return convertComment(comment, &ProcessRequest{TitleCase: true})
+}
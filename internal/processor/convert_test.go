@@ -0,0 +1,2558 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIsCommentInsideFunction tests the core function that determines if a comment is inside a function body or struct
+func TestIsCommentInsideFunction(t *testing.T) {
+	// create a temporary file for the test
+	tempDir := t.TempDir()
+	testFilePath := filepath.Join(tempDir, "test_func.go")
+
+	// define test source code containing comments in different locations
+	src := `package main
+
+// Package comment should NOT be modified
+// Another package comment
+
+// Function comment should NOT be modified
+func Example() {
+	// This SHOULD be modified
+	x := 1 // This inline comment SHOULD be modified
+	
+	/*
+	 * This multi-line comment
+	 * SHOULD be modified
+	 */
+	
+	// Another comment to modify
+}
+
+// Another function comment should NOT be modified
+func Example2() {
+	// This one too SHOULD be modified
+}
+
+type S struct {
+	// Struct field comment SHOULD be modified (new behavior)
+	Field int
+	
+	// Another field comment SHOULD be modified (new behavior)
+	AnotherField string
+}
+
+func (s S) Method() {
+	// Method comment SHOULD be modified
+}
+
+// Comment before a type should NOT be modified
+type T int
+
+// Interface comment should NOT be modified
+type I interface {
+	// Interface method comment SHOULD be modified
+	Method() error
+}
+
+// Comment between funcs should NOT be modified
+
+// Complex cases with nested blocks
+func ComplexFunc() {
+	// Comment at start SHOULD be modified
+	if true {
+		// Comment in if block SHOULD be modified
+	}
+	
+	for i := 0; i < 10; i++ {
+		// Comment in for loop SHOULD be modified
+	}
+	
+	// Comment before closure SHOULD be modified
+	func() {
+		// Comment inside closure SHOULD be modified
+	}()
+}
+
+// Comment before var block should NOT be modified
+var (
+	// Comment inside var block SHOULD be modified
+	VarX = 1
+	
+	// Another var comment SHOULD be modified
+	VarY = 2
+)
+
+// Comment before const block should NOT be modified
+const (
+	// Comment inside const block SHOULD be modified
+	ConstX = 1
+	
+	// Another const comment SHOULD be modified
+	ConstY = 2
+)
+
+func Goroutines() {
+	defer func() { // Defer trailing comment SHOULD be modified
+		// Comment inside deferred closure SHOULD be modified
+	}()
+	go func() {
+		// Comment inside goroutine SHOULD be modified
+	}()
+}
+
+// Comment before package level func literal should NOT be modified
+var Handler = func() {
+	// Comment inside package level func literal SHOULD be modified
+}
+`
+
+	// write the test file
+	err := os.WriteFile(testFilePath, []byte(src), 0o600)
+	require.NoError(t, err, "Failed to write test file")
+
+	// parse the source
+	file, err := parser.ParseFile(token.NewFileSet(), testFilePath, nil, parser.ParseComments)
+	require.NoError(t, err, "Failed to parse test source")
+
+	// check all comments using classification patterns
+	for _, commentGroup := range file.Comments {
+		for _, comment := range commentGroup.List {
+			inside := isCommentInsideFunctionOrStruct(file, comment)
+			text := comment.Text
+
+			// check if classification is correct
+			switch {
+			case strings.Contains(text, "SHOULD be modified") && !inside:
+				t.Errorf("Comment incorrectly identified as NOT inside function: %q", text)
+			case strings.Contains(text, "should NOT be modified") && inside:
+				t.Errorf("Comment incorrectly identified as inside function: %q", text)
+			case strings.Contains(text, "Package comment") && inside:
+				t.Errorf("Package comment incorrectly identified as inside function: %q", text)
+			case strings.Contains(text, "Function comment") && inside:
+				t.Errorf("Function comment incorrectly identified as inside function: %q", text)
+			}
+		}
+	}
+}
+
+// TestConvertCommentToLowercase tests the comment conversion function with various formats
+func TestConvertCommentToLowercase(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "single line comment",
+			input:    "// This SHOULD Be Converted",
+			expected: "// this should be converted",
+		},
+		{
+			name:     "preserve comment markers",
+			input:    "// UPPER case comment",
+			expected: "// upper case comment",
+		},
+		{
+			name:     "preserve camel and pascal comment",
+			input:    "// This pascalCase, and CamelCase partially Converted",
+			expected: "// this pascalCase, and CamelCase partially converted",
+		},
+		{
+			name:     "comment with special chars",
+			input:    "// Special: @#$%^&*()",
+			expected: "// special: @#$%^&*()",
+		},
+		{
+			name:     "comment with code example",
+			input:    "// Example: const X = 123",
+			expected: "// example: const x = 123",
+		},
+		{
+			name:     "empty comment",
+			input:    "//",
+			expected: "//",
+		},
+		{
+			name:     "comment with leading space",
+			input:    "//  Leading space",
+			expected: "//  leading space",
+		},
+		{
+			name:     "not a comment",
+			input:    "const X = 1",
+			expected: "const X = 1", // should return unchanged
+		},
+		{
+			name:     "TODO comment",
+			input:    "// TODO This is a TODO Item",
+			expected: "// TODO This is a TODO Item", // leave unchanged due to special indicator
+		},
+		{
+			name:     "FIXME comment",
+			input:    "// FIXME This needs FIXING",
+			expected: "// FIXME This needs FIXING", // leave unchanged due to special indicator
+		},
+		{
+			name:     "TODO with punctuation",
+			input:    "// TODO: Fix this ASAP",
+			expected: "// TODO: Fix this ASAP", // leave unchanged due to special indicator
+		},
+		{
+			name:     "TODO at end of comment",
+			input:    "// This is a TODO",
+			expected: "// this is a todo", // todo is only preserved at start of comment
+		},
+		// additional test cases for camelCase and PascalCase identifiers
+		{
+			name:     "camelCase identifier in lowercase mode",
+			input:    "// Example uses someVariableName for testing",
+			expected: "// example uses someVariableName for testing", // camelCase preserved
+		},
+		{
+			name:     "PascalCase identifier in lowercase mode",
+			input:    "// Using OtherVariable in the code",
+			expected: "// using OtherVariable in the code", // pascalCase preserved
+		},
+		{
+			name:     "mixed case with identifiers",
+			input:    "// USING someVariableName AND OtherVariable TOGETHER",
+			expected: "// using someVariableName and OtherVariable together", // identifiers preserved, rest lowercase
+		},
+		{
+			name:     "inline comment with identifier",
+			input:    "// Initialize someVariableName here",
+			expected: "// initialize someVariableName here",
+		},
+		{
+			name:     "technical linter comment with explanation",
+			input:    "//nolint:gosec // Using math/rand is acceptable for tests",
+			expected: "//nolint:gosec // using math/rand is acceptable for tests", // handle two-part comment correctly
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := convertCommentToLowercase(test.input)
+			assert.Equal(t, test.expected, result, "Comment conversion failed")
+		})
+	}
+}
+
+// TestConvertCommentToTitleCase tests the title case comment conversion function
+func TestConvertCommentToTitleCase(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "single line comment",
+			input:    "// This SHOULD Be Converted",
+			expected: "// this SHOULD Be Converted",
+		},
+		{
+			name:     "uppercase first letter with mixed case",
+			input:    "// UPPEr case comment",
+			expected: "// uPPEr case comment",
+		},
+		{
+			name:     "preserve camel and pascal comment in the middle",
+			input:    "// This pascalCase, and CamelCase partially Converted",
+			expected: "// this pascalCase, and CamelCase partially Converted",
+		},
+		{
+			name:     "preserve camel",
+			input:    "// CamelCase partially Converted",
+			expected: "// CamelCase partially Converted",
+		},
+		{
+			name:     "preserve pascal",
+			input:    "// pascalCase partially Converted",
+			expected: "// pascalCase partially Converted",
+		},
+		{
+			name:     "comment with special chars",
+			input:    "// Special: @#$%^&*()",
+			expected: "// special: @#$%^&*()",
+		},
+		{
+			name:     "comment with code example",
+			input:    "// Example: const X = 123",
+			expected: "// example: const X = 123",
+		},
+		{
+			name:     "empty comment",
+			input:    "//",
+			expected: "//",
+		},
+		{
+			name:     "comment with leading space",
+			input:    "//  Leading space",
+			expected: "//  leading space",
+		},
+		{
+			name:     "dash list item",
+			input:    "// - Foo the thing",
+			expected: "// - foo the thing",
+		},
+		{
+			name:     "numbered list item",
+			input:    "// 1. Bar step",
+			expected: "// 1. bar step",
+		},
+		{
+			name:     "star list item",
+			input:    "// * Baz item",
+			expected: "// * baz item",
+		},
+		{
+			name:     "lettered list item with abbreviation",
+			input:    "// a) API call",
+			expected: "// a) API call",
+		},
+		{
+			name:     "dash without space is not a list marker",
+			input:    "// -Flag is Required",
+			expected: "// -Flag is Required",
+		},
+		{
+			name:     "multi-line with indentation",
+			input:    "/*\n * line 1\n * Line 2\n */",
+			expected: "/*\n * line 1\n * line 2\n */", // each line of a block comment is processed
+		},
+		{
+			name:     "TODO comment",
+			input:    "// TODO This is a TODO Item",
+			expected: "// TODO This is a TODO Item", // leave unchanged due to special indicator
+		},
+		{
+			name:     "FIXME comment",
+			input:    "// FIXME This needs FIXING",
+			expected: "// FIXME This needs FIXING", // leave unchanged due to special indicator
+		},
+		{
+			name:     "TODO with punctuation",
+			input:    "// TODO: Fix this ASAP",
+			expected: "// TODO: Fix this ASAP", // leave unchanged due to special indicator
+		},
+		{
+			name:     "TODO comment followed by space and word",
+			input:    "// TODO Fix this now",
+			expected: "// TODO Fix this now", // leave unchanged due to special indicator
+		},
+		// test cases for the new behavior to preserve all-uppercase words
+		{
+			name:     "all uppercase word",
+			input:    "// AI is an abbreviation",
+			expected: "// AI is an abbreviation", // should not change all-uppercase words
+		},
+		{
+			name:     "all uppercase word with special characters",
+			input:    "// AI: is an abbreviation",
+			expected: "// AI: is an abbreviation", // should not change all-uppercase words with punctuation
+		},
+		{
+			name:     "all uppercase multi-character word",
+			input:    "// CPU usage is high",
+			expected: "// CPU usage is high", // should not change all-uppercase words with multiple chars
+		},
+		{
+			name:     "single letter uppercase",
+			input:    "// A single letter",
+			expected: "// a single letter", // should still lowercase single letter words
+		},
+		{
+			name:     "mixed case word with uppercase start",
+			input:    "// APIclient should be converted",
+			expected: "// aPIclient should be converted", // should convert mixed case words
+		},
+		{
+			name:     "multiline with uppercase first word",
+			input:    "/* API documentation\nSecond line */",
+			expected: "/* API documentation\nsecond line */", // should not change all-uppercase words in multiline
+		},
+		// additional test cases for camelCase and PascalCase identifiers
+		{
+			name:     "camelCase identifier at comment start",
+			input:    "// someVariableName should be preserved",
+			expected: "// someVariableName should be preserved", // should preserve camelCase
+		},
+		{
+			name:     "PascalCase identifier at comment start",
+			input:    "// OtherVariable should be preserved",
+			expected: "// OtherVariable should be preserved", // should preserve PascalCase
+		},
+		{
+			name:     "camelCase and PascalCase identifiers in middle",
+			input:    "// Using someVariableName and OtherVariable in code",
+			expected: "// using someVariableName and OtherVariable in code", // only first word should be lowercase
+		},
+		{
+			name:     "multiple camelCase and PascalCase identifiers",
+			input:    "// The someVariableName, OtherVariable, and anotherCamelCase example",
+			expected: "// the someVariableName, OtherVariable, and anotherCamelCase example", // preserve all identifiers
+		},
+		{
+			name:     "technical linter comment with explanation",
+			input:    "//nolint:gosec // Using math/rand is acceptable for tests",
+			expected: "//nolint:gosec // using math/rand is acceptable for tests", // should handle two-part comment correctly
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := convertCommentToTitleCase(test.input)
+			assert.Equal(t, test.expected, result, "Title case conversion failed")
+		})
+	}
+}
+
+// TestUnicodeCommentProcessing tests that Unicode characters in comments are handled correctly
+func TestUnicodeCommentProcessing(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "unicode_test.go")
+
+	// test content with Unicode characters
+	content := `package main
+
+func main() {
+	switch role {
+	case "nova":    // Мария - analytical and pragmatic
+	case "test":    // 你好 - chinese hello
+	case "emoji":   // 🎉 celebration emoji
+	case "mixed":   // Привет world - mixed script
+	}
+}`
+
+	err := os.WriteFile(testFile, []byte(content), 0o600)
+	require.NoError(t, err, "Failed to create test file")
+
+	t.Run("title case mode preserves Unicode", func(t *testing.T) {
+		// capture output
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process file with title case (default)
+		processFile(testFile, &ProcessRequest{OutputMode: "print"}, writers)
+
+		output := stdoutBuf.String()
+		// verify Unicode characters are preserved correctly
+		assert.Contains(t, output, "// мария - analytical and pragmatic", "Cyrillic should be lowercased correctly")
+		assert.Contains(t, output, "// 你好 - chinese hello", "Chinese characters should be preserved")
+		assert.Contains(t, output, "// 🎉 celebration emoji", "Emoji should be preserved")
+		assert.Contains(t, output, "// привет world - mixed script", "Mixed script should be handled correctly")
+
+		// make sure we don't have corrupted characters
+		assert.NotContains(t, output, "ð", "Should not contain corrupted Unicode")
+		assert.NotContains(t, output, "�", "Should not contain replacement character")
+	})
+
+	t.Run("full lowercase mode preserves Unicode", func(t *testing.T) {
+		// capture output
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process file with full lowercase
+		processFile(testFile, &ProcessRequest{OutputMode: "print", TitleCase: true}, writers)
+
+		output := stdoutBuf.String()
+		// verify Unicode characters are preserved correctly in full lowercase mode
+		assert.Contains(t, output, "// мария - analytical and pragmatic", "Cyrillic should be fully lowercased")
+		assert.Contains(t, output, "// 你好 - chinese hello", "Chinese characters should be preserved")
+		assert.Contains(t, output, "// 🎉 celebration emoji", "Emoji should be preserved")
+		assert.Contains(t, output, "// привет world - mixed script", "Mixed script should be fully lowercased")
+
+		// make sure we don't have corrupted characters
+		assert.NotContains(t, output, "ð", "Should not contain corrupted Unicode")
+		assert.NotContains(t, output, "�", "Should not contain replacement character")
+	})
+}
+
+// TestUnicodeEdgeCases tests edge cases for Unicode handling
+func TestUnicodeEdgeCases(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		fullMode    bool
+		description string
+	}{
+		{
+			name:        "Unicode in leading whitespace",
+			input:       "// 　　Hello World", // full-width spaces (U+3000)
+			expected:    "// 　　hello World",
+			fullMode:    false,
+			description: "Should handle Unicode whitespace correctly",
+		},
+		{
+			name:        "Unicode first word detection",
+			input:       "// МАРИЯ is a name",
+			expected:    "// МАРИЯ is a name", // all uppercase Cyrillic preserved
+			fullMode:    false,
+			description: "Should detect all-uppercase Unicode words",
+		},
+		{
+			name:        "Mixed Unicode identifier",
+			input:       "// МарияName is a variable",
+			expected:    "// МарияName is a variable", // preserved as identifier
+			fullMode:    false,
+			description: "Should preserve mixed Unicode identifiers",
+		},
+		{
+			name:        "Unicode at word boundary",
+			input:       "// Test世界 here",
+			expected:    "// test世界 here",
+			fullMode:    false,
+			description: "Should handle Unicode at word boundaries",
+		},
+		{
+			name:        "Single Unicode character comment",
+			input:       "// 世",
+			expected:    "// 世",
+			fullMode:    false,
+			description: "Should handle single Unicode character",
+		},
+		{
+			name:        "Unicode with technical comment",
+			input:       "//nolint:gosec // Мария says it's safe",
+			expected:    "//nolint:gosec // мария says it's safe",
+			fullMode:    false,
+			description: "Should handle Unicode in technical comments",
+		},
+		{
+			name:        "Emoji at start",
+			input:       "// 🎉 Celebration time",
+			expected:    "// 🎉 Celebration time", // emoji is not a letter, so next word is processed
+			fullMode:    false,
+			description: "Should handle emoji at start correctly",
+		},
+		{
+			name:        "Complex Unicode grapheme",
+			input:       "// 👨‍👩‍👧‍👦 Family emoji", // family emoji with ZWJ
+			expected:    "// 👨‍👩‍👧‍👦 Family emoji",
+			fullMode:    false,
+			description: "Should handle complex Unicode graphemes",
+		},
+		{
+			name:        "Right-to-left text",
+			input:       "// مرحبا بالعالم hello", // arabic "Hello world"
+			expected:    "// مرحبا بالعالم hello",
+			fullMode:    false,
+			description: "Should handle RTL text correctly",
+		},
+		{
+			name:        "Unicode identifier preservation in full mode",
+			input:       "// Test МарияName variable",
+			expected:    "// test МарияName variable",
+			fullMode:    true,
+			description: "Should preserve Unicode identifiers in full lowercase mode",
+		},
+		{
+			name:        "Invalid UTF-8 sequence",
+			input:       "// Hello \xc3\x28 world", // invalid UTF-8
+			expected:    "// hello �( world",       // go replaces invalid UTF-8 with replacement character
+			fullMode:    false,
+			description: "Should handle invalid UTF-8 without panic",
+		},
+		{
+			name:        "Incomplete UTF-8 sequence at end",
+			input:       "// Test \xc3", // incomplete UTF-8 at end
+			expected:    "// test �",    // go replaces incomplete UTF-8 with replacement character
+			fullMode:    false,
+			description: "Should handle incomplete UTF-8 sequence",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result string
+			if tt.fullMode {
+				result = convertCommentToLowercase(tt.input)
+			} else {
+				result = convertCommentToTitleCase(tt.input)
+			}
+			assert.Equal(t, tt.expected, result, tt.description)
+		})
+	}
+}
+
+// TestGetCommentIdentifiersUnicode tests identifier extraction with Unicode
+func TestGetCommentIdentifiersUnicode(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    []string
+		description string
+	}{
+		{
+			name:        "Unicode PascalCase",
+			input:       "МарияName is a variable",
+			expected:    []string{"МарияName"},
+			description: "Should detect Unicode PascalCase identifiers",
+		},
+		{
+			name:        "Unicode camelCase",
+			input:       "testМария is a function",
+			expected:    []string{"testМария"},
+			description: "Should detect Unicode camelCase identifiers",
+		},
+		{
+			name:        "Pure Cyrillic not identifier",
+			input:       "Привет мир",
+			expected:    nil,
+			description: "Pure Cyrillic words are not identifiers",
+		},
+		{
+			name:        "Mixed scripts in identifier",
+			input:       "Test世界Name and another世界Test",
+			expected:    []string{"Test世界Name", "another世界Test"},
+			description: "Should handle mixed script identifiers",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := getCommentIdentifiers(tt.input)
+			assert.Equal(t, tt.expected, result, tt.description)
+		})
+	}
+}
+
+// TestTechnicalComments tests the proper handling of technical comments with linter directives
+func TestTechnicalComments(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		titleCase bool
+		expected  string
+	}{
+		{
+			name:      "linter comment with explanation in title case mode",
+			input:     "//nolint:gosec // Using math/rand is ACCEPTABLE for tests",
+			titleCase: true,
+			expected:  "//nolint:gosec // using math/rand is ACCEPTABLE for tests",
+		},
+		{
+			name:      "linter comment with explanation in full lowercase mode",
+			input:     "//nolint:gosec // Using math/rand is ACCEPTABLE for tests",
+			titleCase: false,
+			expected:  "//nolint:gosec // using math/rand is acceptable for tests",
+		},
+		{
+			name:      "linter comment with different spacing",
+			input:     "//nolint:gosec  //   Using math/rand is ACCEPTABLE for tests",
+			titleCase: true,
+			expected:  "//nolint:gosec  //   using math/rand is ACCEPTABLE for tests",
+		},
+		{
+			name:      "linter comment with no space after directive",
+			input:     "//nolint// Using math/rand is ACCEPTABLE for tests",
+			titleCase: true,
+			expected:  "//nolint// using math/rand is ACCEPTABLE for tests",
+		},
+		{
+			name:      "linter comment without space between the markers",
+			input:     "//nolint:gosec//Using math/rand is ACCEPTABLE for tests",
+			titleCase: true,
+			expected:  "//nolint:gosec//using math/rand is ACCEPTABLE for tests",
+		},
+		{
+			name:      "gosec rule in explanation in full lowercase mode",
+			input:     "//nolint // Because Of G304 Issue",
+			titleCase: false,
+			expected:  "//nolint // because of G304 issue",
+		},
+		{
+			name:      "staticcheck rule in explanation in full lowercase mode",
+			input:     "//lint:ignore U1000 // Kept For SA1000, ST1003 Checks",
+			titleCase: false,
+			expected:  "//lint:ignore U1000 // kept for SA1000, ST1003 checks",
+		},
+		{
+			name:      "rule starting the explanation in title case mode",
+			input:     "//nolint:gosec // G304: File Path Is Trusted",
+			titleCase: true,
+			expected:  "//nolint:gosec // G304: File Path Is Trusted",
+		},
+		{
+			name:      "rule-like words outside of lint directives",
+			input:     "// Uses G304 Rule",
+			titleCase: false,
+			expected:  "// uses G304 rule", // kept as an acronym with digits, not as a rule
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var result string
+			if test.titleCase {
+				result = convertCommentToTitleCase(test.input)
+			} else {
+				result = convertCommentToLowercase(test.input)
+			}
+			assert.Equal(t, test.expected, result, "Comment conversion failed")
+		})
+	}
+}
+
+// TestDocConstAndVarComments tests that documentation comments for both const and var declarations
+// keep their original case while other comments inside const/var blocks are properly converted
+func TestDocConstAndVarComments(t *testing.T) {
+	t.Run("Title case mode for constants", func(t *testing.T) {
+		// create a temporary file with const declarations and documentation comments
+		tempDir := t.TempDir()
+		testFile := filepath.Join(tempDir, "const_comments_test.go")
+
+		content := `package test
+
+const SingleConst = 42 // inline comment
+
+// This comment should NOT be converted
+const ExportedConst = 123
+
+const (
+	// InternalConst is a documented constant
+	// This comment should NOT be changed
+	InternalConst = 456
+
+	// This is a regular comment
+	// and should be converted
+	RegularConst = 789 // inline comment SHOULD be converted
+)`
+
+		err := os.WriteFile(testFile, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to write test file")
+
+		// process the file with title case mode
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		processFile(testFile, &ProcessRequest{OutputMode: "inplace", TitleCase: true}, writers)
+
+		// read the processed file
+		modifiedContent, err := os.ReadFile(testFile)
+		require.NoError(t, err, "Failed to read modified file")
+		processedContent := string(modifiedContent)
+
+		// documentation comments should remain unchanged
+		assert.Contains(t, processedContent, "// InternalConst is a documented constant",
+			"Documentation comment for a constant should remain unchanged")
+
+		// regular comments inside const blocks should be converted
+		assert.Contains(t, processedContent, "// this is a regular comment",
+			"Regular comment inside const block should be converted")
+		assert.Contains(t, processedContent, "// and should be converted",
+			"Regular comment continuation should be converted")
+	})
+
+	t.Run("Title case mode for variables", func(t *testing.T) {
+		// create a temporary file with var declarations and documentation comments
+		tempDir := t.TempDir()
+		testFile := filepath.Join(tempDir, "var_comments_test.go")
+
+		content := `package test
+
+var SingleVar = "value" // inline comment
+
+// This comment should NOT be converted
+var ExportedVar = "exported"
+
+var (
+	// InternalVar is a documented variable
+	// This comment should NOT be changed
+	InternalVar = "internal"
+
+	// This is a regular comment
+	// and should be converted
+	RegularVar = "regular" // inline comment SHOULD be converted
+)`
+
+		err := os.WriteFile(testFile, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to write test file")
+
+		// process the file with title case mode
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		processFile(testFile, &ProcessRequest{OutputMode: "inplace", TitleCase: true}, writers)
+
+		// read the processed file
+		modifiedContent, err := os.ReadFile(testFile)
+		require.NoError(t, err, "Failed to read modified file")
+		processedContent := string(modifiedContent)
+
+		// documentation comments should remain unchanged
+		assert.Contains(t, processedContent, "// InternalVar is a documented variable",
+			"Documentation comment for a variable should remain unchanged")
+
+		// regular comments inside var blocks should be converted
+		assert.Contains(t, processedContent, "// this is a regular comment",
+			"Regular comment inside var block should be converted")
+		assert.Contains(t, processedContent, "// and should be converted",
+			"Regular comment continuation should be converted")
+	})
+
+	t.Run("Full lowercase mode for constants", func(t *testing.T) {
+		// create a temporary file with const declarations and documentation comments
+		tempDir := t.TempDir()
+		testFile := filepath.Join(tempDir, "const_comments_test_full.go")
+
+		content := `package test
+
+const SingleConst = 42 // INLINE Comment
+
+// This comment should NOT be converted
+const ExportedConst = 123
+
+const (
+	// MyConst is a documented constant
+	// This comment should NOT be changed
+	MyConst = 456
+
+	// This IS A Regular Comment
+	// AND Should BE Converted
+	RegularConst = 789 // INLINE Comment SHOULD Be Converted
+)`
+
+		err := os.WriteFile(testFile, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to write test file")
+
+		// process the file with FULL lowercase mode
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		processFile(testFile, &ProcessRequest{OutputMode: "inplace"}, writers)
+
+		// read the processed file
+		modifiedContent, err := os.ReadFile(testFile)
+		require.NoError(t, err, "Failed to read modified file")
+		processedContent := string(modifiedContent)
+
+		// check that documentation comments for constants remain unchanged
+		assert.Contains(t, processedContent, "// MyConst is a documented constant",
+			"Documentation comment for a constant should remain unchanged in full mode")
+
+		// check that regular comments inside const blocks are converted
+		assert.Contains(t, processedContent, "// this is a regular comment",
+			"Regular comment inside const block should be fully lowercase in full mode")
+		assert.Contains(t, processedContent, "// and should be converted",
+			"Regular comment continuation should be fully lowercase in full mode")
+	})
+
+	t.Run("Full lowercase mode for variables", func(t *testing.T) {
+		// create a temporary file with var declarations and documentation comments
+		tempDir := t.TempDir()
+		testFile := filepath.Join(tempDir, "var_comments_test_full.go")
+
+		content := `package test
+
+var SingleVar = "value" // INLINE Comment
+
+// This comment should NOT be converted
+var ExportedVar = "exported"
+
+var (
+	// MyVar is a documented variable
+	// This comment should NOT be changed
+	MyVar = "documented"
+
+	// This IS A Regular Comment
+	// AND Should BE Converted
+	RegularVar = "regular" // INLINE Comment SHOULD Be Converted
+)`
+
+		err := os.WriteFile(testFile, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to write test file")
+
+		// process the file with FULL lowercase mode
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		processFile(testFile, &ProcessRequest{OutputMode: "inplace"}, writers)
+
+		// read the processed file
+		modifiedContent, err := os.ReadFile(testFile)
+		require.NoError(t, err, "Failed to read modified file")
+		processedContent := string(modifiedContent)
+
+		// check that documentation comments for variables remain unchanged
+		assert.Contains(t, processedContent, "// MyVar is a documented variable",
+			"Documentation comment for a variable should remain unchanged in full mode")
+
+		// check that regular comments inside var blocks are converted
+		assert.Contains(t, processedContent, "// this is a regular comment",
+			"Regular comment inside var block should be fully lowercase in full mode")
+		assert.Contains(t, processedContent, "// and should be converted",
+			"Regular comment continuation should be fully lowercase in full mode")
+	})
+}
+
+// TestVarConstBlocks tests the handling of comments inside var and const blocks
+func TestVarConstBlocks(t *testing.T) {
+	t.Run("Full lowercase mode", func(t *testing.T) {
+		// create a temporary directory for tests
+		tempDir := t.TempDir()
+		testFile := filepath.Join(tempDir, "var_const_full.go")
+
+		// create test file content with var and const blocks
+		content := `package test
+
+// Package level variable declaration should NOT be modified
+var SingleVar = "test"
+
+// Package level const declaration should NOT be modified
+const SingleConst = "test"
+
+// This comment before var block should NOT be modified
+var (
+	// THIS Comment SHOULD be modified (inside var block)
+	VarInBlock1 = "test1"
+	
+	// ANOTHER Comment to PROCESS
+	VarInBlock2 = "test2"
+)
+
+// This comment before const block should NOT be modified
+const (
+	// THIS Comment SHOULD be modified (inside const block)
+	ConstInBlock1 = "test1"
+	
+	// ANOTHER Comment to PROCESS
+	ConstInBlock2 = "test2"
+)
+
+func Example() {
+	// This comment inside function should be modified
+	
+	// These comments should all be modified (inside function)
+	var (
+		// THIS Comment SHOULD be modified (inside function and var block)
+		localVar1 = "local1"
+		
+		// ANOTHER Local Comment
+		localVar2 = "local2"
+	)
+	
+	// This comment should be modified (inside function)
+	const (
+		// THIS Comment SHOULD be modified (inside function and const block)
+		localConst1 = "local1"
+		
+		// ANOTHER Local Comment
+		localConst2 = "local2"
+	)
+	
+	_ = localVar1
+	_ = localVar2
+	_ = localConst1
+	_ = localConst2
+}`
+
+		// write the test file
+		err := os.WriteFile(testFile, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to write test file")
+
+		// capture output using buffer writers
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process file with full lowercase mode
+		processFile(testFile, &ProcessRequest{OutputMode: "inplace"}, writers)
+
+		// read the processed file
+		processedContent, err := os.ReadFile(testFile)
+		require.NoError(t, err, "Failed to read processed file")
+		processedStr := string(processedContent)
+
+		// verify package-level comments outside blocks are not modified
+		assert.Contains(t, processedStr, "// Package level variable declaration should NOT be modified",
+			"Package level comment should not be modified")
+		assert.Contains(t, processedStr, "// Package level const declaration should NOT be modified",
+			"Package level comment should not be modified")
+		assert.Contains(t, processedStr, "// This comment before var block should NOT be modified",
+			"Comment before var block should not be modified")
+		assert.Contains(t, processedStr, "// This comment before const block should NOT be modified",
+			"Comment before const block should not be modified")
+
+		// verify comments inside var blocks are modified (full lowercase)
+		assert.Contains(t, processedStr, "// this comment should be modified (inside var block)",
+			"Comment inside var block should be modified")
+		assert.Contains(t, processedStr, "// another comment to process",
+			"Comment inside var block should be modified")
+
+		// verify comments inside const blocks are modified (full lowercase)
+		assert.Contains(t, processedStr, "// this comment should be modified (inside const block)",
+			"Comment inside const block should be modified")
+		assert.Contains(t, processedStr, "// another comment to process",
+			"Comment inside const block should be modified")
+
+		// verify comments inside local var/const blocks are modified (full lowercase)
+		assert.Contains(t, processedStr, "// this comment should be modified (inside function and var block)",
+			"Comment inside local var block should be modified")
+		assert.Contains(t, processedStr, "// this comment should be modified (inside function and const block)",
+			"Comment inside local const block should be modified")
+		assert.Contains(t, processedStr, "// another local comment",
+			"Comment inside local blocks should be modified")
+	})
+
+	// test both title case and full lowercase modes
+	t.Run("Both modes with var/const blocks", func(t *testing.T) {
+		// 1. First test with full lowercase mode
+
+		// create test file with var/const blocks
+		fullDir := t.TempDir()
+		fullFile := filepath.Join(fullDir, "full_lowercase.go")
+
+		fullContent := `package test
+
+var (
+	// UPPERCASE COMMENT to test
+	x = 1
+)
+
+const (
+	// ANOTHER UPPERCASE COMMENT
+	y = 2
+)
+
+func Example() {
+	var (
+		// FUNCTION VAR COMMENT
+		z = 3
+	)
+}`
+
+		// write the test file
+		err := os.WriteFile(fullFile, []byte(fullContent), 0o600)
+		require.NoError(t, err, "Failed to write test file")
+
+		// process with FULL lowercase mode
+		var fullStdout, fullStderr bytes.Buffer
+		fullWriters := OutputWriters{
+			Stdout: &fullStdout,
+			Stderr: &fullStderr,
+		}
+		processFile(fullFile, &ProcessRequest{OutputMode: "inplace"}, fullWriters)
+
+		// read the result
+		fullResult, err := os.ReadFile(fullFile)
+		require.NoError(t, err, "Failed to read result file")
+		fullStr := string(fullResult)
+
+		// in full lowercase mode, comments are fully converted to lowercase
+		assert.Contains(t, fullStr, "// uppercase comment", "Comment in var block should be converted to lowercase")
+		assert.Contains(t, fullStr, "// another uppercase", "Comment in const block should be converted to lowercase")
+		assert.Contains(t, fullStr, "// function var", "Comment in function var block should be converted to lowercase")
+
+		// 2. Now test with title case mode
+
+		// create test file with var/const blocks
+		titleDir := t.TempDir()
+		titleFile := filepath.Join(titleDir, "title_case.go")
+
+		titleContent := `package test
+
+var (
+	// First COMMENT to test
+	x = 1
+)
+
+const (
+	// Second UPPERCASE COMMENT
+	y = 2
+)
+
+func Example() {
+	var (
+		// Third COMMENT in function
+		z = 3
+	)
+}`
+
+		// write the test file
+		err = os.WriteFile(titleFile, []byte(titleContent), 0o600)
+		require.NoError(t, err, "Failed to write test file")
+
+		// process with title case mode
+		var titleStdout, titleStderr bytes.Buffer
+		titleWriters := OutputWriters{
+			Stdout: &titleStdout,
+			Stderr: &titleStderr,
+		}
+		processFile(titleFile, &ProcessRequest{OutputMode: "inplace", TitleCase: true}, titleWriters)
+
+		// read the result
+		titleResult, err := os.ReadFile(titleFile)
+		require.NoError(t, err, "Failed to read result file")
+		titleStr := string(titleResult)
+
+		// both modes work correctly with var/const blocks
+
+		// for title case, only first letter should be lowercase
+		assert.Contains(t, titleStr, "// first", "Comment in var block should have first letter lowercase")
+		assert.Contains(t, titleStr, "// second", "Comment in const block should have first letter lowercase")
+		assert.Contains(t, titleStr, "// third", "Comment in function var block should have first letter lowercase")
+	})
+}
+
+func TestGetCommentIdentifiers(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "extracts pascal case identifiers",
+			content:  "This is a TestFunction with PascalCase",
+			expected: []string{"TestFunction", "PascalCase"},
+		},
+		{
+			name:     "no identifiers, but CAPS words",
+			content:  " This SHOULD Be Converted",
+			expected: []string{},
+		},
+		{
+			name:     "strange UPPEr case comment",
+			content:  "UPPEr case comment",
+			expected: []string{},
+		},
+
+		{
+			name:     "extracts camel case identifiers",
+			content:  "this is a testFunction with camelCase",
+			expected: []string{"testFunction", "camelCase"},
+		},
+		{
+			name:     "extracts mixed case identifiers",
+			content:  "This is a testFunction with MixedCase and camelCase",
+			expected: []string{"testFunction", "MixedCase", "camelCase"},
+		},
+		{
+			name:     "ignores non-identifier words",
+			content:  "this is a simple comment without identifiers",
+			expected: []string{},
+		},
+		{
+			name:     "handles empty content",
+			content:  "",
+			expected: []string{},
+		},
+		{
+			name:     "handles content with special characters",
+			content:  "This is a testFunction_with_specialCharacters and camelCase",
+			expected: []string{"testFunction_with_specialCharacters", "camelCase"},
+		},
+		{
+			name:     "handles content with numbers",
+			content:  "This is a testFunction1 with camelCase2",
+			expected: []string{"testFunction1", "camelCase2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := getCommentIdentifiers(tt.content)
+			assert.ElementsMatch(t, tt.expected, result)
+		})
+	}
+}
+
+// TestMinWords tests that comments shorter than --min-words are left untouched
+func TestMinWords(t *testing.T) {
+	content := `package test
+
+func Example() {
+	// Short Label
+	x := 1
+	// THIS IS a longer comment
+	_ = x
+}
+`
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "min_words.go")
+
+	t.Run("short comment skipped", func(t *testing.T) {
+		err := os.WriteFile(testFile, []byte(content), 0o600)
+		require.NoError(t, err)
+
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+		changes := processFile(testFile, &ProcessRequest{OutputMode: "inplace", MinWords: 3}, writers)
+		assert.Equal(t, 1, changes)
+
+		res, err := os.ReadFile(testFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(res), "// Short Label", "two-word comment should be preserved")
+		assert.Contains(t, string(res), "// this is a longer comment", "longer comment should be converted")
+	})
+
+	t.Run("zero means all comments", func(t *testing.T) {
+		err := os.WriteFile(testFile, []byte(content), 0o600)
+		require.NoError(t, err)
+
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+		changes := processFile(testFile, &ProcessRequest{OutputMode: "inplace"}, writers)
+		assert.Equal(t, 2, changes)
+
+		res, err := os.ReadFile(testFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(res), "// short label")
+	})
+
+	t.Run("word count", func(t *testing.T) {
+		assert.Equal(t, 2, commentWordCount("// Short Label"))
+		assert.Equal(t, 3, commentWordCount("/* one two three */"))
+		assert.Equal(t, 0, commentWordCount("//"))
+	})
+}
+
+// TestLineDirectivesAndCgoPreamble tests that //line directives and cgo preamble comments stay verbatim
+func TestLineDirectivesAndCgoPreamble(t *testing.T) {
+	t.Run("line directive inside function", func(t *testing.T) {
+		assert.Equal(t, "//line Generated.go:10", convertCommentToLowercase("//line Generated.go:10"))
+		assert.Equal(t, "//line Generated.go:10:5", convertCommentToTitleCase("//line Generated.go:10:5"))
+	})
+
+	t.Run("cgo directive lines", func(t *testing.T) {
+		assert.Equal(t, "// #include <Stdio.h>", convertCommentToLowercase("// #include <Stdio.h>"))
+		assert.Equal(t, "// #cgo LDFLAGS: -lFoo", convertCommentToTitleCase("// #cgo LDFLAGS: -lFoo"))
+		assert.Equal(t, "// #1 Priority", convertCommentToTitleCase("// #1 Priority"), "not a cgo directive")
+	})
+
+	t.Run("file with line directive and cgo preamble", func(t *testing.T) {
+		content := `package test
+
+// #include <Stdlib.h>
+// Static Int Counter = 0;
+import "C"
+
+func Example() {
+//line Generated.go:10
+	// THIS SHOULD be converted
+	_ = 1
+}
+`
+		testFile := filepath.Join(t.TempDir(), "cgo.go")
+		err := os.WriteFile(testFile, []byte(content), 0o600)
+		require.NoError(t, err)
+
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+		processFile(testFile, &ProcessRequest{OutputMode: "print"}, writers)
+
+		output := stdoutBuf.String()
+		assert.Contains(t, output, "// #include <Stdlib.h>\n// Static Int Counter = 0;\nimport \"C\"")
+		assert.Contains(t, output, "//line Generated.go:10")
+		assert.Contains(t, output, "// this should be converted")
+	})
+
+	t.Run("cgo preamble and exports with all options", func(t *testing.T) {
+		src := `package test
+
+// #include <Stdlib.h>
+// Initialize The Counter
+// STATIC INT COUNTER = 0;
+import "C"
+
+//export GoCallback
+func GoCallback() {
+	//export NotReally
+	// INITIALIZE the counter
+	// Initialize the counter
+	C.counter = 0
+}
+`
+		obvious, err := compileObviousPatterns(nil)
+		require.NoError(t, err)
+		for _, req := range []*ProcessRequest{
+			{TitleCase: true, ObviousPatterns: obvious, PreserveColonHeaders: true},
+			{ObviousPatterns: obvious, DirectivePrefixes: []string{"export"}},
+			{LeadingCapsOnly: true, MinUpperRun: 2},
+		} {
+			res, _, err := processSource("cgo.go", []byte(src), req)
+			require.NoError(t, err)
+			assert.Contains(t, res, "// #include <Stdlib.h>\n// Initialize The Counter\n// STATIC INT COUNTER = 0;\nimport \"C\"")
+			assert.Contains(t, res, "//export GoCallback\nfunc GoCallback() {")
+			assert.Contains(t, res, "\t//export NotReally\n")
+		}
+	})
+
+	t.Run("cgo preamble detection", func(t *testing.T) {
+		src := "package test\n\n// #include <stdio.h>\nimport \"C\"\n\n// Doc comment\nfunc Example() {}\n"
+		node, err := parser.ParseFile(token.NewFileSet(), "cgo.go", src, parser.ParseComments)
+		require.NoError(t, err)
+		require.Len(t, node.Comments, 2)
+		assert.True(t, isCgoPreamble(node.Comments[0], node))
+		assert.False(t, isCgoPreamble(node.Comments[1], node))
+	})
+}
+
+// TestGoDirectivesMixedWithProse tests that //go: directives are never altered, even when grouped with prose
+func TestGoDirectivesMixedWithProse(t *testing.T) {
+	t.Run("directive conversion", func(t *testing.T) {
+		for _, directive := range []string{"//go:noinline", "//go:generate go run Gen.go -Type=Foo", "//go:embed Static/*"} {
+			assert.Equal(t, directive, convertCommentToLowercase(directive))
+			assert.Equal(t, directive, convertCommentToTitleCase(directive))
+		}
+	})
+
+	content := `package test
+
+//go:noinline
+// This disables inlining
+func Example() {
+	//go:generate go run Gen.go -Type=Foo
+	// This Is processed
+	_ = 1
+}
+`
+	testFile := filepath.Join(t.TempDir(), "directives.go")
+
+	for _, titleCase := range []bool{true, false} {
+		t.Run(fmt.Sprintf("file title case %v", titleCase), func(t *testing.T) {
+			err := os.WriteFile(testFile, []byte(content), 0o600)
+			require.NoError(t, err)
+
+			var stdoutBuf, stderrBuf bytes.Buffer
+			writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+			changes := processFile(testFile, &ProcessRequest{OutputMode: "print", TitleCase: titleCase}, writers)
+			assert.Equal(t, 1, changes)
+
+			output := stdoutBuf.String()
+			assert.Contains(t, output, "\n//go:noinline\n", "package level directive preserved")
+			assert.Contains(t, output, "\n// This disables inlining\n", "package level prose preserved")
+			assert.Contains(t, output, "//go:generate go run Gen.go -Type=Foo", "directive inside function preserved")
+			assert.Contains(t, output, "// this ", "prose inside function processed")
+		})
+	}
+}
+
+// TestBannerComments tests that banner and ascii-art comments are skipped
+func TestBannerComments(t *testing.T) {
+	t.Run("banner detection", func(t *testing.T) {
+		tests := []struct {
+			content  string
+			expected bool
+		}{
+			{" ===== Foo =====", true},
+			{" *** Bar ***", true},
+			{" --- Section", true},
+			{" #### ####", true},
+			{" Normal Comment here", false},
+			{" Wait for it...", false},
+			{" x := 1", false},
+			{" Check (a, b)", false},
+			{" i++", false},
+			{" a != b", false},
+			{" -*- mode: go -*-", true},
+			{"", false},
+		}
+		for _, tc := range tests {
+			assert.Equal(t, tc.expected, isBannerComment(tc.content, 0.5), "content %q", tc.content)
+		}
+	})
+
+	t.Run("banners preserved", func(t *testing.T) {
+		req := &ProcessRequest{TitleCase: true, BannerThreshold: 0.5}
+		assert.Equal(t, "// ===== Foo =====", convertComment("// ===== Foo =====", req))
+		assert.Equal(t, "// *** Bar ***", convertComment("// *** Bar ***", req))
+		assert.Equal(t, "// regular comment", convertComment("// Regular comment", req))
+		assert.Equal(t, "// i++", convertComment("// I++", req))
+		assert.Equal(t, "// a != b", convertComment("// A != B", &ProcessRequest{BannerThreshold: 0.5}))
+
+		req = &ProcessRequest{BannerThreshold: 0.5}
+		assert.Equal(t, "// ===== Foo =====", convertComment("// ===== Foo =====", req))
+		assert.Equal(t, "// *** Bar ***", convertComment("// *** Bar ***", req))
+	})
+
+	t.Run("zero threshold disables detection", func(t *testing.T) {
+		req := &ProcessRequest{TitleCase: false}
+		assert.Equal(t, "// ===== foo =====", convertComment("// ===== Foo =====", req))
+	})
+}
+
+// TestNormalizeDirectiveSpacing tests collapsing of spacing in two-part directive comments
+func TestNormalizeDirectiveSpacing(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "extra spacing collapsed",
+			input:    "//nolint:gosec  //   Using math/rand is ACCEPTABLE for tests",
+			expected: "//nolint:gosec // using math/rand is ACCEPTABLE for tests",
+		},
+		{
+			name:     "no spacing",
+			input:    "//nolint:gosec//Using math/rand",
+			expected: "//nolint:gosec // using math/rand",
+		},
+		{
+			name:     "space only before the marker",
+			input:    "//nolint:Gosec //Using math/rand",
+			expected: "//nolint:Gosec // using math/rand",
+		},
+		{
+			name:     "canonical form unchanged",
+			input:    "//nolint:gosec // using math/rand",
+			expected: "//nolint:gosec // using math/rand",
+		},
+		{
+			name:     "regular comment with inner marker not normalized",
+			input:    "// See a  //  b",
+			expected: "// See a  //  b",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &ProcessRequest{TitleCase: true, NormalizeDirectiveSpacing: true}
+			assert.Equal(t, tc.expected, convertComment(tc.input, req))
+		})
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		input := "//nolint:gosec  //   Using math/rand"
+		assert.Equal(t, "//nolint:gosec  //   using math/rand", convertComment(input, &ProcessRequest{TitleCase: true}))
+	})
+}
+
+// TestImportBlockAndPackageLevelComments tests that comments after the package clause and inside import blocks are preserved
+func TestImportBlockAndPackageLevelComments(t *testing.T) {
+	content := `package test
+
+// Trailing Comment After Package clause
+
+import (
+	// Standard Library Imports
+	"fmt"
+	"strings" // Used For Joining
+
+	// Third Party
+	"github.com/fatih/color"
+)
+
+func Example() {
+	// Converted Comment
+	fmt.Println(strings.ToLower(color.RedString("x")))
+}
+`
+	testFile := filepath.Join(t.TempDir(), "imports.go")
+	err := os.WriteFile(testFile, []byte(content), 0o600)
+	require.NoError(t, err)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+	changes := processFile(testFile, &ProcessRequest{OutputMode: "print"}, writers)
+	assert.Equal(t, 1, changes, "only the in-function comment should change")
+
+	output := stdoutBuf.String()
+	assert.Contains(t, output, "// Trailing Comment After Package clause")
+	assert.Contains(t, output, "// Standard Library Imports")
+	assert.Contains(t, output, "// Used For Joining")
+	assert.Contains(t, output, "// Third Party")
+	assert.Contains(t, output, "// converted comment")
+}
+
+// TestProperNouns tests preserving proper nouns listed in --proper-nouns file
+func TestProperNouns(t *testing.T) {
+	nounsFile := filepath.Join(t.TempDir(), "nouns.txt")
+	err := os.WriteFile(nounsFile, []byte("# brands\niOS\nmacOS\n\nPostgres\n"), 0o600)
+	require.NoError(t, err)
+
+	nouns, err := loadWordList(nounsFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"iOS", "macOS", "Postgres"}, nouns)
+
+	_, err = loadWordList(filepath.Join(t.TempDir(), "missing.txt"))
+	require.Error(t, err)
+
+	tests := []struct {
+		name      string
+		input     string
+		titleCase bool
+		expected  string
+	}{
+		{"full mode keeps postgres", "// Saves To Postgres Database", false, "// saves to Postgres database"},
+		{"full mode restores canonical form", "// SAVES TO POSTGRES", false, "// saves to Postgres"},
+		{"full mode keeps ios and macos", "// Works On iOS And macOS Only", false, "// works on iOS and macOS only"},
+		{"title mode keeps first word", "// Postgres is used here", true, "// Postgres is used here"},
+		{"title mode converts other first words", "// Saves to Postgres", true, "// saves to Postgres"},
+		{"no partial word matches", "// Postgresql Is Different", false, "// postgresql is different"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &ProcessRequest{TitleCase: tc.titleCase, ProperNouns: nouns}
+			assert.Equal(t, tc.expected, convertComment(tc.input, req))
+		})
+	}
+
+	t.Run("words file", func(t *testing.T) {
+		wordsFile := filepath.Join(t.TempDir(), "words.txt")
+		require.NoError(t, os.WriteFile(wordsFile, []byte("PostgreSQL\nKubernetes\n"), 0o600))
+
+		words, err := loadWordLists(nounsFile, "", wordsFile)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"iOS", "macOS", "Postgres", "PostgreSQL", "Kubernetes"}, words)
+
+		req := &ProcessRequest{ProperNouns: words}
+		assert.Equal(t, "// deploy PostgreSQL to Kubernetes from macOS",
+			convertComment("// Deploy POSTGRESQL To kubernetes From MacOS", req), "canonical form is restored")
+		assert.Equal(t, "// Kubernetes runs it", convertComment("// Kubernetes Runs It", req))
+		assert.Equal(t, "// kubernetes runs it", convertComment("// kubernetes runs it", &ProcessRequest{TitleCase: true, ProperNouns: words}),
+			"title mode doesn't change the rest of the comment")
+
+		words, err = loadWordLists("", "")
+		require.NoError(t, err)
+		assert.Empty(t, words)
+
+		_, err = loadWordLists(wordsFile, filepath.Join(t.TempDir(), "missing.txt"))
+		require.Error(t, err)
+	})
+
+	t.Run("repeated options", func(t *testing.T) {
+		otherFile := filepath.Join(t.TempDir(), "other.txt")
+		require.NoError(t, os.WriteFile(otherFile, []byte("gRPC\n"), 0o600))
+		wordsFile := filepath.Join(t.TempDir(), "words.txt")
+		require.NoError(t, os.WriteFile(wordsFile, []byte("Kubernetes\n"), 0o600))
+
+		var opts Options
+		_, err := flags.NewParser(&opts, flags.Default).ParseArgs([]string{"run", "--proper-nouns", nounsFile,
+			"--proper-nouns", otherFile, "--words-file", wordsFile})
+		require.NoError(t, err)
+		req, err := newProcessRequest(opts, "inplace")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"iOS", "macOS", "Postgres", "gRPC", "Kubernetes"}, req.ProperNouns,
+			"all lists are loaded, --words-file is an alias")
+	})
+}
+
+// TestAcronyms tests keeping acronyms as written anywhere in a comment in full mode
+func TestAcronyms(t *testing.T) {
+	var opts Options
+	_, err := flags.NewParser(&opts, flags.Default).ParseArgs([]string{"run"})
+	require.NoError(t, err)
+	req := &ProcessRequest{Acronyms: acronymsPattern(splitList(opts.Acronyms))}
+
+	tests := []struct {
+		name, input, expected string
+	}{
+		{"mid-comment acronyms", "// Send The JSON Body Over HTTP", "// send the JSON body over HTTP"},
+		{"first word", "// CPU And GPU Load", "// CPU and GPU load"},
+		{"plural", "// Collect All IDs From The API", "// collect all IDs from the API"},
+		{"lowercase kept lowercase", "// Parse The json Body", "// parse the json body"},
+		{"no partial word matches", "// IDENTITY And APIKEY Values", "// identity and apikey values"},
+		{"punctuation around", "// Build (URL), Then XML.", "// build (URL), then XML."},
+		{"identifiers are kept", "// Set userID From Request", "// set userID from request"},
+		{"capitalized words lowercased", "// Set The Id And Api Url", "// set the id and api url"},
+		{"mixed with listed form", "// Get Id From The ID Header Via Api", "// get id from the ID header via api"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, convertComment(tc.input, req))
+		})
+	}
+
+	t.Run("custom list", func(t *testing.T) {
+		req := &ProcessRequest{Acronyms: acronymsPattern(splitList([]string{"SQL,TLS", "GRPC"}))}
+		assert.Equal(t, "// run SQL over TLS with http", convertComment("// Run SQL Over TLS With HTTP", req))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Nil(t, acronymsPattern(splitList([]string{""})))
+		assert.Equal(t, "// send JSON", convertComment("// Send JSON", &ProcessRequest{TitleCase: true}),
+			"title mode keeps the rest unchanged anyway")
+		assert.Equal(t, "// send json", convertComment("// Send JSON", &ProcessRequest{}))
+	})
+}
+
+// TestDigitAcronyms tests preserving acronyms with digits, like UTF8, S3 and IPv6
+func TestDigitAcronyms(t *testing.T) {
+	tests := []struct {
+		name, input, title, full string
+	}{
+		{"utf8 first", "// UTF8 Handling Here", "// UTF8 Handling Here", "// UTF8 handling here"},
+		{"s3 first", "// S3 Bucket Name", "// S3 Bucket Name", "// S3 bucket name"},
+		{"ipv6 first", "// IPv6 Address Parsing", "// IPv6 Address Parsing", "// IPv6 address parsing"},
+		{"mid-comment", "// Store UTF8 Text In S3 Over IPv6", "// store UTF8 Text In S3 Over IPv6",
+			"// store UTF8 text in S3 over IPv6"},
+		{"punctuation around", "// Uses (S3), H2O.", "// uses (S3), H2O.", "// uses (S3), H2O."},
+		{"first with punctuation", "// S3, Then More", "// S3, Then More", "// S3, then more"},
+		{"mixed case words with digits", "// Go1 And Win32 Are Words", "// go1 And Win32 Are Words",
+			"// go1 and win32 are words"},
+		{"single letter", "// X Marks The Spot", "// x Marks The Spot", "// x marks the spot"},
+		{"no match inside words", "// Store In Box1 Or X1", "// store In Box1 Or X1", "// store in box1 or X1"},
+		{"no match at word end", "// Upload Files3 To S3", "// upload Files3 To S3", "// upload files3 to S3"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.title, convertComment(tc.input, &ProcessRequest{TitleCase: true}))
+			assert.Equal(t, tc.full, convertComment(tc.input, &ProcessRequest{}))
+		})
+	}
+
+	assert.Equal(t, []string{"UTF8", "S3", "IPv6", "userID"}, getCommentIdentifiers("UTF8 in S3, IPv6; userID go1"))
+}
+
+// TestURLsInComments tests that URLs are kept as is while the prose around them is converted
+func TestURLsInComments(t *testing.T) {
+	tbl := []struct {
+		name, input, expected string
+		title, leadingCaps    bool
+	}{
+		{name: "full", input: "// See https://Example.com/API for Details", expected: "// see https://Example.com/API for details"},
+		{name: "full with several urls", input: "// Mirror HTTP://A.org/X And https://B.org/Y?Q=1.",
+			expected: "// mirror HTTP://A.org/X and https://B.org/Y?Q=1."},
+		{name: "full url in parens", input: "// Docs (https://Go.dev/Ref/Spec) Here", expected: "// docs (https://Go.dev/Ref/Spec) here"},
+		{name: "full without urls", input: "// Plain Text HTTP Server", expected: "// plain text http server"},
+		{name: "title", input: "// See https://Example.com/API", expected: "// see https://Example.com/API", title: true},
+		{name: "title starting with url", input: "// HTTPS://Example.com/API Docs", expected: "// HTTPS://Example.com/API Docs", title: true},
+		{name: "leading caps", input: "// SEE THE DOCS AT HTTPS://Example.com/API", expected: "// see the docs at HTTPS://Example.com/API",
+			leadingCaps: true},
+		{name: "block comment", input: "/* Read https://Example.com/Guide */", expected: "/* read https://Example.com/Guide */"},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &ProcessRequest{TitleCase: tt.title, LeadingCapsOnly: tt.leadingCaps}
+			assert.Equal(t, tt.expected, convertComment(tt.input, req))
+		})
+	}
+}
+
+// TestCapitalize tests the capitalize mode, the inverse of title case
+func TestCapitalize(t *testing.T) {
+	tbl := []struct {
+		input, expected string
+	}{
+		{"// some comment", "// Some comment"},
+		{"// Already capitalized", "// Already capitalized"},
+		{"//no space", "//No space"},
+		{"/* block comment */", "/* Block comment */"},
+		{"// userID is set", "// userID is set"},
+		{"// getUser returns a user", "// getUser returns a user"},
+		{"// TODO fix this", "// TODO fix this"},
+		{"// - list item", "// - List item"},
+		{"// élan vital", "// Élan vital"},
+		{"// https://example.com/api docs", "// https://example.com/api docs"},
+		{"//go:generate mockgen", "//go:generate mockgen"},
+		{"// nolint:gosec // random is fine", "// nolint:gosec // Random is fine"},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, convertComment(tt.input, &ProcessRequest{TitleCase: true, Capitalize: true}))
+		})
+	}
+
+	t.Run("without title case", func(t *testing.T) {
+		assert.Equal(t, "// Some Comment", convertComment("// some Comment", &ProcessRequest{Capitalize: true}),
+			"capitalize never lowercases the rest")
+	})
+}
+
+// TestWhitespaceOnlyComments tests that comments without text are left as is in every mode
+func TestWhitespaceOnlyComments(t *testing.T) {
+	src := "package test\n\nfunc Example() {\n\t//   \n\t// \t\n\t//nolint //  \n\t// - \n\tx := 1 //  \n\t_ = x\n}\n"
+	tbl := []struct {
+		name string
+		req  *ProcessRequest
+	}{
+		{"title case", &ProcessRequest{TitleCase: true}},
+		{"capitalize", &ProcessRequest{TitleCase: true, Capitalize: true}},
+		{"full", &ProcessRequest{}},
+		{"sentence", &ProcessRequest{TitleCase: true, Sentence: true}},
+	}
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			res, changes, err := processSource("test.go", []byte(src), tt.req)
+			require.NoError(t, err)
+			assert.Empty(t, changes)
+			assert.Contains(t, res, "\t// -\n")
+		})
+	}
+}
+
+// TestGroupAware tests converting only the first line of a group of consecutive line comments
+func TestGroupAware(t *testing.T) {
+	src := `package test
+
+func Example() {
+	// First Line Of The Paragraph
+	// Continues With userID Here
+	// And Ends Here.
+	x := 1 // Trailing One
+	y := 2 // Trailing Two
+
+	// Separate Group
+	/* Block Comment */
+	_, _ = x, y
+}
+`
+	t.Run("title case", func(t *testing.T) {
+		res, changes, err := processSource("test.go", []byte(src), &ProcessRequest{TitleCase: true, GroupAware: true})
+		require.NoError(t, err)
+		assert.Contains(t, res, "\t// first Line Of The Paragraph\n\t// Continues With userID Here\n\t// And Ends Here.\n")
+		assert.Contains(t, res, "x := 1\t// trailing One\n\ty := 2\t// trailing Two\n", "trailing comments are separate groups")
+		assert.Contains(t, res, "\t// separate Group\n\t/* block Comment */\n", "block comments are not continuation lines")
+		assert.Len(t, changes, 5)
+	})
+
+	t.Run("full", func(t *testing.T) {
+		res, _, err := processSource("test.go", []byte(src), &ProcessRequest{GroupAware: true})
+		require.NoError(t, err)
+		assert.Contains(t, res, "\t// first line of the paragraph\n\t// Continues with userID here\n\t// And ends here.\n")
+	})
+
+	t.Run("capitalize", func(t *testing.T) {
+		res, _, err := processSource("test.go", []byte("package test\n\nfunc Example() {\n\t// first line\n\t// second line\n}\n"),
+			&ProcessRequest{TitleCase: true, Capitalize: true, GroupAware: true})
+		require.NoError(t, err)
+		assert.Contains(t, res, "\t// First line\n\t// second line\n")
+	})
+
+	t.Run("keep leading case", func(t *testing.T) {
+		assert.Equal(t, "// Some text", keepLeadingCase("// Some Text", "// some text"))
+		assert.Equal(t, "// Élan vital", keepLeadingCase("// Élan Vital", "// élan vital"))
+		assert.Equal(t, "// 42", keepLeadingCase("// 42", "// 42"))
+		assert.Equal(t, "// some text", keepLeadingCase("// some Text", "// some text"))
+	})
+}
+
+// TestSentenceMode tests converting the first character of every sentence of a comment
+func TestSentenceMode(t *testing.T) {
+	t.Run("split sentences", func(t *testing.T) {
+		tbl := []struct {
+			input    string
+			expected []string
+		}{
+			{"one sentence", []string{"one sentence"}},
+			{"First. Second", []string{"First. ", "Second"}},
+			{"First! Second? Third.", []string{"First! ", "Second? ", "Third."}},
+			{"Wait...  What", []string{"Wait...  ", "What"}},
+			{"Use e.g. Foo. Next", []string{"Use e.g. Foo. ", "Next"}},
+			{"See https://x.com/A. B", []string{"See https://x.com/A. B"}},
+			{" . Dot", []string{" . Dot"}},
+		}
+		for _, tt := range tbl {
+			assert.Equal(t, tt.expected, splitSentences(tt.input), tt.input)
+		}
+	})
+
+	t.Run("convert", func(t *testing.T) {
+		tbl := []struct {
+			input, expected string
+			capitalize      bool
+		}{
+			{input: "// Fix this. Then check that", expected: "// fix this. then check that"},
+			{input: "// First! Second? Third.", expected: "// first! second? third."},
+			{input: "// Sets userID. UserName is set. HTTP works", expected: "// sets userID. UserName is set. HTTP works"},
+			{input: "// Done. TODO clean up. Next step", expected: "// done. TODO clean up. next step"},
+			{input: "// Use e.g. Foo here. See the docs", expected: "// use e.g. Foo here. see the docs"},
+			{input: "/* One thing. Two things. */", expected: "/* one thing. two things. */"},
+			{input: "// nolint:gosec // Random is fine. Really", expected: "// nolint:gosec // random is fine. really"},
+			{input: "// fix this. then check that", expected: "// Fix this. Then check that", capitalize: true},
+		}
+		for _, tt := range tbl {
+			req := &ProcessRequest{TitleCase: true, Sentence: true, Capitalize: tt.capitalize}
+			assert.Equal(t, tt.expected, convertComment(tt.input, req), tt.input)
+		}
+	})
+
+	t.Run("title case without sentence mode", func(t *testing.T) {
+		assert.Equal(t, "// fix this. Then check that", convertComment("// Fix this. Then check that", &ProcessRequest{TitleCase: true}))
+	})
+}
+
+// TestTrim tests stripping trailing whitespace of line comments
+func TestTrim(t *testing.T) {
+	tbl := []struct {
+		input, expected string
+		trim            bool
+	}{
+		{input: "// This is a comment   ", expected: "// this is a comment", trim: true},
+		{input: "// clean comment \t ", expected: "// clean comment", trim: true},
+		{input: "//   ", expected: "//", trim: true},
+		{input: "//  Indented content  ", expected: "//  indented content", trim: true},
+		{input: "// TODO keep case  ", expected: "// TODO keep case", trim: true},
+		{input: "//go:generate mockgen ", expected: "//go:generate mockgen", trim: true},
+		{input: "/* Block comment  */", expected: "/* block comment  */", trim: true},
+		{input: "// This is a comment   ", expected: "// this is a comment   "},
+	}
+	for _, tt := range tbl {
+		assert.Equal(t, tt.expected, convertComment(tt.input, &ProcessRequest{TitleCase: true, Trim: tt.trim}), "%q", tt.input)
+	}
+
+	t.Run("comment with only trailing whitespace is a change", func(t *testing.T) {
+		src := "package test\n\nfunc A() {\n\t// clean comment  \n}\n"
+		res, changes, err := processSource(stdinFileName, []byte(src), &ProcessRequest{OutputMode: "print", TitleCase: true, Trim: true})
+		require.NoError(t, err)
+		assert.Equal(t, "package test\n\nfunc A() {\n\t// clean comment\n}\n", res)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "// clean comment", changes[0].After)
+
+		_, changes, err = processSource(stdinFileName, []byte(src), &ProcessRequest{OutputMode: "print", TitleCase: true})
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+}
+
+// TestEnsureSpace tests inserting a missing space after "//" while keeping directives unchanged
+func TestEnsureSpace(t *testing.T) {
+	tbl := []struct {
+		input, expected string
+		ensure          bool
+	}{
+		{input: "//Comment with no space", expected: "// comment with no space", ensure: true},
+		{input: "//fix this", expected: "// fix this", ensure: true},
+		{input: "//123 items", expected: "// 123 items", ensure: true},
+		{input: "//Éclair", expected: "// éclair", ensure: true},
+		{input: "// Already spaced", expected: "// already spaced", ensure: true},
+		{input: "//TODO fix this", expected: "// TODO fix this", ensure: true},
+		{input: "//nolint", expected: "//nolint", ensure: true},
+		{input: "//nolint,errcheck", expected: "//nolint,errcheck", ensure: true},
+		{input: "//nolint:gosec // Random is fine", expected: "//nolint:gosec // random is fine", ensure: true},
+		{input: "//go:generate mockgen -source=a.go", expected: "//go:generate mockgen -source=a.go", ensure: true},
+		{input: "//lint:ignore SA1000 reason", expected: "//lint:ignore SA1000 reason", ensure: true},
+		{input: "//export MyFunc", expected: "//export MyFunc", ensure: true},
+		{input: "//line file.go:10", expected: "//line file.go:10", ensure: true},
+		{input: "//---", expected: "//---", ensure: true},
+		{input: "///Doc comment", expected: "///Doc comment", ensure: true},
+		{input: "//", expected: "//", ensure: true},
+		{input: "//Comment with no space", expected: "//comment with no space"},
+	}
+	for _, tt := range tbl {
+		assert.Equal(t, tt.expected, convertComment(tt.input, &ProcessRequest{TitleCase: true, EnsureSpace: tt.ensure}), tt.input)
+	}
+
+	t.Run("custom directive prefix", func(t *testing.T) {
+		req := &ProcessRequest{TitleCase: true, EnsureSpace: true, DirectivePrefixes: []string{"sqlc"}}
+		assert.Equal(t, "//sqlc some query", convertComment("//sqlc Some query", req))
+	})
+}
+
+// TestBuildConstraints tests that build constraint lines are kept verbatim wherever they are
+func TestBuildConstraints(t *testing.T) {
+	src := `//go:build linux && !CGO
+// +build linux,!CGO
+
+package test
+
+func A() {
+	//go:build Stray && WINDOWS
+	// +build Stray WINDOWS
+	// Regular Comment
+}
+
+var (
+	// +build IN_VAR_BLOCK
+	x = 1
+)
+`
+	for _, req := range []*ProcessRequest{{OutputMode: "print"}, {OutputMode: "print", TitleCase: true},
+		{OutputMode: "print", NoKeepIndicators: true, Trim: true, DocComments: true}} {
+		res, changes, err := processSource(stdinFileName, []byte(src), req)
+		require.NoError(t, err)
+		for _, line := range []string{"//go:build linux && !CGO", "// +build linux,!CGO", "//go:build Stray && WINDOWS",
+			"// +build Stray WINDOWS", "// +build IN_VAR_BLOCK"} {
+			assert.Contains(t, res, line+"\n")
+		}
+		require.Len(t, changes, 1)
+		assert.Equal(t, "// Regular Comment", changes[0].Before)
+	}
+
+	assert.True(t, isBuildConstraint("//go:build linux"))
+	assert.True(t, isBuildConstraint("// +build linux"))
+	assert.False(t, isBuildConstraint("// go:build is a directive"))
+	assert.False(t, isBuildConstraint("//  +build linux"))
+}
+
+// TestWordDirectives tests that "//word:" directives are kept as a whole, while comments with a space are processed
+func TestWordDirectives(t *testing.T) {
+	tbl := []struct {
+		input, expected string
+	}{
+		{"//go:generate go run gen.go -Type Foo", "//go:generate go run gen.go -Type Foo"},
+		{"//go:build linux && !CGO", "//go:build linux && !CGO"},
+		{"//go:embed Templates/*.HTML", "//go:embed Templates/*.HTML"},
+		{"//nolint:gosec", "//nolint:gosec"},
+		{"//counterfeiter:generate . Store", "//counterfeiter:generate . Store"},
+		{"//ts:enum Color Red Green", "//ts:enum Color Red Green"},
+		{"//k8s:deepcopy-gen=True", "//k8s:deepcopy-gen=True"},
+		{"//nolint:gosec // Random Is Fine", "//nolint:gosec // random is fine"},
+		{"// hello:world Is Processed", "// hello:world is processed"},
+	}
+	for _, tt := range tbl {
+		assert.Equal(t, tt.expected, convertComment(tt.input, &ProcessRequest{}), tt.input)
+	}
+}
+
+// TestQuotedSpans tests double-quoted spans kept as is while the rest of the comment is converted
+func TestQuotedSpans(t *testing.T) {
+	tbl := []struct {
+		name, input, expected string
+		title, leadingCaps    bool
+	}{
+		{name: "single span", input: `// Returns "OK" On Success`, expected: `// returns "OK" on success`},
+		{name: "multiple spans", input: `// Status Is "Ready" Or "NOT_FOUND", Never "Err"`,
+			expected: `// status is "Ready" or "NOT_FOUND", never "Err"`},
+		{name: "span at start", input: `// "OK" Means Done`, expected: `// "OK" means done`},
+		{name: "empty span", input: `// Empty "" Value`, expected: `// empty "" value`},
+		{name: "unterminated quote", input: `// Returns "ok On Success`, expected: `// returns "ok on success`},
+		{name: "terminated and unterminated", input: `// Sets "Mode" Then "on Exit`, expected: `// sets "Mode" then "on exit`},
+		{name: "span with url", input: `// Fetch "HTTPS://Example.com/API" Now`, expected: `// fetch "HTTPS://Example.com/API" now`},
+		{name: "title", input: `// Returns "OK" On Success`, expected: `// returns "OK" On Success`, title: true},
+		{name: "leading caps", input: `// RETURNS "OK" ON SUCCESS`, expected: `// returns "OK" on success`, leadingCaps: true},
+		{name: "block comment", input: `/* Writes "Done" Here */`, expected: `/* writes "Done" here */`},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &ProcessRequest{TitleCase: tt.title, LeadingCapsOnly: tt.leadingCaps}
+			assert.Equal(t, tt.expected, convertComment(tt.input, req))
+		})
+	}
+}
+
+// TestKeepPrefixes tests custom prefixes of comments kept unchanged, added to or replacing the built-in indicators
+func TestKeepPrefixes(t *testing.T) {
+	tbl := []struct {
+		name, input, expected string
+		only                  bool
+	}{
+		{name: "custom prefix", input: "// SECURITY Check The Token", expected: "// SECURITY Check The Token"},
+		{name: "custom prefix with symbol", input: "//   @TODO Fix It", expected: "//   @TODO Fix It"},
+		{name: "built-in indicator", input: "// FIXME This", expected: "// FIXME This"},
+		{name: "prefix match", input: "// PERFORMANCE Matters", expected: "// PERFORMANCE Matters"},
+		{name: "not a prefix", input: "// Check SECURITY", expected: "// check security"},
+		{name: "block comment", input: "/* PERF Hot Path */", expected: "/* PERF Hot Path */"},
+		{name: "custom prefix only", input: "// SECURITY Check", expected: "// SECURITY Check", only: true},
+		{name: "built-in replaced", input: "// FIXME This", expected: "// fixme this", only: true},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &ProcessRequest{KeepPrefixes: []string{"@TODO", "SECURITY", "PERF"}, KeepPrefixOnly: tt.only}
+			assert.Equal(t, tt.expected, convertComment(tt.input, req))
+		})
+	}
+
+	assert.Equal(t, "// security check", convertComment("// SECURITY Check", &ProcessRequest{}), "without the option")
+
+	t.Run("preservation disabled", func(t *testing.T) {
+		req := &ProcessRequest{NoKeepIndicators: true, KeepPrefixes: []string{"SECURITY"}}
+		assert.Equal(t, "// todo fix this", convertComment("// TODO Fix This", req))
+		assert.Equal(t, "// security check", convertComment("// SECURITY Check", req))
+		assert.Equal(t, "/* fixme later */", convertComment("/* FIXME Later */", req))
+	})
+}
+
+// TestDirectivePrefixes tests custom directive prefixes registered with --directive-prefix
+func TestDirectivePrefixes(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		titleCase bool
+		expected  string
+	}{
+		{"title mode keeps token", "// sqlc:Embed Users Table", true, "// sqlc:Embed users Table"},
+		{"full mode keeps token", "// sqlc:Embed Users Table", false, "// sqlc:Embed users table"},
+		{"no space after marker", "//sqlc:Arg Name Of Param", false, "//sqlc:Arg name of param"},
+		{"token only", "// sqlc:Slice", false, "// sqlc:Slice"},
+		{"other prefix not affected", "// Mockery:name Here", false, "// mockery:name here"},
+		{"prefix in the middle not affected", "// Uses sqlc:embed Here", true, "// uses sqlc:embed Here"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &ProcessRequest{TitleCase: tc.titleCase, DirectivePrefixes: []string{"sqlc:"}}
+			assert.Equal(t, tc.expected, convertComment(tc.input, req))
+		})
+	}
+
+	t.Run("split", func(t *testing.T) {
+		token, rest, ok := splitDirectivePrefix(" sqlc:Arg Name", []string{"mockery:", "sqlc:"})
+		assert.True(t, ok)
+		assert.Equal(t, " sqlc:Arg", token)
+		assert.Equal(t, " Name", rest)
+
+		_, _, ok = splitDirectivePrefix(" Name", []string{"sqlc:"})
+		assert.False(t, ok)
+	})
+}
+
+// TestInterfaceComments tests that comments of interface methods are converted, while the interface doc is kept
+func TestInterfaceComments(t *testing.T) {
+	src := `package test
+
+// Store Keeps The Records
+type Store interface {
+	// Get Returns A Record
+	Get(id string) (string, error)
+
+	// Put Saves A Record
+	Put(id, value string) error // Trailing Comment Here
+	Embedded
+}
+`
+	res, _, err := processSource(stdinFileName, []byte(src), &ProcessRequest{OutputMode: "print"})
+	require.NoError(t, err)
+	assert.Contains(t, res, "// Store Keeps The Records\n")
+	assert.Contains(t, res, "// get returns a record\n")
+	assert.Contains(t, res, "// put saves a record\n")
+	assert.Contains(t, res, "// trailing comment here\n")
+}
+
+// TestStructTagComments tests that comments echoing struct tags are preserved verbatim
+func TestStructTagComments(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"single tag", `// json:"UserID"`, `// json:"UserID"`},
+		{"multiple tags", `// Json:"UserID" Validate:"Required"`, `// Json:"UserID" Validate:"Required"`},
+		{"tag in prose", `// Serialized As json:"UserName" Field`, `// Serialized As json:"UserName" Field`},
+		{"colon without quotes processed", `// Result: OK Value`, `// result: ok value`},
+		{"quotes without key processed", `// Returns "ok" Value`, `// returns "ok" value`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, convertCommentToLowercase(tc.input))
+		})
+	}
+}
+
+// TestStripObvious tests removal of standalone comments stating the obvious
+func TestStripObvious(t *testing.T) {
+	patterns, err := compileObviousPatterns(nil)
+	require.NoError(t, err)
+
+	src := `package test
+
+func Example() int {
+	a := 1
+	// Initialize the variable
+	x := 0
+	// Sum Up Both Values
+	x += a // Return the result
+	// Return the result.
+	return x
+}
+`
+	res, changes, err := processSource("test.go", []byte(src), &ProcessRequest{TitleCase: true, ObviousPatterns: patterns})
+	require.NoError(t, err)
+	expected := `package test
+
+func Example() int {
+	a := 1
+	x := 0
+	// sum Up Both Values
+	x += a	// return the result
+	return x
+}
+`
+	assert.Equal(t, expected, res, "trailing comment is converted, not removed")
+	require.Len(t, changes, 4)
+	assert.Equal(t, Change{File: "test.go", Line: 5, Column: 2, Before: "// Initialize the variable"}, changes[0])
+	assert.Equal(t, 7, changes[1].Line)
+	assert.Equal(t, 8, changes[2].Line)
+	assert.Equal(t, Change{File: "test.go", Line: 9, Column: 2, Before: "// Return the result."}, changes[3])
+
+	t.Run("disabled by default", func(t *testing.T) {
+		res, _, err := processSource("test.go", []byte(src), &ProcessRequest{TitleCase: true})
+		require.NoError(t, err)
+		assert.Contains(t, res, "// initialize the variable")
+		assert.Contains(t, res, "// return the result.")
+	})
+
+	t.Run("custom patterns", func(t *testing.T) {
+		patterns, err := compileObviousPatterns([]string{`(?i)^sum up`})
+		require.NoError(t, err)
+		res, _, err := processSource("test.go", []byte(src), &ProcessRequest{TitleCase: true, ObviousPatterns: patterns})
+		require.NoError(t, err)
+		assert.NotContains(t, res, "Both Values")
+		assert.Contains(t, res, "// initialize the variable")
+
+		_, err = compileObviousPatterns([]string{"("})
+		require.Error(t, err)
+	})
+
+	t.Run("comments outside functions and multi-line groups kept", func(t *testing.T) {
+		src := "package test\n\n// Return the result\nvar v = 1\n\nfunc f() {\n\t// Return the result\n\t// of the call\n\tf()\n}\n"
+		res, _, err := processSource("test.go", []byte(src), &ProcessRequest{ObviousPatterns: patterns})
+		require.NoError(t, err)
+		assert.Equal(t, strings.Replace(src, "\t// Return", "\t// return", 1), res)
+	})
+
+	t.Run("struct fields and var blocks kept", func(t *testing.T) {
+		src := "package test\n\ntype T struct {\n\t// Initialize the variable\n\tA int\n}\n\nvar (\n\t// Return the result\n\tv = 1\n)\n"
+		res, changes, err := processSource("test.go", []byte(src), &ProcessRequest{TitleCase: true, ObviousPatterns: patterns})
+		require.NoError(t, err)
+		assert.Contains(t, res, "\t// initialize the variable\n\tA int")
+		assert.Contains(t, res, "\t// return the result\n\tv = 1")
+		assert.Len(t, changes, 2, "converted, not removed")
+	})
+}
+
+// TestDeferAndGoFuncLitComments tests that comments inside deferred and goroutine closures are converted
+func TestDeferAndGoFuncLitComments(t *testing.T) {
+	src := `package test
+
+func Example() {
+	defer func() {
+		// Deferred Cleanup
+	}()
+	go func() { // Goroutine Start
+		// Background Work
+	}()
+	defer wg.Wait() // Wait For All
+}
+
+var handler = func() {
+	// Package Level Closure
+}
+`
+	res, changes, err := processSource("test.go", []byte(src), &ProcessRequest{TitleCase: true})
+	require.NoError(t, err)
+	assert.Len(t, changes, 5)
+	for _, expected := range []string{"// deferred Cleanup", "// goroutine Start", "// background Work",
+		"// wait For All", "// package Level Closure"} {
+		assert.Contains(t, res, expected)
+	}
+}
+
+// TestMinUpperRun tests converting only comments with a run of uppercase letters
+func TestMinUpperRun(t *testing.T) {
+	assert.Equal(t, 0, maxUpperRun("// only lowercase"))
+	assert.Equal(t, 1, maxUpperRun("// This is"))
+	assert.Equal(t, 4, maxUpperRun("// THIS IS"))
+	assert.Equal(t, 4, maxUpperRun("// use HTTP-Server"))
+
+	src := "package test\n\nfunc Example() {\n\t// THIS IS Important\n\t// This is normal\n\t// The API Call\n}\n"
+	res, changes, err := processSource("test.go", []byte(src), &ProcessRequest{MinUpperRun: 4})
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Contains(t, res, "// this is important")
+	assert.Contains(t, res, "// This is normal")
+	assert.Contains(t, res, "// The API Call")
+
+	_, changes, err = processSource("test.go", []byte(src), &ProcessRequest{})
+	require.NoError(t, err)
+	assert.Len(t, changes, 3, "all comments converted without the filter")
+}
+
+// TestPreserveExportedDocs tests keeping comments of exported fields and methods
+func TestPreserveExportedDocs(t *testing.T) {
+	src := `package test
+
+type Config struct {
+	// Name Of The Service
+	Name string // Used In Logs
+	// Internal Retry Counter
+	retries int
+	a, B int // Mixed Names Field
+}
+
+func Example() {
+	type local interface {
+		// Run Starts The Job
+		Run()
+		// Stop Job
+		stop()
+	}
+	// Regular Comment
+}
+`
+	res, changes, err := processSource("test.go", []byte(src), &ProcessRequest{TitleCase: true, PreserveExportedDocs: true})
+	require.NoError(t, err)
+	assert.Len(t, changes, 3)
+	for _, kept := range []string{"// Name Of The Service", "// Used In Logs", "// Mixed Names Field", "// Run Starts The Job"} {
+		assert.Contains(t, res, kept)
+	}
+	for _, converted := range []string{"// internal Retry Counter", "// stop Job", "// regular Comment"} {
+		assert.Contains(t, res, converted)
+	}
+
+	_, changes, err = processSource("test.go", []byte(src), &ProcessRequest{TitleCase: true})
+	require.NoError(t, err)
+	assert.Len(t, changes, 7, "exported docs converted without the option")
+}
+
+// TestWantDirectives tests that analysistest expectations are preserved verbatim
+func TestWantDirectives(t *testing.T) {
+	for _, comment := range []string{`// want "foo"`, `// want "Unused Variable X" "Second"`,
+		"// want `Call To Deprecated [A-Z]+`", `//want "Bar"`} {
+		assert.Equal(t, comment, convertCommentToLowercase(comment))
+		assert.Equal(t, comment, convertCommentToTitleCase(comment))
+	}
+	assert.Equal(t, "// want to check it", convertCommentToLowercase("// Want To Check it"), "prose is not a directive")
+	assert.Equal(t, "// wanted Result", convertCommentToTitleCase("// Wanted Result"))
+
+	src := "package test\n\nfunc Example() {\n\tx := 1 // want \"X Declared And Not Used\"\n\t// Some Comment\n}\n"
+	res, changes, err := processSource("test.go", []byte(src), &ProcessRequest{TitleCase: true})
+	require.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Contains(t, res, `// want "X Declared And Not Used"`)
+}
+
+// TestBlockComments tests processing of block comments line by line, keeping the decoration
+func TestBlockComments(t *testing.T) {
+	tbl := []struct {
+		name, input, title, full string
+	}{
+		{name: "single line", input: "/* Foo Comment */", title: "/* foo Comment */", full: "/* foo comment */"},
+		{name: "no spaces", input: "/*Foo*/", title: "/*foo*/", full: "/*foo*/"},
+		{name: "empty", input: "/**/", title: "/**/", full: "/**/"},
+		{name: "javadoc style", input: "/**\n\t * Foo Does Things\n\t * With userID Value\n\t */",
+			title: "/**\n\t * foo Does Things\n\t * with userID Value\n\t */",
+			full:  "/**\n\t * foo does things\n\t * with userID value\n\t */"},
+		{name: "special indicator line", input: "/*\n TODO Fix This\n Other Line\n*/",
+			title: "/*\n TODO Fix This\n other Line\n*/", full: "/*\n TODO Fix This\n other line\n*/"},
+		{name: "line directive", input: "/*line Foo.go:10*/", title: "/*line Foo.go:10*/", full: "/*line Foo.go:10*/"},
+		{name: "indented lines", input: "/*\n   First Line\n      Indented Deeper\n*/",
+			title: "/*\n   first Line\n      indented Deeper\n*/", full: "/*\n   first line\n      indented deeper\n*/"},
+		{name: "text after opening", input: "/* First Line\n * Second Line */",
+			title: "/* first Line\n * second Line */", full: "/* first line\n * second line */"},
+		{name: "stars without space", input: "/*\n **Bold Line\n *Next Line\n */",
+			title: "/*\n **bold Line\n *next Line\n */", full: "/*\n **bold line\n *next line\n */"},
+		{name: "url on one line", input: "/*\n * See HTTPS://Example.com/Path\n * Next Line\n */",
+			title: "/*\n * see HTTPS://Example.com/Path\n * next Line\n */",
+			full:  "/*\n * see HTTPS://Example.com/Path\n * next line\n */"},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.title, convertCommentToTitleCase(tt.input))
+			assert.Equal(t, tt.full, convertCommentToLowercase(tt.input))
+		})
+	}
+
+	t.Run("in function body", func(t *testing.T) {
+		src := "package test\n\n/* Doc Comment */\nfunc Example() {\n\t/* This Comment */\n\tx := 1 /* Inline Note */\n\t_ = x\n}\n"
+		res, changes, err := processSource("test.go", []byte(src), &ProcessRequest{TitleCase: true})
+		require.NoError(t, err)
+		assert.Len(t, changes, 2)
+		assert.Contains(t, res, "/* Doc Comment */")
+		assert.Contains(t, res, "/* this Comment */")
+		assert.Contains(t, res, "/* inline Note */")
+	})
+
+	t.Run("doc comments", func(t *testing.T) {
+		req := &ProcessRequest{TitleCase: true, DocComments: true}
+		assert.Equal(t, "/**\n * Foo does Things\n *\n * Deprecated: Use Bar Instead\n */",
+			convertDocComment("/**\n * Foo Does Things\n *\n * Deprecated: Use Bar Instead\n */", []string{"Foo"}, req))
+		assert.Equal(t, "/*\nDeprecated: Use Bar\nother Line\n*/",
+			convertDocComment("/*\nDeprecated: Use Bar\nOther Line\n*/", []string{"Foo"}, req))
+		assert.Equal(t, "/* Deprecated: Use Bar */", convertDocComment("/* Deprecated: Use Bar */", nil, req))
+	})
+}
+
+// TestDocSlashComments tests that "///" and "//!" doc-annotation comments are preserved unless requested
+func TestDocSlashComments(t *testing.T) {
+	for _, comment := range []string{"/// Foo Does Things", "//! Bar Is Documented", "///Foo"} {
+		assert.Equal(t, comment, convertCommentToLowercase(comment))
+		assert.Equal(t, comment, convertCommentToTitleCase(comment))
+	}
+
+	req := &ProcessRequest{TitleCase: true, DocSlash: true}
+	assert.Equal(t, "/// foo Does Things", convertComment("/// Foo Does Things", req))
+	assert.Equal(t, "//! bar Is Documented", convertComment("//! Bar Is Documented", req))
+	assert.Equal(t, "/// foo does things", convertComment("/// Foo Does Things", &ProcessRequest{DocSlash: true}))
+}
+
+// TestLeadingCapsOnly tests lowercasing only the leading run of all-caps words
+func TestLeadingCapsOnly(t *testing.T) {
+	tbl := []struct {
+		input, expected string
+	}{
+		{"// THIS RETURNS the userID value", "// this returns the userID value"},
+		{"// Returns the userID", "// Returns the userID"},
+		{"// HTTP server started", "// HTTP server started"},
+		{"// THIS IS A Test Case", "// this is a Test Case"},
+		{"// VERY IMPORTANT: check the result", "// very important: check the result"},
+		{"// ALL CAPS COMMENT", "// all caps comment"},
+		{"// USE MAX_SIZE here", "// USE MAX_SIZE here"},
+		{"// DECODE H264 STREAM", "// DECODE H264 STREAM"},
+		{"// CALL Foo THEN BAR", "// CALL Foo THEN BAR"},
+		{"// PLEASE CALL Foo THEN BAR", "// please call Foo THEN BAR"},
+		{"//nolint:gosec // THIS IS fine", "//nolint:gosec // this is fine"},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, convertComment(tt.input, &ProcessRequest{LeadingCapsOnly: true}))
+			assert.Equal(t, tt.expected, convertComment(tt.input, &ProcessRequest{LeadingCapsOnly: true, TitleCase: true}))
+		})
+	}
+}
+
+// TestPreserveColonHeaders tests keeping short section headers ending with a colon
+func TestPreserveColonHeaders(t *testing.T) {
+	tbl := []struct {
+		input, expected string
+	}{
+		{"// Steps:", "// Steps:"},
+		{"// Note the following:", "// Note the following:"},
+		{"// Steps: ", "// Steps: "},
+		{"// Returns: X", "// returns: X"},
+		{"// This is a much longer sentence that ends with a colon:", "// this is a much longer sentence that ends with a colon:"},
+		{"// Regular comment", "// regular comment"},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, convertComment(tt.input, &ProcessRequest{TitleCase: true, PreserveColonHeaders: true}))
+		})
+	}
+
+	assert.Equal(t, "// steps:", convertComment("// Steps:", &ProcessRequest{TitleCase: true}), "converted without the option")
+}
+
+// TestPreserveSingleCaps tests keeping standalone single capital letters, like math variables
+func TestPreserveSingleCaps(t *testing.T) {
+	tbl := []struct {
+		name, input, expected string
+		title                 bool
+	}{
+		{name: "function of variable", input: "// P(X)", expected: "// P(X)"},
+		{name: "variables in prose", input: "// Given Theta and X", expected: "// given theta and X"},
+		{name: "sentence-initial article", input: "// A Value of X. I Think so", expected: "// a value of X. i think so"},
+		{name: "variable A in the middle", input: "// Sum of A and B", expected: "// sum of A and B"},
+		{name: "letters of words and identifiers", input: "// X1 And X_Y Are Not", expected: "// X1 and x_y are not"},
+		{name: "title case variable", input: "// P(X) Is Zero", expected: "// P(X) Is Zero", title: true},
+		{name: "title case article", input: "// A Value", expected: "// a Value", title: true},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, convertComment(tt.input, &ProcessRequest{TitleCase: tt.title, PreserveSingleCaps: true}))
+		})
+	}
+
+	assert.Equal(t, "// p(x)", convertComment("// P(X)", &ProcessRequest{}), "converted without the option")
+}
+
+// TestPreserveDeclared tests that words matching identifiers declared in the package are kept
+func TestPreserveDeclared(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	require.NoError(t, os.WriteFile("types.go",
+		[]byte("package test\n\ntype Config struct {\n\tTimeout int\n}\n\nconst Retries = 3\n"), 0o600))
+	src := "package test\n\nfunc Load() {\n\t// Config Holds Settings\n\t// Uses Timeout And Retries From Load\n}\n"
+
+	run := func(req *ProcessRequest) string {
+		require.NoError(t, os.WriteFile("load.go", []byte(src), 0o600))
+		var stdoutBuf, stderrBuf bytes.Buffer
+		processFile("load.go", req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		res, err := os.ReadFile("load.go")
+		require.NoError(t, err)
+		return string(res)
+	}
+
+	t.Run("title case", func(t *testing.T) {
+		res := run(&ProcessRequest{OutputMode: "inplace", TitleCase: true, PreserveDeclared: true})
+		assert.Contains(t, res, "// Config Holds Settings")
+		assert.Contains(t, res, "// uses Timeout And Retries From Load")
+	})
+
+	t.Run("full", func(t *testing.T) {
+		res := run(&ProcessRequest{OutputMode: "inplace", PreserveDeclared: true})
+		assert.Contains(t, res, "// Config holds settings")
+		assert.Contains(t, res, "// uses Timeout and Retries from Load")
+	})
+
+	t.Run("without the option", func(t *testing.T) {
+		res := run(&ProcessRequest{OutputMode: "inplace", TitleCase: true})
+		assert.Contains(t, res, "// config Holds Settings")
+	})
+
+	t.Run("parallel", func(t *testing.T) {
+		files := []string{"load.go", "load2.go", "load3.go"}
+		for _, name := range files {
+			require.NoError(t, os.WriteFile(name, []byte(src), 0o600))
+		}
+		req := &ProcessRequest{OutputMode: "inplace", PreserveDeclared: true, Jobs: 3}
+		processFilesParallel(files, req, OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		for _, name := range files {
+			res, err := os.ReadFile(name)
+			require.NoError(t, err)
+			assert.Contains(t, string(res), "// uses Timeout and Retries from Load", name)
+		}
+		require.NotNil(t, req.declaredCache, "workers share the cache of declared names")
+		names, ok := req.declaredCache.get(".")
+		require.True(t, ok)
+		assert.True(t, names["Retries"])
+		for _, name := range files[1:] {
+			require.NoError(t, os.Remove(name))
+		}
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		res, _, err := processSource(stdinFileName, []byte(src), &ProcessRequest{OutputMode: "print", PreserveDeclared: true})
+		require.NoError(t, err)
+		assert.Contains(t, res, "// uses timeout and retries from Load", "only declarations of the source itself")
+	})
+
+	t.Run("params and locals", func(t *testing.T) {
+		code := `package test
+
+const MAX_RETRIES = 3
+
+func (s *Store) Fetch(userid string, OPTS ...int) (Result, error) {
+	// Retry Up To MAX_RETRIES For USERID userid With OPTS
+	for IDX, v := range OPTS {
+		// Skip IDX When v Is Zero
+	}
+	RES := Result{}
+	// Return RES
+	return RES, nil
+}
+`
+		res, _, err := processSource(stdinFileName, []byte(code), &ProcessRequest{OutputMode: "print", PreserveDeclared: true})
+		require.NoError(t, err)
+		assert.Contains(t, res, "// retry up to MAX_RETRIES for userid userid with OPTS")
+		assert.Contains(t, res, "// skip IDX when v is zero")
+		assert.Contains(t, res, "// return RES")
+	})
+
+	t.Run("declared names", func(t *testing.T) {
+		code := `package test
+
+type T[K comparable] struct{ Field int }
+
+type I interface{ Method() }
+
+func (recv T[K]) F(param int) (result int) {
+	local := 1
+	var other int
+	for key, val := range []int{} {
+		_, _ = key, val
+	}
+	_, _ = local, other
+	return
+}
+`
+		file, err := parser.ParseFile(token.NewFileSet(), "", code, 0)
+		require.NoError(t, err)
+		names := slices.Sorted(maps.Keys(declaredNames([]*ast.File{file})))
+		assert.Equal(t, []string{"F", "Field", "I", "K", "Method", "T", "key", "local", "other", "param", "recv", "result", "val"}, names)
+	})
+}
+
+// TestDocComments tests processing of declaration doc comments with the leading declared name kept
+func TestDocComments(t *testing.T) {
+	src := `package test
+
+// Config Holds The Settings
+// Used By Everything
+type Config struct{}
+
+// Load Reads The Config
+//
+// Deprecated: Use LoadFile Instead
+func (c *Config) Load() {}
+
+/* Save Writes The Config */
+func Save() {}
+
+// MaxRetries Is The Retry Limit
+const MaxRetries = 3
+
+// Group Of Values
+var (
+	// A Is The First
+	A, B = 1, 2
+)
+
+// Detached Comment Stays
+
+// Helper Does Things
+func helper() {}
+
+// NewConfig Makes A Config
+//
+// Example:
+//
+//	Cfg := NewConfig()
+//	Cfg.Load()
+func NewConfig() *Config { return nil }
+
+/*
+ * Parse Reads Values
+ *
+ *	Parse(Args)
+ */
+func Parse() {}
+`
+
+	t.Run("title case", func(t *testing.T) {
+		res, _, err := processSource(stdinFileName, []byte(src), &ProcessRequest{OutputMode: "print", TitleCase: true, DocComments: true})
+		require.NoError(t, err)
+		assert.Contains(t, res, "// Config holds The Settings\n// used By Everything\n")
+		assert.Contains(t, res, "// Load reads The Config\n//\n// Deprecated: Use LoadFile Instead\n")
+		assert.Contains(t, res, "/* Save writes The Config */")
+		assert.Contains(t, res, "// MaxRetries is The Retry Limit")
+		assert.Contains(t, res, "// group Of Values")
+		assert.Contains(t, res, "// A is The First")
+		assert.Contains(t, res, "// Detached Comment Stays", "comments not attached to declarations are kept")
+		assert.Contains(t, res, "// helper Does Things", "leading word is kept only if it matches the name exactly")
+	})
+
+	t.Run("full", func(t *testing.T) {
+		res, _, err := processSource(stdinFileName, []byte(src), &ProcessRequest{OutputMode: "print", DocComments: true})
+		require.NoError(t, err)
+		assert.Contains(t, res, "// Config holds the settings\n// used by everything\n")
+		assert.Contains(t, res, "// Deprecated: Use LoadFile Instead")
+		assert.Contains(t, res, "// MaxRetries is the retry limit")
+		assert.Contains(t, res, "// NewConfig makes a config\n//\n// Example:\n//\n//\tCfg := NewConfig()\n//\tCfg.Load()\n",
+			"code blocks and labels introducing them are kept")
+		assert.Contains(t, res, "/*\n * Parse reads values\n *\n *\tParse(Args)\n */", "code lines of block comments are kept")
+	})
+
+	t.Run("without the option", func(t *testing.T) {
+		res, _, err := processSource(stdinFileName, []byte(src), &ProcessRequest{OutputMode: "print", TitleCase: true})
+		require.NoError(t, err)
+		assert.Contains(t, res, "// Config Holds The Settings")
+		assert.Contains(t, res, "// MaxRetries Is The Retry Limit")
+		assert.Contains(t, res, "// A Is The First")
+	})
+}
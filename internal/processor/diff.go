@@ -0,0 +1,418 @@
+package processor
+
+import (
+	"cmp"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fatih/color"
+)
+
+// handleDiffMode shows a diff between original and modified content with custom writers
+func handleDiffMode(fileName string, origBytes []byte, fset *token.FileSet, node *ast.File, req *ProcessRequest,
+	writers OutputWriters) {
+	// generate modified content
+	modifiedContent, err := getModifiedContent(fset, node, req.printerConfig())
+	if err != nil {
+		writers.errorf("Error creating diff: %v\n", err)
+		return
+	}
+	printDiff(fileName, string(origBytes), modifiedContent, req, writers)
+}
+
+// printDiff shows a diff between the original and modified content, formatted if requested
+func printDiff(fileName, original, modifiedContent string, req *ProcessRequest, writers OutputWriters) {
+	originalContent := original
+
+	// unified diff is made against the original as is, so it can be applied to the file
+	if req.DiffFormat == "unified" {
+		modifiedContent = req.matchLineEndings(originalContent, req.formatSource(fileName, modifiedContent, writers))
+		fmt.Fprint(writers.Stdout, unifiedDiff(fileName, originalContent, modifiedContent, req.DiffContext))
+		return
+	}
+
+	// apply formatting if requested
+	if req.Format {
+		// format both original and modified content for consistency
+		// gofmt normalizes line endings, the formatted original gets them back to be compared as is
+		originalContent = req.matchLineEndings(original, formatWithGofmt(originalContent, req.GofmtBin, writers))
+	}
+	// imports of the original are left as is, so their changes show in the diff
+	modifiedContent = req.matchLineEndings(original, req.formatSource(fileName, modifiedContent, writers))
+
+	// display diff with colors
+	cyan := color.New(color.FgCyan, color.Bold).SprintFunc()
+	fmt.Fprintf(writers.Stdout, "%s\n", cyan("--- "+fileName+" (original)"))
+	fmt.Fprintf(writers.Stdout, "%s\n", cyan("+++ "+fileName+" (modified)"))
+
+	// columns are readable only on a color terminal, fall back to the stacked diff otherwise
+	if req.SideBySide && !color.NoColor {
+		fmt.Fprint(writers.Stdout, sideBySideDiff(originalContent, modifiedContent, terminalWidth()))
+		return
+	}
+	fmt.Fprint(writers.Stdout, simpleDiff(originalContent, modifiedContent, req.DiffContext))
+}
+
+// defaultTerminalWidth is the terminal width used if it is not set in the COLUMNS environment variable
+const defaultTerminalWidth = 160
+
+// terminalWidth returns the terminal width from the COLUMNS environment variable, or the default width
+func terminalWidth() int {
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return defaultTerminalWidth
+}
+
+// sideBySideDiff creates a colorized diff output with original and modified lines in two columns, like "diff -y"
+func sideBySideDiff(original, modified string, width int) string {
+	origLines := strings.Split(original, "\n")
+	modLines := strings.Split(modified, "\n")
+
+	const separator = " | "
+	colWidth := max((width-len(separator))/2, 10)
+
+	red := color.New(color.FgRed, color.Bold).SprintFunc()
+	green := color.New(color.FgGreen, color.Bold).SprintFunc()
+
+	// column expands tabs and pads or truncates the line to the column width
+	column := func(line string) string {
+		runes := []rune(strings.ReplaceAll(line, "\t", "    "))
+		if len(runes) > colWidth {
+			return string(runes[:colWidth-1]) + "…"
+		}
+		return string(runes) + strings.Repeat(" ", colWidth-len(runes))
+	}
+
+	// a run of consecutive changed lines is shown as rows of removed and added lines side by side,
+	// a column is left empty if one side of the run has more lines, like for stripped comments
+	var diff strings.Builder
+	ops := diffLines(origLines, modLines, sameLine)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		var removed, added []string
+		for ; i < len(ops) && ops[i].kind != ' '; i++ {
+			if ops[i].kind == '-' {
+				removed = append(removed, ops[i].line)
+				continue
+			}
+			added = append(added, ops[i].line)
+		}
+		for row := range max(len(removed), len(added)) {
+			var orig, mod string
+			if row < len(removed) {
+				orig = removed[row]
+			}
+			if row < len(added) {
+				mod = added[row]
+			}
+			diff.WriteString(red(column(orig)) + separator + green(strings.TrimRight(column(mod), " ")) + "\n")
+		}
+	}
+
+	return diff.String()
+}
+
+// sameLine checks if lines are equal ignoring trailing whitespace, the printer strips it
+// from the modified content, so it is not a change made by the tool
+func sameLine(original, modified string) bool {
+	return strings.TrimRightFunc(original, unicode.IsSpace) == strings.TrimRightFunc(modified, unicode.IsSpace)
+}
+
+// unifiedDiff creates a unified diff of the file, with hunks of changed lines and context lines around them,
+// which can be applied with "patch -p0". returns an empty string if there are no differences
+func unifiedDiff(fileName, original, modified string, context int) string {
+	// lines keep their line breaks, so a missing line break at the end of a file is a difference too
+	ops := diffLines(splitLines(original), splitLines(modified), func(a, b string) bool { return a == b })
+	hunks := diffHunks(ops, context)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "--- %s\n+++ %s\n", fileName, fileName)
+	for _, h := range hunks {
+		diff.WriteString(h.header() + "\n")
+		for _, op := range ops[h.start:h.end] {
+			diff.WriteString(string(op.kind) + op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				diff.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	return diff.String()
+}
+
+// splitLines splits the content into lines keeping their line breaks, the final line break doesn't start
+// another empty line
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffHunk is a range of diff operations, changes with unchanged context lines around them,
+// and the ranges of lines it covers in the original and modified content
+type diffHunk struct {
+	start, end           int // operations range
+	origStart, origCount int
+	modStart, modCount   int
+}
+
+// header returns the hunk header, like "@@ -1,4 +1,5 @@"
+func (h diffHunk) header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.origStart, h.origCount, h.modStart, h.modCount)
+}
+
+// diffHunks groups changed operations into hunks with up to context unchanged lines around them
+func diffHunks(ops []diffOp, context int) []diffHunk {
+	// positions of each operation in the original and modified lines
+	origPos, modPos := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	var changed []int
+	for i, op := range ops {
+		origPos[i+1], modPos[i+1] = origPos[i], modPos[i]
+		if op.kind != '+' {
+			origPos[i+1]++
+		}
+		if op.kind != '-' {
+			modPos[i+1]++
+		}
+		if op.kind != ' ' {
+			changed = append(changed, i)
+		}
+	}
+
+	var res []diffHunk
+	for i := 0; i < len(changed); {
+		// a hunk takes all changes with gaps of unchanged lines not larger than two contexts, like diff -u does
+		j := i
+		for j+1 < len(changed) && changed[j+1]-changed[j]-1 <= 2*context {
+			j++
+		}
+		h := diffHunk{start: max(changed[i]-context, 0), end: min(changed[j]+context+1, len(ops))}
+		h.origStart, h.origCount = origPos[h.start]+1, origPos[h.end]-origPos[h.start]
+		h.modStart, h.modCount = modPos[h.start]+1, modPos[h.end]-modPos[h.start]
+		if h.origCount == 0 {
+			h.origStart-- // empty range refers to the line before it
+		}
+		if h.modCount == 0 {
+			h.modStart--
+		}
+		res = append(res, h)
+		i = j + 1
+	}
+	return res
+}
+
+// diffOp is an operation of a line diff, a line kept (' '), removed from the original ('-') or added ('+')
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines returns the shortest list of operations turning lines a into lines b, using the linear space
+// variant of the Myers algorithm. removed lines go before added ones in each run of changes
+func diffLines(a, b []string, equal func(a, b string) bool) []diffOp {
+	size := (len(a)+len(b)+1)/2 + 1
+	ld := &lineDiff{a: a, b: b, equal: equal, offset: size, fwd: make([]int, 2*size+1), bwd: make([]int, 2*size+1)}
+	ops := make([]diffOp, 0, max(len(a), len(b)))
+	ops = ld.compare(ops, 0, len(a), 0, len(b))
+
+	// a run of changes between kept lines can be in any order, show it as removed lines replaced by added ones
+	for start := 0; start < len(ops); {
+		end := start
+		for end < len(ops) && ops[end].kind != ' ' {
+			end++
+		}
+		slices.SortStableFunc(ops[start:end], func(x, y diffOp) int { return cmp.Compare(y.kind, x.kind) })
+		start = end + 1
+	}
+	return ops
+}
+
+// lineDiff keeps lines compared by diffLines and the furthest points reached on each diagonal k = x - y
+// by the forward and backward searches, indexed by k + offset and shared by all subproblems
+type lineDiff struct {
+	a, b     []string
+	equal    func(a, b string) bool
+	offset   int
+	fwd, bwd []int
+}
+
+// compare appends operations turning a[aLo:aHi] into b[bLo:bHi] to ops. common leading and trailing lines
+// are kept, the rest is split by the middle snake of the shortest path into two smaller subproblems
+func (ld *lineDiff) compare(ops []diffOp, aLo, aHi, bLo, bHi int) []diffOp {
+	for aLo < aHi && bLo < bHi && ld.equal(ld.a[aLo], ld.b[bLo]) {
+		ops = append(ops, diffOp{kind: ' ', line: ld.a[aLo]})
+		aLo, bLo = aLo+1, bLo+1
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && ld.equal(ld.a[aHi-suffix-1], ld.b[bHi-suffix-1]) {
+		suffix++
+	}
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	switch {
+	case aLo == aHi:
+		for _, line := range ld.b[bLo:bHi] {
+			ops = append(ops, diffOp{kind: '+', line: line})
+		}
+	case bLo == bHi:
+		for _, line := range ld.a[aLo:aHi] {
+			ops = append(ops, diffOp{kind: '-', line: line})
+		}
+	default:
+		mid := ld.middleSnake(aLo, aHi, bLo, bHi)
+		ops = ld.compare(ops, aLo, mid.x, bLo, mid.y)
+		for _, line := range ld.a[mid.x:mid.u] {
+			ops = append(ops, diffOp{kind: ' ', line: line})
+		}
+		ops = ld.compare(ops, mid.u, aHi, mid.v, bHi)
+	}
+
+	for _, line := range ld.a[aHi : aHi+suffix] {
+		ops = append(ops, diffOp{kind: ' ', line: line})
+	}
+	return ops
+}
+
+// snake is a run of equal lines a[x:u] and b[y:v], possibly empty
+type snake struct {
+	x, y, u, v int
+}
+
+// middleSnake finds the snake in the middle of the shortest path turning a[aLo:aHi] into b[bLo:bHi].
+// the forward search from the start and the backward search from the end advance by one edit in turn
+// until they overlap
+func (ld *lineDiff) middleSnake(aLo, aHi, bLo, bHi int) snake {
+	ld.fwd[ld.offset+1], ld.bwd[ld.offset+1] = 0, 0
+	for d := 0; d <= (aHi-aLo+bHi-bLo+1)/2; d++ {
+		if s, ok := ld.forward(d, aLo, aHi, bLo, bHi); ok {
+			return s
+		}
+		if s, ok := ld.backward(d, aLo, aHi, bLo, bHi); ok {
+			return s
+		}
+	}
+	return snake{x: aLo, y: bLo, u: aLo, v: bLo} // not reached, the searches overlap by the middle of the shortest path
+}
+
+// forward advances the forward search to d edits, returns the middle snake and true if it overlaps
+// the backward search made with d-1 edits, possible for the odd difference of lengths only
+func (ld *lineDiff) forward(d, aLo, aHi, bLo, bHi int) (snake, bool) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	for k := -d; k <= d; k += 2 {
+		px := ld.furthest(ld.fwd, k, d)
+		py := px - k
+		ex, ey := px, py
+		for ex < n && ey < m && ld.equal(ld.a[aLo+ex], ld.b[bLo+ey]) {
+			ex, ey = ex+1, ey+1
+		}
+		ld.fwd[ld.offset+k] = ex
+		if delta%2 != 0 && delta-k >= -(d-1) && delta-k <= d-1 && ex+ld.bwd[ld.offset+delta-k] >= n {
+			return snake{x: aLo + px, y: bLo + py, u: aLo + ex, v: bLo + ey}, true
+		}
+	}
+	return snake{}, false
+}
+
+// backward advances the backward search to d edits, returns the middle snake and true if it overlaps
+// the forward search made with d edits, possible for the even difference of lengths only.
+// x and y of the backward search are counted from the end
+func (ld *lineDiff) backward(d, aLo, aHi, bLo, bHi int) (snake, bool) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	for k := -d; k <= d; k += 2 {
+		px := ld.furthest(ld.bwd, k, d)
+		py := px - k
+		ex, ey := px, py
+		for ex < n && ey < m && ld.equal(ld.a[aHi-ex-1], ld.b[bHi-ey-1]) {
+			ex, ey = ex+1, ey+1
+		}
+		ld.bwd[ld.offset+k] = ex
+		if delta%2 == 0 && delta-k >= -d && delta-k <= d && ex+ld.fwd[ld.offset+delta-k] >= n {
+			return snake{x: aHi - ex, y: bHi - ey, u: aHi - px, v: bHi - py}, true
+		}
+	}
+	return snake{}, false
+}
+
+// furthest returns the furthest x on diagonal k before following equal lines in round d, moving down
+// by an insertion from the diagonal above or right by a deletion from the diagonal below
+func (ld *lineDiff) furthest(vec []int, k, d int) int {
+	if k == -d || (k != d && vec[ld.offset+k-1] < vec[ld.offset+k+1]) {
+		return vec[ld.offset+k+1]
+	}
+	return vec[ld.offset+k-1] + 1
+}
+
+// simpleDiff creates a colorized diff output, with hunks of changes and context unchanged lines around them
+// like in unified diffs, or with changed lines only if context is 0
+func simpleDiff(original, modified string, context int) string {
+	// line breaks are trimmed from the lines shown, lines are compared ignoring trailing whitespace anyway
+	origLines, modLines := splitLines(original), splitLines(modified)
+	for i := range origLines {
+		origLines[i] = strings.TrimSuffix(origLines[i], "\n")
+	}
+	for i := range modLines {
+		modLines[i] = strings.TrimSuffix(modLines[i], "\n")
+	}
+
+	// set up colors - use bright versions for better visibility
+	red := color.New(color.FgRed, color.Bold).SprintFunc()
+	green := color.New(color.FgGreen, color.Bold).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+
+	var diff strings.Builder
+
+	// a run of consecutive changed lines is written as one block, all removals followed by all additions,
+	// lines inserted or removed, like stripped comments, don't shift the lines after them
+	write := func(ops []diffOp, withContext bool) {
+		for i := 0; i < len(ops); {
+			if ops[i].kind == ' ' {
+				if withContext {
+					diff.WriteString("  " + ops[i].line + "\n")
+				}
+				i++
+				continue
+			}
+			var removed, added []string
+			for ; i < len(ops) && ops[i].kind != ' '; i++ {
+				if ops[i].kind == '-' {
+					removed = append(removed, ops[i].line)
+					continue
+				}
+				added = append(added, ops[i].line)
+			}
+			for _, line := range removed {
+				diff.WriteString(red("- "+line) + "\n")
+			}
+			for _, line := range added {
+				diff.WriteString(green("+ "+line) + "\n")
+			}
+		}
+	}
+
+	ops := diffLines(origLines, modLines, sameLine)
+	if context <= 0 {
+		write(ops, false)
+		return diff.String()
+	}
+	for _, h := range diffHunks(ops, context) {
+		diff.WriteString(cyan(h.header()) + "\n")
+		write(ops[h.start:h.end], true)
+	}
+	return diff.String()
+}
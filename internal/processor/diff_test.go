@@ -0,0 +1,383 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDiffModeNoColorOutput tests the diff mode with color disabled
+func TestDiffModeNoColorOutput(t *testing.T) {
+	// create a temporary directory for test files
+	tempDir := t.TempDir()
+
+	// create a test file with comments
+	testFile := filepath.Join(tempDir, "color_test.go")
+	content := `package test
+
+func Example() {
+	// THIS COMMENT should be converted
+	x := 1 // ANOTHER COMMENT
+}`
+	err := os.WriteFile(testFile, []byte(content), 0o600)
+	require.NoError(t, err, "Failed to write test file")
+
+	// save original color setting
+	originalNoColor := color.NoColor
+	defer func() { color.NoColor = originalNoColor }()
+
+	// disable colors for this test
+	color.NoColor = true
+
+	// capture output using buffers
+	var stdoutBuf, stderrBuf bytes.Buffer
+	writers := OutputWriters{
+		Stdout: &stdoutBuf,
+		Stderr: &stderrBuf,
+	}
+
+	// process file in diff mode
+	processFile(testFile, &ProcessRequest{OutputMode: "diff"}, writers)
+
+	// verify diff output
+	output := stdoutBuf.String()
+	assert.Contains(t, output, "---", "Should show diff markers")
+	assert.Contains(t, output, "+++", "Should show diff markers")
+	assert.Contains(t, output, "THIS COMMENT", "Should show original comment")
+	assert.Contains(t, output, "this comment", "Should show converted comment")
+	assert.NotContains(t, output, "@@", "no hunks without context")
+
+	// with context the changes are shown in a hunk with unchanged lines around them
+	stdoutBuf.Reset()
+	processFile(testFile, &ProcessRequest{OutputMode: "diff", DiffContext: 1}, writers)
+	assert.Contains(t, stdoutBuf.String(), "@@ -3,4 +3,4 @@\n  func Example() {\n- \t// THIS COMMENT should be converted\n"+
+		"- \tx := 1 // ANOTHER COMMENT\n+ \t// this comment should be converted\n+ \tx := 1\t// another comment\n  }\n")
+
+	// re-enable colors if needed for other tests
+	color.NoColor = originalNoColor
+}
+
+// TestSimpleDiff tests the diff function
+func TestSimpleDiff(t *testing.T) {
+	// save original color setting
+	originalNoColor := color.NoColor
+	defer func() { color.NoColor = originalNoColor }()
+
+	// disable colors for predictable testing
+	color.NoColor = true
+
+	tests := []struct {
+		name     string
+		original string
+		modified string
+		expect   []string
+	}{
+		{
+			name:     "simple change",
+			original: "Line 1\nLine 2\nLine 3",
+			modified: "Line 1\nModified\nLine 3",
+			expect:   []string{"Line 2", "Modified"},
+		},
+		{
+			name:     "comment change",
+			original: "// THIS IS A COMMENT",
+			modified: "// this is a comment",
+			expect:   []string{"THIS IS A COMMENT", "this is a comment"},
+		},
+		{
+			name:     "no change",
+			original: "Line 1\nLine 2",
+			modified: "Line 1\nLine 2",
+			expect:   []string{},
+		},
+		{
+			name:     "add line",
+			original: "Line 1\nLine 2",
+			modified: "Line 1\nLine 2\nLine 3",
+			expect:   []string{"Line 3"},
+		},
+		{
+			name:     "remove line",
+			original: "Line 1\nLine 2\nLine 3",
+			modified: "Line 1\nLine 3",
+			expect:   []string{"Line 2"},
+		},
+		{
+			name:     "trailing whitespace only",
+			original: "Line 1  \nLine 2\t\nLine 3",
+			modified: "Line 1\nLine 2\nLine 3",
+			expect:   []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff := simpleDiff(test.original, test.modified, 0)
+			for _, expect := range test.expect {
+				assert.Contains(t, diff, expect, "Diff should contain expected changes")
+			}
+			if len(test.expect) == 0 {
+				assert.Empty(t, diff, "Diff should be empty when no changes")
+			}
+		})
+	}
+
+	t.Run("consecutive changes grouped", func(t *testing.T) {
+		diff := simpleDiff("Line 1\nLine 2\nLine 3\nLine 4\nLine 5", "Line 1\nline 2\nline 3\nLine 4\nline 5", 0)
+		assert.Equal(t, "- Line 2\n- Line 3\n+ line 2\n+ line 3\n- Line 5\n+ line 5\n", diff)
+	})
+
+	t.Run("changed lines followed by added", func(t *testing.T) {
+		diff := simpleDiff("Line 1\nLine 2", "Line 1\nline 2\nLine 3", 0)
+		assert.Equal(t, "- Line 2\n+ line 2\n+ Line 3\n", diff)
+	})
+
+	t.Run("removed line doesn't shift following lines", func(t *testing.T) {
+		diff := simpleDiff("Line 1\n// Obvious\nLine 2\nLine 3\nLine 4", "Line 1\nLine 2\nLine 3\nline 4", 0)
+		assert.Equal(t, "- // Obvious\n- Line 4\n+ line 4\n", diff)
+	})
+
+	t.Run("inserted line doesn't shift following lines", func(t *testing.T) {
+		diff := simpleDiff("Line 1\nLine 2\nLine 3", "Line 1\nNew\nLine 2\nLine 3", 0)
+		assert.Equal(t, "+ New\n", diff)
+	})
+
+	t.Run("trailing whitespace around removed line", func(t *testing.T) {
+		diff := simpleDiff("Line 1  \nRemoved\nLine 2\t", "Line 1\nLine 2", 0)
+		assert.Equal(t, "- Removed\n", diff)
+	})
+
+	t.Run("context lines", func(t *testing.T) {
+		var orig, mod []string
+		for i := 1; i <= 12; i++ {
+			orig = append(orig, fmt.Sprintf("Line %d", i))
+			mod = append(mod, fmt.Sprintf("Line %d", i))
+		}
+		mod[1], mod[10] = "line 2", "line 11"
+		original, modified := strings.Join(orig, "\n"), strings.Join(mod, "\n")
+
+		assert.Equal(t, "@@ -1,4 +1,4 @@\n  Line 1\n- Line 2\n+ line 2\n  Line 3\n  Line 4\n"+
+			"@@ -9,4 +9,4 @@\n  Line 9\n  Line 10\n- Line 11\n+ line 11\n  Line 12\n", simpleDiff(original, modified, 2))
+
+		// changes close to each other are in one hunk
+		assert.Equal(t, "@@ -1,12 +1,12 @@\n  Line 1\n- Line 2\n+ line 2\n  Line 3\n  Line 4\n  Line 5\n  Line 6\n"+
+			"  Line 7\n  Line 8\n  Line 9\n  Line 10\n- Line 11\n+ line 11\n  Line 12\n", simpleDiff(original, modified, 5))
+
+		assert.Equal(t, "- Line 2\n+ line 2\n- Line 11\n+ line 11\n", simpleDiff(original, modified, 0))
+
+		// a gap of two contexts merges hunks
+		mod[1], mod[10] = "Line 2", "line 11"
+		mod[3] = "line 4"
+		assert.Equal(t, "@@ -1,12 +1,12 @@\n  Line 1\n  Line 2\n  Line 3\n- Line 4\n+ line 4\n  Line 5\n  Line 6\n  Line 7\n"+
+			"  Line 8\n  Line 9\n  Line 10\n- Line 11\n+ line 11\n  Line 12\n", simpleDiff(original, strings.Join(mod, "\n"), 3))
+		assert.Empty(t, simpleDiff(original, original, 3))
+	})
+
+	t.Run("final line break", func(t *testing.T) {
+		original := "Line 1\nLine 2\nLine 3\nLine 4\nLine 5\n"
+		modified := "Line 1\nLine 2\nLine 3\nLine 4\nline 5\n"
+		assert.Equal(t, "@@ -2,4 +2,4 @@\n  Line 2\n  Line 3\n  Line 4\n- Line 5\n+ line 5\n", simpleDiff(original, modified, 3),
+			"no empty line after the final line break")
+		assert.Equal(t, "- Line 5\n+ line 5\n", simpleDiff(original, modified, 0))
+		assert.Empty(t, simpleDiff("", "", 3))
+	})
+
+	t.Run("negative context", func(t *testing.T) {
+		_, err := newProcessRequest(Options{Context: -1, BackupExt: defaultBackupExt}, "diff")
+		require.EqualError(t, err, "--context can't be negative")
+		req, err := newProcessRequest(Options{Context: 0, BackupExt: defaultBackupExt}, "diff")
+		require.NoError(t, err)
+		assert.Equal(t, 0, req.DiffContext)
+	})
+}
+
+// TestUnifiedDiff tests unified diffs with hunk headers and context lines
+func TestUnifiedDiff(t *testing.T) {
+	original := "l1\nl2\nl3\nl4\nl5\nl6\nl7\nl8\nl9\nl10\nl11\nl12\n"
+
+	tbl := []struct {
+		name, modified, expected string
+	}{
+		{name: "no changes", modified: original, expected: ""},
+		{name: "changed line", modified: strings.Replace(original, "l2\n", "L2\n", 1),
+			expected: "--- f.go\n+++ f.go\n@@ -1,5 +1,5 @@\n l1\n-l2\n+L2\n l3\n l4\n l5\n"},
+		{name: "removed line", modified: strings.Replace(original, "l6\n", "", 1),
+			expected: "--- f.go\n+++ f.go\n@@ -3,7 +3,6 @@\n l3\n l4\n l5\n-l6\n l7\n l8\n l9\n"},
+		{name: "separate hunks", modified: strings.Replace(strings.Replace(original, "l1\n", "L1\n", 1), "l12\n", "L12\n", 1),
+			expected: "--- f.go\n+++ f.go\n@@ -1,4 +1,4 @@\n-l1\n+L1\n l2\n l3\n l4\n@@ -9,4 +9,4 @@\n l9\n l10\n l11\n-l12\n+L12\n"},
+		{name: "close changes in one hunk", modified: strings.Replace(strings.Replace(original, "l3\n", "L3\n", 1), "l9\n", "L9\n", 1),
+			expected: "--- f.go\n+++ f.go\n@@ -1,12 +1,12 @@\n l1\n l2\n-l3\n+L3\n l4\n l5\n l6\n l7\n l8\n-l9\n+L9\n l10\n l11\n l12\n"},
+		{name: "missing line break at the end", modified: strings.TrimSuffix(original, "\n"),
+			expected: "--- f.go\n+++ f.go\n@@ -9,4 +9,4 @@\n l9\n l10\n l11\n-l12\n+l12\n\\ No newline at end of file\n"},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, unifiedDiff("f.go", original, tt.modified, 3))
+		})
+	}
+
+	t.Run("without context", func(t *testing.T) {
+		modified := strings.Replace(strings.Replace(original, "l2\n", "L2\n", 1), "l5\n", "L5\n", 1)
+		assert.Equal(t, "--- f.go\n+++ f.go\n@@ -2,1 +2,1 @@\n-l2\n+L2\n@@ -5,1 +5,1 @@\n-l5\n+L5\n",
+			unifiedDiff("f.go", original, modified, 0))
+	})
+
+	t.Run("applied with patch", func(t *testing.T) {
+		if _, err := exec.LookPath("patch"); err != nil {
+			t.Skip("patch not found, skipping test")
+		}
+		t.Chdir(t.TempDir())
+		src := "package test\n\nfunc Example() {\n\t// Some Comment\n\tx := 1  \n\n\t// Return the result\n\t_ = x\n" +
+			strings.Repeat("\tx++\n", 10) + "\t// Another Comment\n}\n"
+		require.NoError(t, os.WriteFile("a.go", []byte(src), 0o600))
+		obvious, err := compileObviousPatterns(defaultObviousPatterns)
+		require.NoError(t, err)
+
+		var diffBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "diff", DiffFormat: "unified", DiffContext: 3, TitleCase: true, ObviousPatterns: obvious}
+		processFile("a.go", req, OutputWriters{Stdout: &diffBuf, Stderr: io.Discard})
+		assert.Equal(t, 2, strings.Count(diffBuf.String(), "\n@@ "), "two hunks expected:\n%s", diffBuf.String())
+
+		expected, _, err := processSource("a.go", []byte(src), &ProcessRequest{TitleCase: true, ObviousPatterns: obvious})
+		require.NoError(t, err)
+
+		cmd := exec.Command("patch", "-p0")
+		cmd.Stdin = &diffBuf
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		res, err := os.ReadFile("a.go")
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(res))
+	})
+}
+
+// TestDiffLines tests the shortest edit script of line diffs
+func TestDiffLines(t *testing.T) {
+	ops := diffLines([]string{"a", "b", "c", "a", "b", "b", "a"}, []string{"c", "b", "a", "b", "a", "c"},
+		func(a, b string) bool { return a == b })
+	var edits, orig, mod []string
+	for _, op := range ops {
+		if op.kind != ' ' {
+			edits = append(edits, string(op.kind)+op.line)
+		}
+		if op.kind != '+' {
+			orig = append(orig, op.line)
+		}
+		if op.kind != '-' {
+			mod = append(mod, op.line)
+		}
+	}
+	assert.Len(t, edits, 5, "shortest edit script of the classic example has 5 edits")
+	assert.Equal(t, []string{"a", "b", "c", "a", "b", "b", "a"}, orig)
+	assert.Equal(t, []string{"c", "b", "a", "b", "a", "c"}, mod)
+
+	assert.Empty(t, diffLines(nil, nil, func(a, b string) bool { return a == b }))
+	assert.Equal(t, []diffOp{{kind: '+', line: "x"}}, diffLines(nil, []string{"x"}, func(a, b string) bool { return a == b }))
+
+	t.Run("many changed lines", func(t *testing.T) {
+		var a, b []string
+		for i := range 4000 {
+			a = append(a, fmt.Sprintf("// Comment %d", i), fmt.Sprintf("x = %d", i))
+			b = append(b, fmt.Sprintf("// comment %d", i), fmt.Sprintf("x = %d", i))
+		}
+		ops := diffLines(a, b, func(a, b string) bool { return a == b })
+		require.Len(t, ops, 12000)
+		for i := range 4000 {
+			assert.Equal(t, []diffOp{{kind: '-', line: a[2*i]}, {kind: '+', line: b[2*i]}, {kind: ' ', line: a[2*i+1]}},
+				ops[3*i:3*i+3], "removed line goes before the added one")
+		}
+	})
+}
+
+// TestSideBySideDiff tests the two-column diff rendering
+func TestSideBySideDiff(t *testing.T) {
+	originalNoColor := color.NoColor
+	defer func() { color.NoColor = originalNoColor }()
+	color.NoColor = true // compare plain text in columns
+
+	original := "package test\n\nfunc Example() {\n\t// Original Comment\n\tx := 1\n}\n"
+	modified := "package test\n\nfunc Example() {\n\t// original Comment\n\tx := 1\n}\n"
+
+	t.Run("changed lines in columns", func(t *testing.T) {
+		res := sideBySideDiff(original, modified, 60)
+		lines := strings.Split(strings.TrimSuffix(res, "\n"), "\n")
+		require.Len(t, lines, 1, "only changed lines are shown")
+		left, right, ok := strings.Cut(lines[0], " | ")
+		require.True(t, ok)
+		assert.Len(t, left, 28)
+		assert.Equal(t, "    // Original Comment", strings.TrimRight(left, " "))
+		assert.Equal(t, "    // original Comment", right)
+	})
+
+	t.Run("long lines truncated", func(t *testing.T) {
+		res := sideBySideDiff("// Very Long Original Comment Line", "// very Long Original Comment Line", 30)
+		assert.Equal(t, "// Very Long… | // very Long…\n", res)
+	})
+
+	t.Run("added and removed lines", func(t *testing.T) {
+		res := sideBySideDiff("a\nb", "a\nb\nc", 20)
+		assert.Equal(t, strings.Repeat(" ", 10)+" | c\n", res)
+		res = sideBySideDiff("a\nb", "a", 20)
+		assert.Equal(t, "b"+strings.Repeat(" ", 9)+" | \n", res)
+	})
+
+	t.Run("removed line doesn't shift the rest", func(t *testing.T) {
+		orig := "func F() {\n\t// Return The Result\n\tx := 1\n\t// Some Comment\n\treturn x\n}\n"
+		mod := "func F() {\n\tx := 1\n\t// some Comment\n\treturn x\n}\n"
+		res := sideBySideDiff(orig, mod, 60)
+		assert.Equal(t, "    // Return The Result"+strings.Repeat(" ", 4)+" | \n"+
+			"    // Some Comment"+strings.Repeat(" ", 9)+" |     // some Comment\n", res)
+	})
+
+	t.Run("diff mode falls back without color", func(t *testing.T) {
+		tempDir := t.TempDir()
+		file := filepath.Join(tempDir, "test.go")
+		require.NoError(t, os.WriteFile(file, []byte(original), 0o600))
+
+		var stdoutBuf, stderrBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "diff", TitleCase: true, SideBySide: true}
+		processFile(file, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Contains(t, stdoutBuf.String(), "- \t// Original Comment\n+ \t// original Comment\n")
+
+		color.NoColor = false
+		defer func() { color.NoColor = true }()
+		t.Setenv("COLUMNS", "80")
+		stdoutBuf.Reset()
+		processFile(file, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Contains(t, stdoutBuf.String(), "    // Original Comment")
+		assert.Contains(t, stdoutBuf.String(), " | ")
+		assert.Contains(t, stdoutBuf.String(), "    // original Comment")
+		assert.NotContains(t, stdoutBuf.String(), "- \t// Original Comment")
+	})
+}
+
+// TestDiffTrailingWhitespace tests that trailing whitespace stripped by the printer doesn't show up in diffs
+func TestDiffTrailingWhitespace(t *testing.T) {
+	originalNoColor := color.NoColor
+	defer func() { color.NoColor = originalNoColor }()
+	color.NoColor = true
+
+	src := "package test\n\nfunc Example() {\n\t// already lowercase   \n\t// Some Comment  \n\tx := 1\n\t_ = x\n}\n"
+	file := filepath.Join(t.TempDir(), "test.go")
+	require.NoError(t, os.WriteFile(file, []byte(src), 0o600))
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	changes := processFile(file, &ProcessRequest{OutputMode: "diff", TitleCase: true},
+		OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+	assert.Equal(t, 1, changes)
+
+	lines := strings.Split(strings.TrimSuffix(stdoutBuf.String(), "\n"), "\n")
+	require.Len(t, lines, 4, "headers and the changed comment only, got:\n%s", stdoutBuf.String())
+	assert.Equal(t, "- \t// Some Comment  ", lines[2])
+	assert.Equal(t, "+ \t// some Comment", lines[3])
+}
@@ -0,0 +1,130 @@
+package processor
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/tools/imports"
+)
+
+// printerConfig returns the configuration of the printer for the modified sources,
+// the same as the default one of printer.Fprint unless tab width or spaces are set
+func (r *ProcessRequest) printerConfig() *printer.Config {
+	cfg := &printer.Config{Tabwidth: 8}
+	if r.TabWidth > 0 {
+		cfg.Tabwidth = r.TabWidth
+	}
+	if r.UseSpaces {
+		cfg.Mode |= printer.UseSpaces
+	}
+	return cfg
+}
+
+// formatWithGofmt formats the given content in-process like gofmt does, or with "gofmt -s" of the given binary if set.
+// returns the original content if formatting fails
+func formatWithGofmt(content, gofmtBin string, writers OutputWriters) string {
+	if gofmtBin == "" {
+		formattedBytes, err := format.Source([]byte(content))
+		if err != nil {
+			writers.errorf("Error formatting: %v\n", err)
+			return content // return original content on error
+		}
+		return string(formattedBytes)
+	}
+
+	cmd := exec.Command(gofmtBin, "-s") //nolint:gosec // the binary is set by the user explicitly
+	cmd.Stdin = strings.NewReader(content)
+
+	// capture the stdout output
+	formattedBytes, err := cmd.Output()
+	if err != nil {
+		writers.errorf("Error formatting with gofmt: %v\n", err)
+		return content // return original content on error
+	}
+
+	return string(formattedBytes)
+}
+
+// tidyImports formats the content of the file like goimports, which also adds missing and removes unused imports
+// and may reorder them. packages are resolved in the directory of the file, as if goimports processed the file
+// itself. indentation follows the printer config. returns the original content if imports can't be tidied
+func tidyImports(fileName, content string, cfg *printer.Config, writers OutputWriters) string {
+	opts := &imports.Options{Comments: true, TabIndent: cfg.Mode&printer.UseSpaces == 0, TabWidth: cfg.Tabwidth}
+	formattedBytes, err := imports.Process(fileName, []byte(content), opts)
+	if err != nil {
+		writers.errorf("Error tidying imports: %v\n", err)
+		return content
+	}
+	if cfg.Mode&printer.UseSpaces == 0 {
+		return string(formattedBytes)
+	}
+
+	// imports formats the result like gofmt does at the end, so indentation with spaces is printed again
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, fileName, formattedBytes, parser.ParseComments)
+	if err != nil {
+		writers.errorf("Error tidying imports: %v\n", err)
+		return content
+	}
+	res, err := getModifiedContent(fset, node, cfg)
+	if err != nil {
+		writers.errorf("Error tidying imports: %v\n", err)
+		return content
+	}
+	return res
+}
+
+// formatSource formats the modified content with gofmt and tidies its imports with goimports if requested,
+// goimports runs after gofmt
+func (r *ProcessRequest) formatSource(fileName, content string, writers OutputWriters) string {
+	if r.Format {
+		content = formatWithGofmt(content, r.GofmtBin, writers)
+	}
+	if r.Imports {
+		content = tidyImports(fileName, content, r.printerConfig(), writers)
+	}
+	return content
+}
+
+// getModifiedContent generates the modified content as a string
+func getModifiedContent(fset *token.FileSet, node *ast.File, cfg *printer.Config) (string, error) {
+	var modifiedBuf strings.Builder
+	if err := cfg.Fprint(&modifiedBuf, fset, node); err != nil {
+		return "", fmt.Errorf("save modified buffer: %w", err)
+	}
+	return modifiedBuf.String(), nil
+}
+
+// matchLineEndings makes line endings of the modified content, printed with "\n" only, match the original content:
+// the final newline is kept or left absent, and lines end with "\r\n" if set or detected as dominant in the original
+func (r *ProcessRequest) matchLineEndings(original, modified string) string {
+	modified = strings.ReplaceAll(matchFinalNewline(original, modified), "\r\n", "\n")
+	if r.LineEndings == "crlf" || (r.LineEndings != "lf" && dominantCRLF(original)) {
+		return strings.ReplaceAll(modified, "\n", "\r\n")
+	}
+	return modified
+}
+
+// dominantCRLF checks if most lines of the content end with "\r\n"
+func dominantCRLF(content string) bool {
+	crlf := strings.Count(content, "\r\n")
+	return crlf > strings.Count(content, "\n")-crlf
+}
+
+// matchFinalNewline makes the modified content end with a newline only if the original content does,
+// the printer and gofmt always add it, so a file without the final newline would get a noisy change
+func matchFinalNewline(original, modified string) string {
+	if strings.HasSuffix(original, "\n") {
+		if !strings.HasSuffix(modified, "\n") {
+			return modified + "\n"
+		}
+		return modified
+	}
+	return strings.TrimRight(modified, "\n")
+}
@@ -0,0 +1,476 @@
+package processor
+
+import (
+	"bytes"
+	"go/printer"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatOption(t *testing.T) {
+	tempDir := t.TempDir()
+	content := `package testpkg
+
+func Example(  ) {
+    // THIS COMMENT should be modified
+    x:=1  // ANOTHER Comment Here
+}`
+
+	// write test file
+	testFile := filepath.Join(tempDir, "format_test.go")
+	err := os.WriteFile(testFile, []byte(content), 0o600)
+	require.NoError(t, err, "Failed to write test file")
+
+	t.Run("inplace mode with format", func(t *testing.T) {
+		// reset the file
+		err := os.WriteFile(testFile, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to reset test file")
+
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process the file with format option
+		processFile(testFile, &ProcessRequest{OutputMode: "inplace", Format: true}, writers)
+
+		// verify output
+		output := stdoutBuf.String()
+		assert.Contains(t, output, "Updated:", "Should show update message")
+
+		// read the file content
+		modifiedContent, err := os.ReadFile(testFile)
+		require.NoError(t, err, "Failed to read modified file")
+		modifiedStr := string(modifiedContent)
+
+		// check that formatting was applied
+		assert.Contains(t, modifiedStr, "// this comment", "Should convert comments to lowercase")
+		assert.Contains(t, modifiedStr, "// another comment", "Should convert all comments to lowercase")
+
+		// specific formatting checks can be flaky due to differences in gofmt behavior
+		// between environments, so we'll focus on the comment changes
+	})
+
+	t.Run("inplace mode without format", func(t *testing.T) {
+		// reset the file
+		err := os.WriteFile(testFile, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to reset test file")
+
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process without format option
+		processFile(testFile, &ProcessRequest{OutputMode: "inplace"}, writers)
+
+		// read the file content
+		modifiedContent, err := os.ReadFile(testFile)
+		require.NoError(t, err, "Failed to read modified file")
+		modifiedStr := string(modifiedContent)
+
+		// verify comments are changed
+		assert.Contains(t, modifiedStr, "// this comment", "Should convert comments to lowercase")
+		assert.Contains(t, modifiedStr, "// another comment", "Should convert all comments to lowercase")
+
+		// skip spacing checks since printer may normalize some aspects
+	})
+
+	t.Run("print mode with format", func(t *testing.T) {
+		// reset the file
+		err := os.WriteFile(testFile, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to reset test file")
+
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process the file with format in print mode
+		processFile(testFile, &ProcessRequest{OutputMode: "print", Format: true}, writers)
+
+		// verify output
+		output := stdoutBuf.String()
+		assert.Contains(t, output, "// this comment", "Should convert comments to lowercase")
+		assert.Contains(t, output, "// another comment", "Should convert all comments to lowercase")
+
+		// file should remain unchanged
+		origContent, err := os.ReadFile(testFile)
+		require.NoError(t, err, "Failed to read original file")
+		assert.Equal(t, content, string(origContent), "Original file should not be modified")
+	})
+
+	t.Run("diff mode with format", func(t *testing.T) {
+		// reset the file
+		err := os.WriteFile(testFile, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to reset test file")
+
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process with format in diff mode
+		processFile(testFile, &ProcessRequest{OutputMode: "diff", Format: true}, writers)
+
+		// verify output
+		output := stdoutBuf.String()
+		// in diff mode, only changed lines appear in the diff
+		assert.Contains(t, output, "// this comment", "Should show lowercase comments")
+		assert.Contains(t, output, "// another comment", "Should show all lowercase comments")
+
+		// file should remain unchanged
+		origContent, err := os.ReadFile(testFile)
+		require.NoError(t, err, "Failed to read original file")
+		assert.Equal(t, content, string(origContent), "Original file should not be modified")
+	})
+}
+
+// TestProcessPatternWithFormat tests format option with pattern matching
+func TestProcessPatternWithFormat(t *testing.T) {
+	// create a temporary directory for test files
+	tempDir := t.TempDir()
+
+	// create a subdirectory
+	subDir := filepath.Join(tempDir, "subdir")
+	err := os.Mkdir(subDir, 0o750)
+	require.NoError(t, err, "Failed to create subdirectory")
+
+	// test content with uppercase comments and poor formatting
+	content := `package testpkg
+
+func Test(  ) {
+    // UPPERCASE COMMENT
+    x:=1
+}`
+
+	// write to multiple files
+	files := []string{
+		filepath.Join(tempDir, "file1.go"),
+		filepath.Join(subDir, "file2.go"),
+	}
+
+	for _, file := range files {
+		err = os.WriteFile(file, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to write test file")
+	}
+
+	// change to temp dir
+	t.Chdir(tempDir)
+
+	// process the files with format option
+	t.Run("recursive pattern with format", func(t *testing.T) {
+		// reset files
+		for _, file := range files {
+			err = os.WriteFile(file, []byte(content), 0o600)
+			require.NoError(t, err)
+		}
+
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process recursively with format
+		req := ProcessRequest{OutputMode: "inplace", TitleCase: false, Format: true, SkipPatterns: []string{}}
+		processPattern("./...", &req, writers)
+
+		// verify output
+		output := stdoutBuf.String()
+		assert.Contains(t, output, "Updated:", "Should show update message")
+
+		// check that both files were formatted
+		for _, file := range files {
+			relFile, err := filepath.Rel(tempDir, file)
+			require.NoError(t, err)
+
+			formatted, err := os.ReadFile(relFile)
+			require.NoError(t, err)
+			formattedStr := string(formatted)
+
+			// check for comment changes
+			assert.Contains(t, formattedStr, "// uppercase", "Should convert comments to lowercase")
+		}
+	})
+}
+
+// TestFormatErrorHandling tests error handling in the format feature
+func TestFormatErrorHandling(t *testing.T) {
+	// create a temporary directory
+	tempDir := t.TempDir()
+
+	// test content with poor formatting
+	content := `package testpkg
+
+func Example(  ) {
+    // THIS COMMENT
+    x:=1
+}`
+
+	// write test file
+	testFile := filepath.Join(tempDir, "format_error_test.go")
+	err := os.WriteFile(testFile, []byte(content), 0o600)
+	require.NoError(t, err, "Failed to write test file")
+
+	t.Run("error handling for gofmt", func(t *testing.T) {
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// try to run with format
+		processFile(testFile, &ProcessRequest{OutputMode: "inplace", Format: true}, writers)
+
+		// despite potential gofmt errors, the file should still be processed for comments
+		fileContent, err := os.ReadFile(testFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(fileContent), "// this comment", "Should still convert comments")
+	})
+
+	t.Run("missing gofmt binary", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(testFile, []byte(content), 0o600))
+		var stdoutBuf, stderrBuf bytes.Buffer
+		processFile(testFile, &ProcessRequest{OutputMode: "inplace", Format: true, GofmtBin: filepath.Join(tempDir, "no-gofmt")},
+			OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Contains(t, stderrBuf.String(), "Error formatting with gofmt")
+
+		fileContent, err := os.ReadFile(testFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(fileContent), "// this comment")
+	})
+}
+
+// TestImports tests tidying imports, without a goimports binary
+func TestImports(t *testing.T) {
+	src := "package test\n\nimport \"os\"\n\nfunc A() {\n\t// Some Comment\n}\n"
+	tempDir := t.TempDir()
+	file := filepath.Join(tempDir, "a.go")
+
+	t.Run("inplace", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte(src), 0o600))
+		var stdoutBuf, stderrBuf bytes.Buffer
+		processFile(file, &ProcessRequest{OutputMode: "inplace", TitleCase: true, Format: true, Imports: true},
+			OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Empty(t, stderrBuf.String())
+		res, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, "package test\n\nfunc A() {\n\t// some Comment\n}\n", string(res))
+	})
+
+	t.Run("print", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte(src), 0o600))
+		var stdoutBuf, stderrBuf bytes.Buffer
+		processFile(file, &ProcessRequest{OutputMode: "print", TitleCase: true, Imports: true},
+			OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.NotContains(t, stdoutBuf.String(), `import "os"`)
+		assert.Contains(t, stdoutBuf.String(), "// some Comment")
+	})
+
+	t.Run("diff shows import changes", func(t *testing.T) {
+		var stdoutBuf, stderrBuf bytes.Buffer
+		processFile(file, &ProcessRequest{OutputMode: "diff", TitleCase: true, Imports: true, DiffFormat: "unified", DiffContext: 3},
+			OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Contains(t, stdoutBuf.String(), "-import \"os\"\n")
+		assert.Contains(t, stdoutBuf.String(), "+\t// some Comment\n")
+	})
+
+	t.Run("missing import added", func(t *testing.T) {
+		missing := "package test\n\nfunc A() string {\n\treturn strings.ToLower(\"A\")\n}\n"
+		res := tidyImports(file, missing, &printer.Config{Tabwidth: 8}, OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		assert.Equal(t, "package test\n\nimport \"strings\"\n\nfunc A() string {\n\treturn strings.ToLower(\"A\")\n}\n", res)
+	})
+
+	t.Run("indentation of the request", func(t *testing.T) {
+		missing := "package test\n\nfunc A() string {\n\treturn strings.ToLower(\"A\")\n}\n"
+		req := &ProcessRequest{TabWidth: 4, UseSpaces: true}
+		res := tidyImports(file, missing, req.printerConfig(), OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		assert.Equal(t, "package test\n\nimport \"strings\"\n\nfunc A() string {\n    return strings.ToLower(\"A\")\n}\n", res)
+	})
+
+	t.Run("invalid source", func(t *testing.T) {
+		var stderrBuf bytes.Buffer
+		res := tidyImports(file, "package", &printer.Config{Tabwidth: 8}, OutputWriters{Stdout: io.Discard, Stderr: &stderrBuf})
+		assert.Equal(t, "package", res)
+		assert.Contains(t, stderrBuf.String(), "Error tidying imports")
+	})
+
+	t.Run("no goimports binary needed", func(t *testing.T) {
+		t.Setenv("PATH", "")
+		_, err := newProcessRequest(Options{Imports: true, BackupExt: defaultBackupExt}, "inplace")
+		require.NoError(t, err)
+	})
+}
+
+// TestFormatWithGofmt tests formatting with the built-in formatter and an external gofmt binary
+func TestFormatWithGofmt(t *testing.T) {
+	unformatted := "package test\n\nfunc A(  ) {\n    x:=[]int{1}\n    for i, _ := range x {\n        _ = i\n    }\n}\n"
+	var stdoutBuf, stderrBuf bytes.Buffer
+	writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+
+	t.Run("built-in", func(t *testing.T) {
+		t.Setenv("PATH", "") // no gofmt binary needed
+		res := formatWithGofmt(unformatted, "", writers)
+		assert.Equal(t, "package test\n\nfunc A() {\n\tx := []int{1}\n\tfor i, _ := range x {\n\t\t_ = i\n\t}\n}\n", res)
+		assert.Empty(t, stderrBuf.String())
+	})
+
+	t.Run("invalid source", func(t *testing.T) {
+		stderrBuf.Reset()
+		assert.Equal(t, "package test\nfunc {", formatWithGofmt("package test\nfunc {", "", writers))
+		assert.Contains(t, stderrBuf.String(), "Error formatting:")
+	})
+
+	t.Run("external binary", func(t *testing.T) {
+		gofmtBin, err := exec.LookPath("gofmt")
+		if err != nil {
+			t.Skip("gofmt not available for testing")
+		}
+		stderrBuf.Reset()
+		res := formatWithGofmt(unformatted, gofmtBin, writers)
+		assert.Equal(t, "package test\n\nfunc A() {\n\tx := []int{1}\n\tfor i := range x {\n\t\t_ = i\n\t}\n}\n", res,
+			"gofmt -s simplifies the range")
+		assert.Empty(t, stderrBuf.String())
+	})
+}
+
+// TestFinalNewline tests that the final newline of the original source is kept or left absent
+func TestFinalNewline(t *testing.T) {
+	t.Run("match", func(t *testing.T) {
+		assert.Equal(t, "a\n", matchFinalNewline("x\n", "a\n"))
+		assert.Equal(t, "a\n", matchFinalNewline("x\n", "a"))
+		assert.Equal(t, "a", matchFinalNewline("x", "a\n"))
+		assert.Equal(t, "a", matchFinalNewline("x", "a"))
+	})
+
+	src := "package test\n\nfunc Example() {\n\t// Some Comment\n}"
+	diff := "--- test.go\n+++ test.go\n@@ -1,5 +1,5 @@\n package test\n \n func Example() {\n-\t// Some Comment\n+\t// some Comment\n }\n"
+	for _, tc := range []struct {
+		name, src, expected, expectedDiff string
+	}{
+		{name: "without final newline", src: src, expected: "package test\n\nfunc Example() {\n\t// some Comment\n}",
+			expectedDiff: diff + "\\ No newline at end of file\n"},
+		{name: "with final newline", src: src + "\n", expected: "package test\n\nfunc Example() {\n\t// some Comment\n}\n",
+			expectedDiff: diff},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			require.NoError(t, os.WriteFile("test.go", []byte(tc.src), 0o600))
+
+			var stdoutBuf bytes.Buffer
+			req := &ProcessRequest{OutputMode: "diff", TitleCase: true, DiffFormat: "unified", DiffContext: 3}
+			processFile("test.go", req, OutputWriters{Stdout: &stdoutBuf, Stderr: io.Discard})
+			assert.Equal(t, tc.expectedDiff, stdoutBuf.String(), "last line is not changed")
+
+			req = &ProcessRequest{OutputMode: "inplace", TitleCase: true}
+			processFile("test.go", req, OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+			res, err := os.ReadFile("test.go")
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(res))
+
+			out, _, err := processSource("test.go", []byte(tc.src), &ProcessRequest{TitleCase: true})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+// TestLineEndings tests that CRLF line endings are detected and kept, or forced with the option
+func TestLineEndings(t *testing.T) {
+	t.Run("dominant", func(t *testing.T) {
+		assert.True(t, dominantCRLF("a\r\nb\r\nc\n"))
+		assert.False(t, dominantCRLF("a\r\nb\nc\n"))
+		assert.False(t, dominantCRLF("a\nb\n"))
+		assert.False(t, dominantCRLF("a"))
+	})
+
+	lf := "package test\n\nfunc Example() {\n\t// Some Comment\n\tx := 1\n\t_ = x\n}\n"
+	crlf := strings.ReplaceAll(lf, "\n", "\r\n")
+	expected := strings.Replace(lf, "// Some Comment", "// some Comment", 1)
+
+	tbl := []struct {
+		name, src, lineEndings, expected string
+	}{
+		{name: "crlf kept", src: crlf, expected: strings.ReplaceAll(expected, "\n", "\r\n")},
+		{name: "lf kept", src: lf, expected: expected},
+		{name: "lf forced", src: crlf, lineEndings: "lf", expected: expected},
+		{name: "crlf forced", src: lf, lineEndings: "crlf", expected: strings.ReplaceAll(expected, "\n", "\r\n")},
+		{name: "crlf without final newline", src: strings.TrimSuffix(crlf, "\r\n"),
+			expected: strings.TrimSuffix(strings.ReplaceAll(expected, "\n", "\r\n"), "\r\n")},
+	}
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			require.NoError(t, os.WriteFile("test.go", []byte(tt.src), 0o600))
+			req := &ProcessRequest{OutputMode: "inplace", TitleCase: true, LineEndings: tt.lineEndings}
+			assert.Equal(t, 1, processFile("test.go", req, OutputWriters{Stdout: io.Discard, Stderr: io.Discard}))
+			res, err := os.ReadFile("test.go")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(res))
+		})
+	}
+
+	t.Run("diff of crlf file", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		require.NoError(t, os.WriteFile("test.go", []byte(crlf), 0o600))
+		var stdoutBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "diff", TitleCase: true, DiffFormat: "unified", DiffContext: 3}
+		processFile("test.go", req, OutputWriters{Stdout: &stdoutBuf, Stderr: io.Discard})
+		assert.Equal(t, 1, strings.Count(stdoutBuf.String(), "\n-"), "only the comment line is changed: %q", stdoutBuf.String())
+		assert.Contains(t, stdoutBuf.String(), "\n+\t// some Comment\r\n")
+	})
+}
+
+// TestPrinterConfig tests that the modified sources are printed with the configured tab width and spaces
+func TestPrinterConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test.go")
+	src := "package test\n\ntype T struct {\n\tA    int\n\tLong int\n}\n\nfunc Example() {\n\tif true {\n\t\t// Some Comment\n\t}\n}\n"
+	require.NoError(t, os.WriteFile(file, []byte(src), 0o600))
+
+	tbl := []struct {
+		name     string
+		req      ProcessRequest
+		expected string
+	}{
+		{name: "default", req: ProcessRequest{},
+			expected: "package test\n\ntype T struct {\n\tA\tint\n\tLong\tint\n}\n\nfunc Example() {\n\tif true {\n\t\t// some Comment\n\t}\n}\n"},
+		{name: "spaces with tab width 2", req: ProcessRequest{TabWidth: 2, UseSpaces: true},
+			expected: "package test\n\ntype T struct {\n  A    int\n  Long int\n}\n\nfunc Example() {\n  if true {\n    // some Comment\n  }\n}\n"},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdoutBuf bytes.Buffer
+			req := tt.req
+			req.OutputMode, req.TitleCase = "print", true
+			processFile(file, &req, OutputWriters{Stdout: &stdoutBuf, Stderr: io.Discard})
+			assert.Equal(t, tt.expected, stdoutBuf.String())
+		})
+	}
+
+	t.Run("inplace", func(t *testing.T) {
+		req := ProcessRequest{OutputMode: "inplace", TitleCase: true, TabWidth: 4, UseSpaces: true}
+		processFile(file, &req, OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		res, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Contains(t, string(res), "\n    if true {\n        // some Comment\n")
+	})
+}
@@ -0,0 +1,119 @@
+package processor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// gitAdded matches the "XY" status of files added to the index or untracked, X is the index status
+func gitAdded(status string) bool {
+	return status[0] == 'A' || status == "??"
+}
+
+// gitModified matches the "XY" status of files modified in the working tree or untracked, Y is the work tree status
+func gitModified(status string) bool {
+	return status[1] == 'M' || status == "??"
+}
+
+// gitStatusFiles returns absolute paths of files in the git repository of dir with the status,
+// as reported by "git status --porcelain", matching any of the given matchers. renamed and copied files
+// are reported by their new paths
+func gitStatusFiles(dir string, matchers ...func(status string) bool) (map[string]bool, error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil, fmt.Errorf("find git repository root in %s: %w", dir, err)
+	}
+	root := strings.TrimSpace(string(out))
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved // the same as paths of files looked up in the result
+	}
+
+	out, err = exec.Command("git", "-C", dir, "status", "--porcelain", "-z", "--untracked-files=all").Output()
+	if err != nil {
+		return nil, fmt.Errorf("get git status in %s: %w", dir, err)
+	}
+
+	res := map[string]bool{}
+	entries := strings.Split(string(out), "\x00")
+	for i := 0; i < len(entries); i++ {
+		// each entry is "XY path" with the path unquoted, XY is "??" for untracked files.
+		// renamed and copied files are followed by an entry with the original path
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++ // skip the original path
+		}
+		if !slices.ContainsFunc(matchers, func(match func(string) bool) bool { return match(entry[:2]) }) {
+			continue
+		}
+		res[filepath.Join(root, filepath.FromSlash(entry[3:]))] = true
+	}
+	return res, nil
+}
+
+// gitStagedFiles returns Go files added, copied, modified or renamed in the git index of the repository of dir,
+// as reported by "git diff --cached", with paths relative to dir. deleted files are not returned
+func gitStagedFiles(dir string) ([]string, error) {
+	return gitDiffFiles(dir, "staged files", "--cached")
+}
+
+// gitChangedFiles returns Go files added, copied, modified or renamed by commits since the revision,
+// as reported by "git diff rev...HEAD", with paths relative to dir. uncommitted changes are not included.
+// the revision can't be taken for an option of git, like "--output=file"
+func gitChangedFiles(dir, rev string) ([]string, error) {
+	return gitDiffFiles(dir, "files changed since "+rev, "--end-of-options", rev+"...HEAD")
+}
+
+// gitDiffFiles returns Go files added, copied, modified or renamed in the diff made with the arguments,
+// with paths relative to dir. what describes the files for errors
+func gitDiffFiles(dir, what string, diffArgs ...string) ([]string, error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil, fmt.Errorf("find git repository root in %s, %s need a git repository: %w", dir, what, gitError(err))
+	}
+	root := strings.TrimSpace(string(out))
+
+	args := slices.Concat([]string{"-C", dir, "diff", "--name-only", "--diff-filter=ACMR", "-z"}, diffArgs, []string{"--"})
+	out, err = exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("get %s in %s: %w", what, dir, gitError(err))
+	}
+
+	// the root is reported with symlinks resolved, so is the directory to make paths relative to
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("get absolute path of %s: %w", dir, err)
+	}
+	if resolved, err := filepath.EvalSymlinks(absDir); err == nil {
+		absDir = resolved
+	}
+
+	var res []string
+	for _, path := range strings.Split(string(out), "\x00") {
+		if !strings.HasSuffix(path, ".go") {
+			continue
+		}
+		path = filepath.Join(root, filepath.FromSlash(path))
+		if rel, err := filepath.Rel(absDir, path); err == nil {
+			path = rel
+		}
+		res = append(res, path)
+	}
+	return res, nil
+}
+
+// gitError adds the message git wrote to stderr, like "fatal: bad revision", to the error of the command
+func gitError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) > 0 {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(exitErr.Stderr))
+	}
+	return err
+}
@@ -0,0 +1,320 @@
+package processor
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewFilesOnly tests limiting processing to files added or untracked in git
+func TestNewFilesOnly(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found, skipping test")
+	}
+
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	content := "package test\n\nfunc Example() {\n\t// Some Comment\n}\n"
+	writeFile := func(path string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	git("init", "-q")
+	writeFile("tracked.go")
+	writeFile("modified.go")
+	git("add", "tracked.go", "modified.go")
+	git("commit", "-q", "-m", "initial")
+
+	require.NoError(t, os.WriteFile("modified.go", []byte(content+"\n// trailing\n"), 0o600))
+	writeFile("added.go")
+	git("add", "added.go")
+	writeFile(filepath.Join("pkg", "untracked.go"))
+
+	newFiles, err := gitStatusFiles(".", gitAdded)
+	require.NoError(t, err)
+	assert.Len(t, newFiles, 2)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	req := &ProcessRequest{OutputMode: "inplace", TitleCase: true, NewFiles: newFiles}
+	processPatterns([]string{"./..."}, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+	assert.Equal(t, 2, req.FilesAnalyzed)
+	assert.Equal(t, 2, req.FilesUpdated)
+
+	for file, expected := range map[string]string{
+		"tracked.go":                         "// Some Comment",
+		"modified.go":                        "// Some Comment",
+		"added.go":                           "// some Comment",
+		filepath.Join("pkg", "untracked.go"): "// some Comment",
+	} {
+		res, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Contains(t, string(res), expected, file)
+	}
+
+	t.Run("through a symlink", func(t *testing.T) {
+		link := filepath.Join(t.TempDir(), "link")
+		require.NoError(t, os.Symlink(tempDir, link))
+		newFiles, err := gitStatusFiles(link, gitAdded)
+		require.NoError(t, err)
+		req := &ProcessRequest{NewFiles: newFiles}
+		assert.True(t, req.fileSelected(filepath.Join(link, "added.go")))
+		assert.True(t, req.fileSelected(filepath.Join(link, "pkg", "untracked.go")))
+		assert.False(t, req.fileSelected(filepath.Join(link, "tracked.go")))
+	})
+
+	t.Run("not a git repository", func(t *testing.T) {
+		_, err := gitStatusFiles(t.TempDir(), gitAdded)
+		require.Error(t, err)
+	})
+}
+
+// TestDirtyFiles tests limiting processing to files modified in the working tree or untracked in git
+func TestDirtyFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found, skipping test")
+	}
+
+	t.Chdir(t.TempDir())
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	content := "package test\n\nfunc Example() {\n\t// Some Comment\n}\n"
+	for _, name := range []string{"unmodified.go", "modified.go", "skipped.go", "gen.go"} {
+		require.NoError(t, os.WriteFile(name, []byte(content), 0o600))
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	require.NoError(t, os.WriteFile("modified.go", []byte(content+"\n// trailing\n"), 0o600))
+	require.NoError(t, os.WriteFile("skipped.go", []byte(content+"\n// trailing\n"), 0o600))
+	require.NoError(t, os.WriteFile("gen.go", []byte("// Code generated by test. DO NOT EDIT.\n"+content), 0o600))
+	require.NoError(t, os.WriteFile("staged.go", []byte(content), 0o600))
+	git("add", "staged.go")
+
+	dirty, err := gitStatusFiles(".", gitModified)
+	require.NoError(t, err)
+	assert.Len(t, dirty, 3, "staged new file is not modified in the working tree")
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	req := &ProcessRequest{OutputMode: "inplace", TitleCase: true, NewFiles: dirty, SkipPatterns: []string{"skipped.go"}}
+	processPatterns([]string{"."}, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+	assert.Equal(t, 2, req.FilesAnalyzed, "modified and generated files are analyzed")
+	assert.Equal(t, 1, req.FilesUpdated)
+	assert.True(t, strings.HasPrefix(stdoutBuf.String(), "Updated: modified.go\n\nSummary:"), stdoutBuf.String())
+
+	res, err := os.ReadFile("unmodified.go")
+	require.NoError(t, err)
+	assert.Equal(t, content, string(res), "unmodified file is not processed")
+
+	t.Run("combined with new files", func(t *testing.T) {
+		files, err := gitStatusFiles(".", gitAdded, gitModified)
+		require.NoError(t, err)
+		assert.Len(t, files, 4)
+	})
+
+	t.Run("renamed and modified", func(t *testing.T) {
+		git("mv", "unmodified.go", "renamed file.go")
+		require.NoError(t, os.WriteFile("renamed file.go", []byte(content+"\n// trailing\n"), 0o600))
+		files, err := gitStatusFiles(".", gitModified)
+		require.NoError(t, err)
+		wd, err := os.Getwd()
+		require.NoError(t, err)
+		wd, err = filepath.EvalSymlinks(wd)
+		require.NoError(t, err)
+		assert.True(t, files[filepath.Join(wd, "renamed file.go")], "new path of the renamed file, %v", files)
+		assert.False(t, files[filepath.Join(wd, "unmodified.go")], "not the original path")
+
+		req := &ProcessRequest{OutputMode: "diff", TitleCase: true, NewFiles: files}
+		processPatterns([]string{"renamed file.go"}, req, OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		assert.Equal(t, 1, req.FilesAnalyzed)
+	})
+}
+
+// TestStagedFiles tests that staged command finds go files added, copied or modified in the git index
+func TestStagedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found, skipping test")
+	}
+
+	t.Run("not a git repository", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		_, err := gitStagedFiles(".")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "staged files need a git repository")
+	})
+
+	t.Chdir(t.TempDir())
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	content := "package test\n\nfunc Example() {\n\t// Some Comment\n}\n"
+	require.NoError(t, os.MkdirAll("pkg", 0o750))
+	for _, name := range []string{"committed.go", "modified.go", "deleted.go", "renamed.go", filepath.Join("pkg", "skipped.go")} {
+		require.NoError(t, os.WriteFile(name, []byte(content+"\n// "+name+"\n"), 0o600))
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	require.NoError(t, os.WriteFile("modified.go", []byte(content+"\n// trailing\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join("pkg", "skipped.go"), []byte(content+"\n// trailing\n"), 0o600))
+	require.NoError(t, os.WriteFile("added.go", []byte(content), 0o600))
+	require.NoError(t, os.WriteFile("notes.txt", []byte("notes"), 0o600))
+	require.NoError(t, os.WriteFile("unstaged.go", []byte(content), 0o600))
+	git("add", "modified.go", "added.go", "notes.txt", filepath.Join("pkg", "skipped.go"))
+	git("rm", "-q", "deleted.go")
+	git("mv", "renamed.go", "moved.go")
+
+	files, err := gitStagedFiles(".")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"added.go", "modified.go", "moved.go", filepath.Join("pkg", "skipped.go")}, files)
+
+	t.Run("relative to subdirectory", func(t *testing.T) {
+		files, err := gitStagedFiles("pkg")
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join("..", "added.go"), filepath.Join("..", "modified.go"),
+			filepath.Join("..", "moved.go"), "skipped.go"}, files)
+	})
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	req := &ProcessRequest{OutputMode: "inplace", TitleCase: true, SkipPatterns: []string{"pkg"}}
+	processPatterns(files, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+	assert.Empty(t, stderrBuf.String())
+	assert.Equal(t, 3, req.FilesAnalyzed)
+	assert.Equal(t, 3, req.FilesUpdated)
+	assert.Contains(t, stdoutBuf.String(), "Summary: 3 files analyzed, 3 files updated, 3 total changes")
+
+	res, err := os.ReadFile("unstaged.go")
+	require.NoError(t, err)
+	assert.Equal(t, content, string(res), "unstaged file is not processed")
+
+	t.Run("processing mode", func(t *testing.T) {
+		opts := Options{}
+		p := flags.NewParser(&opts, flags.Default)
+		p.Active = p.Find("staged")
+		require.NotNil(t, p.Active)
+		assert.Equal(t, ProcessingResult{Mode: "inplace", Staged: true}, determineProcessingMode(opts, p))
+		opts.DryRun = true
+		assert.Equal(t, ProcessingResult{Mode: "diff", Staged: true}, determineProcessingMode(opts, p))
+	})
+}
+
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found, skipping test")
+	}
+
+	t.Run("not a git repository", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		_, err := gitChangedFiles(".", "main")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "files changed since main need a git repository")
+	})
+
+	t.Chdir(t.TempDir())
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	content := "package test\n\nfunc Example() {\n\t// Some Comment\n}\n"
+	require.NoError(t, os.MkdirAll("pkg", 0o750))
+	for _, name := range []string{"old.go", "modified.go", "deleted.go", filepath.Join("pkg", "skipped.go")} {
+		require.NoError(t, os.WriteFile(name, []byte(content+"\n// "+name+"\n"), 0o600))
+	}
+	git("init", "-q", "-b", "main")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	git("checkout", "-q", "-b", "feature")
+	require.NoError(t, os.WriteFile("modified.go", []byte(content+"\n// trailing\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join("pkg", "skipped.go"), []byte(content+"\n// trailing\n"), 0o600))
+	require.NoError(t, os.WriteFile("added.go", []byte(content), 0o600))
+	require.NoError(t, os.WriteFile("notes.txt", []byte("notes"), 0o600))
+	git("add", ".")
+	git("rm", "-q", "deleted.go")
+	git("commit", "-q", "-m", "feature")
+	require.NoError(t, os.WriteFile("uncommitted.go", []byte(content), 0o600))
+
+	files, err := gitChangedFiles(".", "main")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"added.go", "modified.go", filepath.Join("pkg", "skipped.go")}, files)
+
+	t.Run("relative revision", func(t *testing.T) {
+		files, err := gitChangedFiles(".", "HEAD~1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"added.go", "modified.go", filepath.Join("pkg", "skipped.go")}, files)
+	})
+
+	t.Run("invalid revision", func(t *testing.T) {
+		_, err := gitChangedFiles(".", "no-such-branch")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "get files changed since no-such-branch")
+		assert.Contains(t, err.Error(), "no-such-branch...HEAD")
+	})
+
+	t.Run("option-like revision", func(t *testing.T) {
+		_, err := gitChangedFiles(".", "--output=out.txt")
+		require.Error(t, err)
+		assert.NoFileExists(t, "out.txt...HEAD", "the revision is not passed as an option")
+	})
+
+	t.Run("with patterns", func(t *testing.T) {
+		tbl := []struct {
+			patterns []string
+			want     []string
+		}{
+			{patterns: nil, want: files},
+			{patterns: []string{"./..."}, want: files},
+			{patterns: []string{"."}, want: []string{"added.go", "modified.go"}},
+			{patterns: []string{"pkg/..."}, want: []string{filepath.Join("pkg", "skipped.go")}},
+			{patterns: []string{"pkg"}, want: []string{filepath.Join("pkg", "skipped.go")}},
+			{patterns: []string{"added.go", "old.go"}, want: []string{"added.go"}},
+			{patterns: []string{"*ed.go"}, want: []string{"added.go", "modified.go"}},
+			{patterns: []string{"uncommitted.go"}, want: nil},
+		}
+		for _, tt := range tbl {
+			res, err := filesToProcess(Options{Since: "main"}, ProcessingResult{Patterns: tt.patterns})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, res, "patterns %v", tt.patterns)
+		}
+		_, err := filesToProcess(Options{Since: "main"}, ProcessingResult{Patterns: []string{"file[.go"}})
+		require.EqualError(t, err, "glob pattern file[.go: syntax error in pattern")
+	})
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	req := &ProcessRequest{OutputMode: "inplace", TitleCase: true, SkipPatterns: []string{"pkg"}}
+	processPatterns(files, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+	assert.Empty(t, stderrBuf.String())
+	assert.Equal(t, 2, req.FilesAnalyzed)
+	assert.Equal(t, 2, req.FilesUpdated)
+	assert.Contains(t, stdoutBuf.String(), "Summary: 2 files analyzed, 2 files updated, 2 total changes")
+
+	for _, name := range []string{"old.go", "uncommitted.go", filepath.Join("pkg", "skipped.go")} {
+		res, err := os.ReadFile(name)
+		require.NoError(t, err)
+		assert.Contains(t, string(res), "// Some Comment", name)
+	}
+	res, err := os.ReadFile("added.go")
+	require.NoError(t, err)
+	assert.Contains(t, string(res), "// some Comment")
+}
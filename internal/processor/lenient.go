@@ -0,0 +1,128 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// processLenient converts comments of a file which can't be parsed with a line-based scan, see lenientComments.
+// the broken source can't be formatted, so formatting options are ignored
+func processLenient(fileName string, src []byte, parseErr error, req *ProcessRequest, writers OutputWriters) int {
+	writers.warnf("Can't parse %s, processing comments line by line: %v\n", fileName, parseErr)
+	if src == nil {
+		var err error
+		if src, err = os.ReadFile(fileName); err != nil { //nolint:gosec
+			writers.errorf("Error reading file %s: %v\n", fileName, err)
+			return 0
+		}
+	}
+
+	modified, changes := lenientComments(fileName, string(src), req)
+	if len(changes) == 0 {
+		return 0
+	}
+
+	switch req.OutputMode {
+	case "inplace":
+		if !checkWrite(fileName, string(src), modified, req, writers) {
+			return 0
+		}
+		if req.Backup {
+			writeBackup(fileName, src, req, writers)
+		}
+		if err := writeFileAtomic(fileName, []byte(modified), writers); err != nil {
+			writers.errorf("Error writing to file %s: %v\n", fileName, err)
+			req.FilesFailed++
+			return 0
+		}
+		writers.infof("Updated: %s\n", fileName)
+		reportUpdate(fileName, changes, req, writers)
+	case "print":
+		if req.OnlyChanged {
+			printChangedComments(changes, writers)
+			break
+		}
+		fmt.Fprint(writers.Stdout, modified)
+	case "diff":
+		if req.PreviewLimit > 0 && req.FilesUpdated >= req.PreviewLimit {
+			req.FilesNotShown++
+			break
+		}
+		plain := *req
+		plain.Format, plain.Imports = false, false
+		printDiff(fileName, string(src), modified, &plain, writers)
+	default:
+		reportChanges(fileName, changes, req, writers)
+	}
+
+	for _, c := range changes {
+		req.CharsChanged += c.churn()
+	}
+	return len(changes)
+}
+
+// lenientComments converts standalone "//" comments inside "{ ... }" blocks of a source which can't be parsed.
+// blocks are found by counting braces outside of strings, runes and comments line by line. it is not as accurate
+// as the syntax tree, so trailing comments after code are not converted
+func lenientComments(fileName, content string, req *ProcessRequest) (string, []Change) {
+	lines := strings.SplitAfter(content, "\n")
+	var changes []Change
+	var depth int
+	var inRaw, inBlock bool // inside a multi-line raw string or block comment
+	for i, line := range lines {
+		body := strings.TrimRight(line, "\r\n")
+		trimmed := strings.TrimLeft(body, " \t")
+		if depth == 0 || inRaw || inBlock || !strings.HasPrefix(trimmed, "//") {
+			depth, inRaw, inBlock = scanBraces(body, depth, inRaw, inBlock)
+			continue
+		}
+		if skipCommentText(trimmed, req) {
+			continue
+		}
+		processed := convertComment(trimmed, req)
+		if processed == trimmed {
+			continue
+		}
+		indent := body[:len(body)-len(trimmed)]
+		lines[i] = indent + processed + line[len(body):]
+		changes = append(changes, Change{File: fileName, Line: i + 1, Column: len(indent) + 1, Before: trimmed, After: processed})
+	}
+	return strings.Join(lines, ""), changes
+}
+
+// scanBraces returns the brace depth after the line, and if a raw string or block comment continues after it.
+// braces in strings, runes and comments are not counted, the depth never goes below zero
+func scanBraces(line string, depth int, inRaw, inBlock bool) (int, bool, bool) {
+	for i := 0; i < len(line); i++ {
+		switch {
+		case inBlock:
+			if strings.HasPrefix(line[i:], "*/") {
+				inBlock = false
+				i++
+			}
+		case inRaw:
+			inRaw = line[i] != '`'
+		case line[i] == '`':
+			inRaw = true
+		case line[i] == '"' || line[i] == '\'':
+			// skip to the closing quote on the same line, escaped quotes are not closing
+			quote := line[i]
+			for i++; i < len(line) && line[i] != quote; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+		case strings.HasPrefix(line[i:], "//"):
+			return depth, false, false
+		case strings.HasPrefix(line[i:], "/*"):
+			inBlock = true
+			i++
+		case line[i] == '{':
+			depth++
+		case line[i] == '}':
+			depth = max(depth-1, 0)
+		}
+	}
+	return depth, inRaw, inBlock
+}
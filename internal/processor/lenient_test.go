@@ -0,0 +1,146 @@
+package processor
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLenient tests converting comments of files with syntax errors by the line-based scan
+func TestLenient(t *testing.T) {
+	src := "package broken\n\n// Package Level Stays\nfunc A() {\n\t// This Is Converted\n" +
+		"\ts := \"{ not a brace // Nor A Comment\"\n\tr := '{'\n\tx := `raw {\n\t// Inside Raw String\n\t}`\n" +
+		"\t/* block {\n\t// Inside Block\n\t*/\n\ty := 1 // Trailing Stays\n\tif x == {\n\t\t// Nested Converted\n\t}\n}\n\n" +
+		"// After Block Stays\nvar Z = 1\n"
+	expected := strings.Replace(strings.Replace(src, "// This Is", "// this Is", 1), "// Nested", "// nested", 1)
+	tempDir := t.TempDir()
+	file := filepath.Join(tempDir, "broken.go")
+
+	t.Run("print", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte(src), 0o600))
+		var stdoutBuf, stderrBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "print", TitleCase: true, Lenient: true}
+		assert.Equal(t, 2, processFile(file, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}))
+		assert.Equal(t, expected, stdoutBuf.String())
+		assert.Contains(t, stderrBuf.String(), "Can't parse "+file+", processing comments line by line")
+	})
+
+	t.Run("inplace with backup", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte(src), 0o600))
+		var stdoutBuf, stderrBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "inplace", TitleCase: true, Lenient: true, Backup: true, Format: true}
+		processFile(file, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Contains(t, stdoutBuf.String(), "Updated: "+file)
+		res, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(res))
+		backup, err := os.ReadFile(file + ".bak")
+		require.NoError(t, err)
+		assert.Equal(t, src, string(backup))
+	})
+
+	t.Run("inplace with asserted docs", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte(src), 0o600))
+		var stdoutBuf, stderrBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "inplace", TitleCase: true, Lenient: true, AssertDocsPreserved: true}
+		assert.Zero(t, processFile(file, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}))
+		assert.Contains(t, stderrBuf.String(), "Error: refusing to write "+file+": parse original source")
+		assert.Equal(t, 1, req.FilesFailed)
+		res, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, src, string(res), "docs can't be checked in a source which can't be parsed")
+	})
+
+	t.Run("inplace with changed directive", func(t *testing.T) {
+		var stderrBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "inplace"}
+		assert.False(t, checkWrite(file, "//go:generate x\nfunc A() {}\n", "func A() {}\n", req, OutputWriters{Stdout: io.Discard, Stderr: &stderrBuf}))
+		assert.Contains(t, stderrBuf.String(), `refusing to write `+file+`: directive "//go:generate x" removed`)
+		assert.Equal(t, 1, req.FilesFailed)
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte(src), 0o600))
+		req := &ProcessRequest{OutputMode: "json", TitleCase: true, Lenient: true}
+		processFile(file, req, OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		assert.Equal(t, []Change{
+			{File: file, Line: 5, Column: 2, Before: "// This Is Converted", After: "// this Is Converted"},
+			{File: file, Line: 16, Column: 3, Before: "// Nested Converted", After: "// nested Converted"},
+		}, req.Changes)
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte(src), 0o600))
+		var stdoutBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "diff", TitleCase: true, Lenient: true, DiffFormat: "unified"}
+		processFile(file, req, OutputWriters{Stdout: &stdoutBuf, Stderr: io.Discard})
+		assert.Contains(t, stdoutBuf.String(), "-\t// This Is Converted\n+\t// this Is Converted\n")
+	})
+
+	t.Run("skipped like parsed files", func(t *testing.T) {
+		src := "package broken\n\nfunc A() {\n\t// Short One\n\t// Four Words Long Here\n\t// Some LOUD Words\n\tif x == {\n}\n"
+		for _, tc := range []struct {
+			name     string
+			req      ProcessRequest
+			expected []string
+		}{
+			{name: "min words", req: ProcessRequest{MinWords: 3},
+				expected: []string{"// four Words Long Here", "// some LOUD Words"}},
+			{name: "min upper run", req: ProcessRequest{MinUpperRun: 3}, expected: []string{"// some LOUD Words"}},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				req := tc.req
+				req.TitleCase, req.Lenient = true, true
+				_, changes := lenientComments("broken.go", src, &req)
+				after := make([]string, 0, len(changes))
+				for _, c := range changes {
+					after = append(after, c.After)
+				}
+				assert.Equal(t, tc.expected, after)
+			})
+		}
+	})
+
+	t.Run("without the option", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte(src), 0o600))
+		var stdoutBuf, stderrBuf bytes.Buffer
+		assert.Zero(t, processFile(file, &ProcessRequest{OutputMode: "inplace", TitleCase: true}, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}))
+		assert.Contains(t, stderrBuf.String(), "Error parsing")
+		res, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, src, string(res))
+	})
+
+	t.Run("scan braces", func(t *testing.T) {
+		tbl := []struct {
+			line          string
+			depth         int
+			inRaw, inBlk  bool
+			expectedDepth int
+			raw, block    bool
+		}{
+			{line: "func A() {", expectedDepth: 1},
+			{line: "}", depth: 1, expectedDepth: 0},
+			{line: "}}", depth: 1, expectedDepth: 0},
+			{line: `s := "\"{"`, depth: 1, expectedDepth: 1},
+			{line: `r := '\''; m := map[string]int{}`, depth: 1, expectedDepth: 1},
+			{line: "x := `{", depth: 1, expectedDepth: 1, raw: true},
+			{line: "} ` + \"{\"", depth: 1, inRaw: true, expectedDepth: 1},
+			{line: "/* { */ {", expectedDepth: 1},
+			{line: "/* {", expectedDepth: 0, block: true},
+			{line: "{ // {", expectedDepth: 1},
+		}
+		for _, tt := range tbl {
+			depth, raw, block := scanBraces(tt.line, tt.depth, tt.inRaw, tt.inBlk)
+			assert.Equal(t, tt.expectedDepth, depth, tt.line)
+			assert.Equal(t, tt.raw, raw, tt.line)
+			assert.Equal(t, tt.block, block, tt.line)
+		}
+	})
+}
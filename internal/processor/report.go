@@ -0,0 +1,555 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// printSummary prints the final statistics of the run, count mode prints only the number of changes,
+// json mode prints the collected changes and the statistics as JSON
+func printSummary(req *ProcessRequest, writers OutputWriters) {
+	if req.OutputMode == "count" {
+		fmt.Fprintf(writers.Stdout, "%d\n", req.TotalChanges)
+		return
+	}
+	if req.OutputMode == "json" {
+		printJSON(req, writers)
+		return
+	}
+	if req.OutputMode == "compare" {
+		fmt.Fprintf(writers.Stdout, "\nSummary: %d files analyzed, %d files with differences, %d total differences\n",
+			req.FilesAnalyzed, req.FilesUpdated, req.TotalChanges)
+		return
+	}
+	if req.OutputMode == "verify" {
+		fmt.Fprintf(writers.Stdout, "\nSummary: %d files analyzed, %d files not idempotent, %d unstable comments\n",
+			req.FilesAnalyzed, req.FilesUpdated, req.TotalChanges)
+		return
+	}
+	if req.OutputMode == "density" {
+		fmt.Fprintf(writers.Stdout, "\nSummary: %d files analyzed, %d files with over-commented functions, %d functions\n",
+			req.FilesAnalyzed, req.FilesUpdated, req.TotalChanges)
+		return
+	}
+	if req.OutputMode == "style" {
+		fmt.Fprintf(writers.Stdout, "Comment styles: %s\n", styleHistogram(req.StyleCounts))
+		fmt.Fprintf(writers.Stdout, "\nSummary: %d files analyzed, %d in-function comments\n",
+			req.FilesAnalyzed, sumCounts(req.StyleCounts))
+		return
+	}
+	if req.OutputMode == "quarantine" {
+		fmt.Fprintf(writers.Stdout, "\nSummary: %d files analyzed, %d files quarantined, %d total changes\n",
+			req.FilesAnalyzed, req.FilesUpdated, req.TotalChanges)
+		return
+	}
+	if req.OutputMode == "export" {
+		fmt.Fprintf(writers.Stdout, "\nSummary: %d files analyzed, %d comments exported, %d to be converted\n",
+			req.FilesAnalyzed, len(req.ExportedComments), req.TotalChanges)
+		return
+	}
+	if req.FilesNotShown > 0 {
+		fmt.Fprintf(writers.Stdout, "... and %d more files changed\n", req.FilesNotShown)
+	}
+	churn := ""
+	if req.ShowChurn {
+		churn = fmt.Sprintf(", %d chars changed", req.CharsChanged)
+	}
+	fmt.Fprintf(writers.Stdout, "\nSummary: %d files analyzed, %d files updated, %d total changes%s\n",
+		req.FilesAnalyzed, req.FilesUpdated, req.TotalChanges, churn)
+}
+
+// reportUpdate prints locations of changes of the file updated in place and adds them to the rewrite log, if requested
+func reportUpdate(fileName string, changes []Change, req *ProcessRequest, writers OutputWriters) {
+	if req.ShowLocations {
+		printLocations(changes, writers)
+	}
+	if req.RewriteLog != nil {
+		if err := writeRewriteLog(req.RewriteLog, changes, time.Now()); err != nil {
+			writers.errorf("Error writing rewrite log for %s: %v\n", fileName, err)
+		}
+	}
+}
+
+// reportChanges handles output modes working with the list of changes only, not with the modified content
+func reportChanges(fileName string, changes []Change, req *ProcessRequest, writers OutputWriters) {
+	switch req.OutputMode {
+	case "quarantine":
+		target, err := quarantineFile(fileName, req.QuarantineDir)
+		if err != nil {
+			writers.errorf("Error quarantining %s: %v\n", fileName, err)
+			return
+		}
+		writers.infof("Quarantined: %s -> %s, %d comments\n", fileName, target, len(changes))
+	case "github":
+		printGithubAnnotations(changes, writers)
+	case "jsonl":
+		printJSONLines(changes, writers)
+	case "json":
+		req.Changes = append(req.Changes, changes...)
+	case "check":
+		fmt.Fprintln(writers.Stdout, fileName)
+	}
+}
+
+// verifyIdempotent processes the file in memory twice, the second pass over the result of the first one,
+// and reports comments changed by the second pass. Returns the number of such unstable comments.
+func verifyIdempotent(fileName string, req *ProcessRequest, writers OutputWriters) int {
+	src, err := os.ReadFile(fileName) //nolint:gosec // file name comes from the walk or command line
+	if err != nil {
+		writers.errorf("Error reading %s: %v\n", fileName, err)
+		return 0
+	}
+
+	firstPass, _, err := processSource(fileName, src, req)
+	if err != nil {
+		writers.errorf("Error processing %s: %v\n", fileName, err)
+		return 0
+	}
+	_, changes, err := processSource(fileName, []byte(firstPass), req)
+	if err != nil {
+		writers.errorf("Error processing result of %s: %v\n", fileName, err)
+		return 0
+	}
+
+	for _, c := range changes {
+		fmt.Fprintf(writers.Stdout, "%s:%d: not idempotent, %q changed again to %q\n", c.File, c.Line, c.Before, c.After)
+	}
+	return len(changes)
+}
+
+// reportDensity prints functions of the file with the ratio of in-body comments to statements above the threshold.
+// returns the number of reported functions
+func reportDensity(fileName string, req *ProcessRequest, writers OutputWriters) int {
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, fileName, nil, parser.ParseComments)
+	if err != nil {
+		writers.errorf("Error parsing %s: %v\n", fileName, err)
+		return 0
+	}
+	if !req.packageMatches(node) {
+		return 0
+	}
+
+	var reported int
+	for _, decl := range node.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		comments, stmts := functionDensity(fn, node)
+		ratio := float64(comments) / float64(max(stmts, 1))
+		if comments == 0 || ratio <= req.DensityThreshold {
+			continue
+		}
+		reported++
+		fmt.Fprintf(writers.Stdout, "%s:%d: %s has %d comments for %d statements, ratio %.2f\n",
+			fileName, fset.Position(fn.Pos()).Line, funcName(fn), comments, stmts, ratio)
+	}
+	return reported
+}
+
+// commentStyles are casing styles of comments reported in style report mode, in the order of the report
+var commentStyles = [...]string{"lowercase-first", "Title", "ALL CAPS", "other"}
+
+// indexes of commentStyles
+const (
+	styleLowercase = iota
+	styleTitle
+	styleAllCaps
+	styleOther
+)
+
+// reportStyle counts casing styles of in-function comments of the file, the file is never modified
+func reportStyle(fileName string, req *ProcessRequest, writers OutputWriters) int {
+	node, err := parser.ParseFile(token.NewFileSet(), fileName, nil, parser.ParseComments)
+	if err != nil {
+		writers.errorf("Error parsing %s: %v\n", fileName, err)
+		return 0
+	}
+	if !req.packageMatches(node) {
+		return 0
+	}
+	for _, group := range node.Comments {
+		for _, comment := range group.List {
+			if commentScope(node, comment) == "function" {
+				req.StyleCounts[commentStyle(comment.Text)]++
+			}
+		}
+	}
+	return 0
+}
+
+// commentStyle returns the index of the casing style of the comment in commentStyles.
+// all-caps comments have at least two letters, all of them uppercase, comments without letters are "other"
+func commentStyle(comment string) int {
+	content := strings.TrimPrefix(comment, "//")
+	if strings.HasPrefix(comment, "/*") {
+		content = strings.TrimSuffix(strings.TrimPrefix(comment, "/*"), "*/")
+	}
+
+	var first rune
+	var letters, upper int
+	for _, r := range content {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if letters == 0 {
+			first = r
+		}
+		letters++
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+
+	switch {
+	case letters >= 2 && upper == letters:
+		return styleAllCaps
+	case unicode.IsLower(first):
+		return styleLowercase
+	case unicode.IsUpper(first):
+		return styleTitle
+	default:
+		return styleOther
+	}
+}
+
+// styleHistogram formats percentages of comment styles, like "62% lowercase-first, 18% Title, 12% ALL CAPS, 8% other"
+func styleHistogram(counts [len(commentStyles)]int) string {
+	total := sumCounts(counts)
+	parts := make([]string, 0, len(counts))
+	for i, n := range counts {
+		pct := 0
+		if total > 0 {
+			pct = int(math.Round(float64(n) * 100 / float64(total)))
+		}
+		parts = append(parts, fmt.Sprintf("%d%% %s", pct, commentStyles[i]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sumCounts returns the total of style counts
+func sumCounts(counts [len(commentStyles)]int) int {
+	var res int
+	for _, n := range counts {
+		res += n
+	}
+	return res
+}
+
+// functionDensity returns the number of comments and statements in the function body, nested blocks included
+func functionDensity(fn *ast.FuncDecl, file *ast.File) (int, int) {
+	comments, stmts := 0, 0
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			if fn.Body.Lbrace <= comment.Pos() && comment.Pos() <= fn.Body.Rbrace {
+				comments++
+			}
+		}
+	}
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.BlockStmt, *ast.EmptyStmt, *ast.CaseClause, *ast.CommClause:
+			// blocks and clauses only group other statements
+		case ast.Stmt:
+			stmts++
+		}
+		return true
+	})
+	return comments, stmts
+}
+
+// funcName returns the function name, prefixed with the receiver type for methods, like "Server.Run"
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	switch t := recv.(type) {
+	case *ast.IndexExpr: // generic receiver, like Set[T]
+		recv = t.X
+	case *ast.IndexListExpr: // generic receiver, like Map[K, V]
+		recv = t.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name + "." + fn.Name.Name
+	}
+	return fn.Name.Name
+}
+
+// CommentRecord describes an in-function comment for export, with its classification and conversion result
+type CommentRecord struct {
+	File       string  `json:"file"`
+	Line       int     `json:"line"`
+	Column     int     `json:"column"`
+	Text       string  `json:"text"`
+	Scope      string  `json:"scope"`  // function, struct, interface, var or const
+	Inline     bool    `json:"inline"` // trailing comment after code on the same line
+	UpperRatio float64 `json:"upper_ratio"`
+	WordCount  int     `json:"word_count"`
+	Converted  bool    `json:"converted"`
+	Result     string  `json:"result,omitempty"` // comment after conversion, empty if removed or not converted
+}
+
+// exportComments collects in-function comments of the file with metadata into the request, the file is not modified.
+// returns the number of comments that would be converted
+func exportComments(fileName string, req *ProcessRequest, writers OutputWriters) int {
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, fileName, nil, parser.ParseComments)
+	if err != nil {
+		writers.errorf("Error parsing %s: %v\n", fileName, err)
+		return 0
+	}
+	if !req.packageMatches(node) {
+		return 0
+	}
+
+	// collect comments before processing, it changes their text in place
+	type collected struct {
+		comment *ast.Comment
+		text    string
+		record  CommentRecord
+	}
+	var comments []collected
+	for _, group := range node.Comments {
+		for _, comment := range group.List {
+			scope := commentScope(node, comment)
+			if scope == "" {
+				continue
+			}
+			pos := fset.Position(comment.Pos())
+			comments = append(comments, collected{comment: comment, text: comment.Text, record: CommentRecord{
+				File: pos.Filename, Line: pos.Line, Column: pos.Column, Text: comment.Text, Scope: scope,
+				Inline: isTrailingComment(comment, node, fset), UpperRatio: upperRatio(comment.Text),
+				WordCount: commentWordCount(comment.Text),
+			}})
+		}
+	}
+
+	changes := processComments(fset, node, req)
+	for _, c := range comments {
+		if c.comment.Text != c.text {
+			c.record.Converted, c.record.Result = true, c.comment.Text
+		}
+		req.ExportedComments = append(req.ExportedComments, c.record)
+	}
+
+	// removed comments keep their text, mark them by position
+	for _, ch := range changes {
+		if ch.After != "" {
+			continue
+		}
+		for i := range req.ExportedComments {
+			if r := &req.ExportedComments[i]; r.File == ch.File && r.Line == ch.Line && r.Column == ch.Column {
+				r.Converted = true
+			}
+		}
+	}
+	return len(changes)
+}
+
+// upperRatio returns the ratio of uppercase letters to all letters in a comment, 0 if there are no letters
+func upperRatio(comment string) float64 {
+	var upper, letters int
+	for _, r := range comment {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	if letters == 0 {
+		return 0
+	}
+	return float64(upper) / float64(letters)
+}
+
+// writeCommentsExport writes exported comments to a JSON file
+func writeCommentsExport(fileName string, records []CommentRecord) error {
+	if records == nil {
+		records = []CommentRecord{} // write an empty list rather than null
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal exported comments: %w", err)
+	}
+	if err := os.WriteFile(fileName, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("write exported comments to %s: %w", fileName, err)
+	}
+	return nil
+}
+
+// maxCompareExamples is the number of differing comments shown per file in compare mode
+const maxCompareExamples = 2
+
+// compareModes processes the file in memory with both full lowercase and title case modes
+// and reports comments converted differently. Returns the number of differing comments.
+func compareModes(fileName string, req *ProcessRequest, writers OutputWriters) int {
+	// each mode gets its own parsed tree, as processing modifies comments in place
+	fset := token.NewFileSet()
+	nodes := make([]*ast.File, 2)
+	for i := range nodes {
+		node, err := parser.ParseFile(fset, fileName, nil, parser.ParseComments)
+		if err != nil {
+			writers.errorf("Error parsing %s: %v\n", fileName, err)
+			return 0
+		}
+		nodes[i] = node
+	}
+	if !req.packageMatches(nodes[0]) {
+		return 0
+	}
+
+	fullReq, titleReq := *req, *req
+	fullReq.TitleCase, titleReq.TitleCase = false, true
+	fullReq.Capitalize, titleReq.Capitalize = false, false
+	processComments(fset, nodes[0], &fullReq)
+	processComments(fset, nodes[1], &titleReq)
+
+	// both trees have the same comments in the same order
+	var examples []string
+	diffs := 0
+	for i, group := range nodes[0].Comments {
+		for j, fullComment := range group.List {
+			titleComment := nodes[1].Comments[i].List[j]
+			if fullComment.Text == titleComment.Text {
+				continue
+			}
+			diffs++
+			if len(examples) < maxCompareExamples {
+				examples = append(examples, fmt.Sprintf("  line %d:\n    full:  %s\n    title: %s\n",
+					fset.Position(fullComment.Pos()).Line, fullComment.Text, titleComment.Text))
+			}
+		}
+	}
+
+	if diffs > 0 {
+		fmt.Fprintf(writers.Stdout, "%s: %d comments differ\n%s", fileName, diffs, strings.Join(examples, ""))
+	}
+	return diffs
+}
+
+// printGithubAnnotations prints changes as GitHub Actions workflow commands, shown inline on pull requests
+func printGithubAnnotations(changes []Change, writers OutputWriters) {
+	for _, c := range changes {
+		msg := fmt.Sprintf("comment should be %q, not %q", c.After, c.Before)
+		if c.After == "" {
+			msg = fmt.Sprintf("comment %q should be removed", c.Before)
+		}
+		fmt.Fprintf(writers.Stdout, "::warning file=%s,line=%d,col=%d,title=unfuck-ai-comments::%s\n",
+			escapeGithubProperty(c.File), c.Line, c.Column, escapeGithubData(msg))
+	}
+}
+
+// jsonLine is a changed comment written by the json lines output
+type jsonLine struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+}
+
+// printJSONLines prints each change as a JSON object on its own line, so the output can be parsed as a stream
+func printJSONLines(changes []Change, writers OutputWriters) {
+	enc := json.NewEncoder(writers.Stdout)
+	enc.SetEscapeHTML(false)
+	for _, c := range changes {
+		if err := enc.Encode(jsonLine{File: c.File, Line: c.Line, Old: c.Before, New: c.After}); err != nil {
+			writers.errorf("Error writing json line for %s: %v\n", c.File, err)
+			return
+		}
+	}
+}
+
+// jsonSummary is the statistics of the run written by the json output
+type jsonSummary struct {
+	FilesAnalyzed int `json:"files_analyzed"`
+	FilesUpdated  int `json:"files_updated"`
+	TotalChanges  int `json:"total_changes"`
+}
+
+// printJSON prints the changes collected from all files as a JSON array, followed by the summary of the run
+// as a separate JSON object
+func printJSON(req *ProcessRequest, writers OutputWriters) {
+	changes := req.Changes
+	if changes == nil {
+		changes = []Change{} // empty array rather than null
+	}
+	enc := json.NewEncoder(writers.Stdout)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(changes); err != nil {
+		writers.errorf("Error writing json changes: %v\n", err)
+		return
+	}
+	summary := jsonSummary{FilesAnalyzed: req.FilesAnalyzed, FilesUpdated: req.FilesUpdated, TotalChanges: req.TotalChanges}
+	if err := enc.Encode(summary); err != nil {
+		writers.errorf("Error writing json summary: %v\n", err)
+	}
+}
+
+// maxLocationPreview is the maximum number of characters of a comment shown in location previews
+const maxLocationPreview = 60
+
+// printLocations prints location of each change with a short preview of the comment before and after to stderr,
+// at the info level of "Updated: file.go" messages
+func printLocations(changes []Change, writers OutputWriters) {
+	preview := func(s string) string {
+		if runes := []rune(s); len(runes) > maxLocationPreview {
+			return string(runes[:maxLocationPreview-1]) + "…"
+		}
+		return s
+	}
+	for _, c := range changes {
+		after := preview(c.After)
+		if c.After == "" {
+			after = "(removed)"
+		}
+		writers.logf(slog.LevelInfo, writers.Stderr, "  %s:%d: %s -> %s\n", c.File, c.Line, preview(c.Before), after)
+	}
+}
+
+// writeRewriteLog writes a line with the timestamp, location and comment before and after for each change.
+// line breaks of block comments are escaped to keep one line per change
+func writeRewriteLog(w io.Writer, changes []Change, ts time.Time) error {
+	escape := strings.NewReplacer("\n", `\n`).Replace
+	for _, c := range changes {
+		if _, err := fmt.Fprintf(w, "%s %s:%d %s -> %s\n", ts.Format(time.RFC3339), c.File, c.Line,
+			escape(c.Before), escape(c.After)); err != nil {
+			return fmt.Errorf("write rewrite log: %w", err)
+		}
+	}
+	return nil
+}
+
+// printChangedComments prints changed comments as "file:line:comment", like "grep -n" for multiple files
+func printChangedComments(changes []Change, writers OutputWriters) {
+	for _, c := range changes {
+		fmt.Fprintf(writers.Stdout, "%s:%d:%s\n", c.File, c.Line, c.After)
+	}
+}
+
+// escapeGithubData escapes the message of a workflow command
+func escapeGithubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGithubProperty escapes a property value of a workflow command
+func escapeGithubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,522 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCountOutput tests that --output=count prints only the number of changes and leaves files untouched
+func TestCountOutput(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "package test\n\nfunc Example() {\n\t// This Comment\n\tx := 1 // Another comment\n\t_ = x\n}\n"
+	testFile := filepath.Join(tempDir, "count.go")
+	err := os.WriteFile(testFile, []byte(content), 0o600)
+	require.NoError(t, err)
+	t.Chdir(tempDir)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+	req := ProcessRequest{OutputMode: "count", TitleCase: true}
+	processPattern(".", &req, writers)
+	processPattern("nothing*.go", &req, writers)
+	printSummary(&req, writers)
+
+	assert.Equal(t, "2\n", stdoutBuf.String())
+	assert.Contains(t, stderrBuf.String(), "No Go files found", "informational messages go to stderr")
+
+	res, err := os.ReadFile(testFile)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(res), "file should not be modified in count mode")
+}
+
+// TestCompareModes tests the compare subcommand reporting differences between full and title case modes
+func TestCompareModes(t *testing.T) {
+	tempDir := t.TempDir()
+	content := `package test
+
+func Example() {
+	// This Comment Differs
+	// THIS one too
+	// Same in both
+	_ = 1
+}
+`
+	testFile := filepath.Join(tempDir, "compare.go")
+	err := os.WriteFile(testFile, []byte(content), 0o600)
+	require.NoError(t, err)
+	sameFile := filepath.Join(tempDir, "same.go")
+	err = os.WriteFile(sameFile, []byte("package test\n\nfunc Same() {\n\t// already lowercase\n}\n"), 0o600)
+	require.NoError(t, err)
+	t.Chdir(tempDir)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+	req := ProcessRequest{OutputMode: "compare"}
+	processPattern(".", &req, writers)
+	printSummary(&req, writers)
+
+	output := stdoutBuf.String()
+	assert.Contains(t, output, "compare.go: 2 comments differ")
+	assert.Contains(t, output, "line 4:\n    full:  // this comment differs\n    title: // this Comment Differs\n")
+	assert.Contains(t, output, "line 5:\n    full:  // this one too\n    title: // THIS one too\n")
+	assert.NotContains(t, output, "same.go")
+	assert.Contains(t, output, "2 files analyzed, 1 files with differences, 2 total differences")
+
+	res, err := os.ReadFile(testFile)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(res), "compare should not modify files")
+
+	t.Run("command selection", func(t *testing.T) {
+		opts := Options{}
+		p := flags.NewParser(&opts, flags.Default)
+		p.Active = p.Find("compare")
+		opts.Compare.Args.Patterns = []string{"file.go"}
+		result := determineProcessingMode(opts, p)
+		assert.Equal(t, "compare", result.Mode)
+		assert.Equal(t, []string{"file.go"}, result.Patterns)
+	})
+}
+
+// TestGithubAnnotations tests reporting comments to change as GitHub workflow annotations
+func TestGithubAnnotations(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "package test\n\nfunc Example() {\n\t// This Comment\n\tx := 1 // Another, 100% Comment\n\t_ = x\n}\n"
+	err := os.WriteFile(filepath.Join(tempDir, "github.go"), []byte(content), 0o600)
+	require.NoError(t, err)
+	t.Chdir(tempDir)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+	req := ProcessRequest{OutputMode: "github", TitleCase: true}
+	processPatterns([]string{"github.go"}, &req, writers)
+
+	lines := strings.Split(strings.TrimSpace(stdoutBuf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, `::warning file=github.go,line=4,col=2,title=unfuck-ai-comments::comment should be "// this Comment", not "// This Comment"`, lines[0])
+	assert.Equal(t, `::warning file=github.go,line=5,col=9,title=unfuck-ai-comments::comment should be "// another, 100%25 Comment", not "// Another, 100%25 Comment"`, lines[1])
+	assert.Equal(t, 2, req.TotalChanges)
+	assert.True(t, req.failed(), "annotations should fail the run")
+
+	res, err := os.ReadFile("github.go")
+	require.NoError(t, err)
+	assert.Equal(t, content, string(res), "file should not be modified")
+
+	t.Run("nothing to annotate", func(t *testing.T) {
+		require.NoError(t, os.WriteFile("clean.go", []byte("package test\n\nfunc Example() {\n\t// this comment\n}\n"), 0o600))
+		var out bytes.Buffer
+		req := ProcessRequest{OutputMode: "github", TitleCase: true}
+		processPatterns([]string{"clean.go"}, &req, OutputWriters{Stdout: &out, Stderr: &out})
+		assert.Empty(t, out.String())
+		assert.False(t, req.failed())
+	})
+
+	t.Run("escaping", func(t *testing.T) {
+		assert.Equal(t, "a%25b%0Ac%0D", escapeGithubData("a%b\nc\r"))
+		assert.Equal(t, "dir%2Cx%3Ay.go", escapeGithubProperty("dir,x:y.go"))
+	})
+}
+
+// TestShowLocations tests printing changed comments of updated files in inplace mode
+func TestShowLocations(t *testing.T) {
+	t.Chdir(t.TempDir())
+	long := "// Long " + strings.Repeat("x", 80)
+	content := "package test\n\nfunc A() {\n\t// Some Comment\n\t" + long + "\n\tx := 1\n\n\t// Return the result\n\t_ = x\n}\n"
+	require.NoError(t, os.WriteFile("a.go", []byte(content), 0o600))
+
+	obvious, err := compileObviousPatterns(defaultObviousPatterns)
+	require.NoError(t, err)
+	var stdoutBuf, stderrBuf bytes.Buffer
+	req := ProcessRequest{OutputMode: "inplace", TitleCase: true, ShowLocations: true, ObviousPatterns: obvious}
+	processPatterns([]string{"a.go"}, &req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+	assert.Contains(t, stdoutBuf.String(), "Updated: a.go\n")
+	assert.NotContains(t, stdoutBuf.String(), "a.go:4:")
+	assert.Equal(t, "  a.go:4: // Some Comment -> // some Comment\n"+
+		"  a.go:5: "+long[:59]+"… -> // long "+strings.Repeat("x", 51)+"…\n"+
+		"  a.go:8: // Return the result -> (removed)\n", stderrBuf.String())
+
+	t.Run("not printed without the option", func(t *testing.T) {
+		require.NoError(t, os.WriteFile("a.go", []byte(content), 0o600))
+		var stderrBuf bytes.Buffer
+		req := ProcessRequest{OutputMode: "inplace", TitleCase: true}
+		processPatterns([]string{"a.go"}, &req, OutputWriters{Stdout: io.Discard, Stderr: &stderrBuf})
+		assert.Empty(t, stderrBuf.String())
+	})
+
+	t.Run("not printed above the info level", func(t *testing.T) {
+		require.NoError(t, os.WriteFile("a.go", []byte(content), 0o600))
+		var stderrBuf bytes.Buffer
+		req := ProcessRequest{OutputMode: "inplace", TitleCase: true, ShowLocations: true, ObviousPatterns: obvious}
+		processPatterns([]string{"a.go"}, &req, OutputWriters{Stdout: io.Discard, Stderr: &stderrBuf, Level: slog.LevelWarn})
+		assert.Empty(t, stderrBuf.String())
+	})
+}
+
+// TestRewriteLog tests that changed comments are appended to the rewrite log across runs
+func TestRewriteLog(t *testing.T) {
+	t.Chdir(t.TempDir())
+	logFile := "rewrite.log"
+	run := func(content string) {
+		require.NoError(t, os.WriteFile("a.go", []byte(content), 0o600))
+		f, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		require.NoError(t, err)
+		defer f.Close()
+		req := ProcessRequest{OutputMode: "inplace", TitleCase: true, RewriteLog: f}
+		processPatterns([]string{"a.go"}, &req, OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+	}
+
+	run("package test\n\nfunc A() {\n\t// Some Comment\n\t/* Block\n\t   Comment */\n}\n")
+	run("package test\n\nfunc A() {\n\t// clean comment\n\n\t// Another Comment\n}\n")
+	run("package test\n\nfunc A() {\n\t// clean comment\n}\n")
+
+	data, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	require.Len(t, lines, 3, "entries of both runs with changes are kept")
+	ts := `\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(Z|[+-]\d{2}:\d{2})`
+	assert.Regexp(t, "^"+ts+` a\.go:4 // Some Comment -> // some Comment$`, lines[0])
+	assert.Regexp(t, "^"+ts+` a\.go:5 /\* Block\\n\t   Comment \*/ -> /\* block\\n\t   comment \*/$`, lines[1])
+	assert.Regexp(t, "^"+ts+` a\.go:6 // Another Comment -> // another Comment$`, lines[2])
+
+	t.Run("format", func(t *testing.T) {
+		var buf bytes.Buffer
+		ts := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+		require.NoError(t, writeRewriteLog(&buf, []Change{{File: "x.go", Line: 7, Before: "// Foo", After: "// foo"}}, ts))
+		assert.Equal(t, "2024-01-01T10:00:00Z x.go:7 // Foo -> // foo\n", buf.String())
+	})
+}
+
+// TestJSONLines tests that json lines output writes each change as a separate JSON object
+func TestJSONLines(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "package test\n\nfunc Example() {\n\t// This <Comment>\n\tx := 1 // Another \"Comment\"\n\t_ = x\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "a.go"), []byte(content), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "b.go"), []byte(content), 0o600))
+	t.Chdir(tempDir)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	req := ProcessRequest{OutputMode: "jsonl", TitleCase: true}
+	processPatterns([]string{"a.go", "b.go"}, &req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+	assert.Empty(t, stderrBuf.String())
+	assert.Equal(t, 4, req.TotalChanges)
+
+	lines := strings.Split(strings.TrimSuffix(stdoutBuf.String(), "\n"), "\n")
+	require.Len(t, lines, 4)
+	for _, line := range lines {
+		assert.True(t, json.Valid([]byte(line)), "line %q should be valid json", line)
+	}
+	assert.Equal(t, `{"file":"a.go","line":4,"old":"// This <Comment>","new":"// this <Comment>"}`, lines[0])
+
+	var rec jsonLine
+	require.NoError(t, json.Unmarshal([]byte(lines[3]), &rec))
+	assert.Equal(t, jsonLine{File: "b.go", Line: 5, Old: `// Another "Comment"`, New: `// another "Comment"`}, rec)
+
+	res, err := os.ReadFile("a.go")
+	require.NoError(t, err)
+	assert.Equal(t, content, string(res), "file should not be modified")
+}
+
+// TestJSON tests that json output writes changes of all files as an array followed by the summary object
+func TestJSON(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "package test\n\nfunc Example() {\n\t// This <Comment>\n\tx := 1 // Another \"Comment\"\n\t_ = x\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "a.go"), []byte(content), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "b.go"), []byte("package test\n\nfunc B() {\n\t// clean\n}\n"), 0o600))
+	t.Chdir(tempDir)
+
+	t.Run("changes and summary", func(t *testing.T) {
+		var stdoutBuf, stderrBuf bytes.Buffer
+		req := ProcessRequest{OutputMode: "json", TitleCase: true}
+		processPatterns([]string{"a.go", "b.go"}, &req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Empty(t, stderrBuf.String())
+
+		dec := json.NewDecoder(&stdoutBuf)
+		var changes []Change
+		require.NoError(t, dec.Decode(&changes))
+		assert.Equal(t, []Change{
+			{File: "a.go", Line: 4, Column: 2, Before: "// This <Comment>", After: "// this <Comment>"},
+			{File: "a.go", Line: 5, Column: 9, Before: `// Another "Comment"`, After: `// another "Comment"`},
+		}, changes)
+		var summary jsonSummary
+		require.NoError(t, dec.Decode(&summary))
+		assert.Equal(t, jsonSummary{FilesAnalyzed: 2, FilesUpdated: 1, TotalChanges: 2}, summary)
+		assert.False(t, dec.More(), "only the changes and the summary")
+
+		res, err := os.ReadFile("a.go")
+		require.NoError(t, err)
+		assert.Equal(t, content, string(res), "file should not be modified")
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		var stdoutBuf bytes.Buffer
+		req := ProcessRequest{OutputMode: "json", TitleCase: true}
+		processPatterns([]string{"b.go"}, &req, OutputWriters{Stdout: &stdoutBuf, Stderr: io.Discard})
+		assert.Equal(t, "[]\n{\n  \"files_analyzed\": 1,\n  \"files_updated\": 0,\n  \"total_changes\": 0\n}\n", stdoutBuf.String(),
+			"empty array, not null")
+	})
+}
+
+// TestVerifyIdempotent tests the idempotency self-check running the conversion twice
+func TestVerifyIdempotent(t *testing.T) {
+	tempDir := t.TempDir()
+	content := `package test
+
+func Example() {
+	// HTTPServer Starts Here
+	// Saves fooBar And FOOBAR
+	// A b
+	_ = 1
+}
+`
+	stableFile := filepath.Join(tempDir, "stable.go")
+	err := os.WriteFile(stableFile, []byte(content), 0o600)
+	require.NoError(t, err)
+
+	for _, titleCase := range []bool{true, false} {
+		t.Run(fmt.Sprintf("stable title case %v", titleCase), func(t *testing.T) {
+			var stdoutBuf, stderrBuf bytes.Buffer
+			writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+			unstable := processFile(stableFile, &ProcessRequest{OutputMode: "verify", TitleCase: titleCase}, writers)
+			assert.Equal(t, 0, unstable)
+			assert.Empty(t, stdoutBuf.String())
+		})
+	}
+
+	t.Run("unstable conversion detected", func(t *testing.T) {
+		// case-sensitive directive prefix matches only after the first pass lowercased the first letter
+		unstableFile := filepath.Join(tempDir, "unstable.go")
+		err := os.WriteFile(unstableFile, []byte("package test\n\nfunc Example() {\n\t// Arg Name\n}\n"), 0o600)
+		require.NoError(t, err)
+
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+		req := &ProcessRequest{OutputMode: "verify", TitleCase: true, DirectivePrefixes: []string{"arg"}}
+		unstable := processFile(unstableFile, req, writers)
+		assert.Equal(t, 1, unstable)
+		assert.Contains(t, stdoutBuf.String(), `unstable.go:4: not idempotent, "// arg Name" changed again to "// arg name"`)
+
+		res, err := os.ReadFile(unstableFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(res), "// Arg Name", "file should not be modified")
+	})
+}
+
+// TestShowChurn tests the total number of characters changed in the summary
+func TestShowChurn(t *testing.T) {
+	assert.Equal(t, 1, Change{Before: "// Foo bar", After: "// foo bar"}.churn())
+	assert.Equal(t, 5, Change{Before: "// Foo bar", After: "// foo"}.churn(), "one replaced and four removed")
+	assert.Equal(t, 7, Change{Before: "// Foo."}.churn(), "removed comment")
+
+	tempDir := t.TempDir()
+	content := "package test\n\nfunc Example() {\n\t// Some Comment\n\t// SOME Other\n\t// already lower\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "a.go"), []byte(content), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "b.go"), []byte(content), 0o600))
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+	req := &ProcessRequest{OutputMode: "diff", ShowChurn: true}
+	processPatterns([]string{tempDir}, req, writers)
+	assert.Equal(t, 14, req.CharsChanged, "two files with 2 chars of 'Some Comment' and 5 of 'SOME Other' lowercased")
+	assert.Contains(t, stdoutBuf.String(), "Summary: 2 files analyzed, 2 files updated, 4 total changes, 14 chars changed\n")
+
+	stdoutBuf.Reset()
+	processPatterns([]string{tempDir}, &ProcessRequest{OutputMode: "diff"}, writers)
+	assert.Contains(t, stdoutBuf.String(), "Summary: 2 files analyzed, 2 files updated, 4 total changes\n")
+}
+
+// TestExportComments tests exporting in-function comments with metadata to JSON
+func TestExportComments(t *testing.T) {
+	tempDir := t.TempDir()
+	src := `package test
+
+// Package Level Comment
+type T struct {
+	// Field Doc
+	F int
+}
+
+func Example() {
+	// SOME Comment
+	x := 1 // inline note
+	_ = x
+}
+`
+	file := filepath.Join(tempDir, "test.go")
+	require.NoError(t, os.WriteFile(file, []byte(src), 0o600))
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	req := &ProcessRequest{OutputMode: "export", TitleCase: true}
+	processPatterns([]string{tempDir}, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+	assert.Contains(t, stdoutBuf.String(), "Summary: 1 files analyzed, 3 comments exported, 1 to be converted\n")
+
+	res, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, src, string(res), "file should not be modified")
+
+	exportFile := filepath.Join(tempDir, "comments.json")
+	require.NoError(t, writeCommentsExport(exportFile, req.ExportedComments))
+	data, err := os.ReadFile(exportFile)
+	require.NoError(t, err)
+
+	var records []map[string]any
+	require.NoError(t, json.Unmarshal(data, &records))
+	require.Len(t, records, 3)
+	assert.Equal(t, map[string]any{"file": file, "line": 5.0, "column": 2.0, "text": "// Field Doc", "scope": "struct",
+		"inline": false, "upper_ratio": 0.25, "word_count": 2.0, "converted": true, "result": "// field Doc"}, records[0])
+	assert.Equal(t, map[string]any{"file": file, "line": 10.0, "column": 2.0, "text": "// SOME Comment", "scope": "function",
+		"inline": false, "upper_ratio": 5.0 / 11.0, "word_count": 2.0, "converted": false}, records[1])
+	assert.Equal(t, map[string]any{"file": file, "line": 11.0, "column": 9.0, "text": "// inline note", "scope": "function",
+		"inline": true, "upper_ratio": 0.0, "word_count": 2.0, "converted": false}, records[2])
+
+	t.Run("empty export", func(t *testing.T) {
+		require.NoError(t, writeCommentsExport(exportFile, nil))
+		data, err := os.ReadFile(exportFile)
+		require.NoError(t, err)
+		assert.Equal(t, "[]\n", string(data))
+	})
+}
+
+// TestStyleReport tests that style report counts casing styles of in-function comments only
+func TestStyleReport(t *testing.T) {
+	t.Run("comment styles", func(t *testing.T) {
+		tbl := []struct {
+			comment  string
+			expected int
+		}{
+			{"// lowercase comment", styleLowercase},
+			{"// userID is set", styleLowercase},
+			{"// Title comment", styleTitle},
+			{"// TODO fix this", styleTitle},
+			{"// ALL CAPS COMMENT", styleAllCaps},
+			{"// HTTP2 ERROR!", styleAllCaps},
+			{"/* Block comment */", styleTitle},
+			{"// I", styleTitle},
+			{"// 123 = 42", styleOther},
+			{"//", styleOther},
+		}
+		for _, tt := range tbl {
+			assert.Equal(t, commentStyles[tt.expected], commentStyles[commentStyle(tt.comment)], tt.comment)
+		}
+	})
+
+	t.Run("report", func(t *testing.T) {
+		tempDir := t.TempDir()
+		t.Chdir(tempDir)
+		content := `package test
+
+// Package-level Comment Is Not Counted
+var x = 1
+
+func A() {
+	// first comment
+	// second comment
+	// third comment
+	// Fourth comment
+	// FIFTH COMMENT
+	y := 1 // 42
+	_ = y
+}
+`
+		require.NoError(t, os.WriteFile("a.go", []byte(content), 0o600))
+		require.NoError(t, os.WriteFile("b.go", []byte("package test\n\nfunc B() {\n\t// Some Comment\n\t// Another one\n\t// last one\n}\n"), 0o600))
+
+		var stdoutBuf, stderrBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "style", TitleCase: true}
+		processPatterns([]string{"."}, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Empty(t, stderrBuf.String())
+		assert.Equal(t, [len(commentStyles)]int{4, 3, 1, 1}, req.StyleCounts)
+		assert.Equal(t, "Comment styles: 44% lowercase-first, 33% Title, 11% ALL CAPS, 11% other\n\n"+
+			"Summary: 2 files analyzed, 9 in-function comments\n", stdoutBuf.String())
+
+		res, err := os.ReadFile("a.go")
+		require.NoError(t, err)
+		assert.Equal(t, content, string(res), "file should not be modified")
+	})
+
+	assert.Equal(t, "0% lowercase-first, 0% Title, 0% ALL CAPS, 0% other", styleHistogram([len(commentStyles)]int{}))
+}
+
+// TestPrintOnlyChanged tests print mode showing only changed comments with line numbers
+func TestPrintOnlyChanged(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	src := "package test\n\n// Package Comment\nfunc Example() {\n\t// Some Comment\n\t// already lower\n\tx := 1 // Trailing Note\n\t_ = x\n}\n"
+	require.NoError(t, os.WriteFile("test.go", []byte(src), 0o600))
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+	processPatterns([]string{"test.go"}, &ProcessRequest{OutputMode: "print", TitleCase: true, OnlyChanged: true}, writers)
+	assert.Equal(t, "test.go:5:// some Comment\ntest.go:7:// trailing Note\n", stdoutBuf.String())
+
+	res, err := os.ReadFile("test.go")
+	require.NoError(t, err)
+	assert.Equal(t, src, string(res), "file should not be modified")
+}
+
+// TestDensityReport tests reporting functions with too many comments per statement
+func TestDensityReport(t *testing.T) {
+	src := `package test
+
+// Padded does little, doc comments are not counted
+func Padded() int {
+	// Initialize the variable
+	x := 0
+	// Increment the value
+	x++
+	// Return the result
+	return x
+}
+
+func Clean(items []int) int {
+	sum := 0
+	for _, v := range items {
+		sum += v // running total
+	}
+	if sum > 10 {
+		sum = 10
+	}
+	return sum
+}
+
+func (s *Server[T]) Run() {
+	// Start The Server
+	// And Wait
+}
+`
+	node, err := parser.ParseFile(token.NewFileSet(), "test.go", src, parser.ParseComments)
+	require.NoError(t, err)
+	comments, stmts := functionDensity(node.Decls[0].(*ast.FuncDecl), node)
+	assert.Equal(t, 3, comments)
+	assert.Equal(t, 3, stmts)
+	comments, stmts = functionDensity(node.Decls[1].(*ast.FuncDecl), node)
+	assert.Equal(t, 1, comments)
+	assert.Equal(t, 6, stmts, "nested statements are counted")
+	assert.Equal(t, "Server.Run", funcName(node.Decls[2].(*ast.FuncDecl)))
+
+	file := filepath.Join(t.TempDir(), "test.go")
+	require.NoError(t, os.WriteFile(file, []byte(src), 0o600))
+	var stdoutBuf, stderrBuf bytes.Buffer
+	req := &ProcessRequest{OutputMode: "density", DensityThreshold: 0.5}
+	processPatterns([]string{file}, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+	assert.Equal(t, file+":4: Padded has 3 comments for 3 statements, ratio 1.00\n"+
+		file+":24: Server.Run has 2 comments for 0 statements, ratio 2.00\n"+
+		"\nSummary: 1 files analyzed, 1 files with over-commented functions, 2 functions\n", stdoutBuf.String())
+
+	res, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, src, string(res), "file should not be modified")
+}
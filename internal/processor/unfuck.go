@@ -0,0 +1,931 @@
+// Package processor converts in-function comments of Go sources to lowercase and implements
+// the unfuck-ai-comments command. the public API of the conversion is the unfuck package
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/jessevdk/go-flags"
+)
+
+//go:generate go run github.com/umputun/unfuck-ai-comments@latest run --fmt .
+
+// processPatterns processes each pattern and prints the summary.
+// if stdin ("-") is among the patterns, stdout is reserved for the processed stdin content,
+// so messages, diffs and summary for other files are written to stderr
+func processPatterns(args []string, req *ProcessRequest, writers OutputWriters) {
+	statusWriters := writers
+	if slices.Contains(args, stdinPattern) && req.OutputMode != "count" && req.OutputMode != "json" && req.OutputMode != "check" {
+		statusWriters = OutputWriters{Stdout: writers.Stderr, Stderr: writers.Stderr}
+	}
+
+	for _, pattern := range args {
+		patternWriters := statusWriters
+		if pattern == stdinPattern {
+			patternWriters = writers
+		}
+		processPattern(pattern, req, patternWriters)
+		if req.failFastTriggered() {
+			break
+		}
+	}
+
+	// print summary for all modes except print, github annotations, json lines and check
+	if req.OutputMode != "print" && req.OutputMode != "github" && req.OutputMode != "jsonl" && req.OutputMode != "check" {
+		printSummary(req, statusWriters)
+	}
+}
+
+// ProcessRequest contains all processing parameters
+type ProcessRequest struct {
+	OutputMode        string
+	TitleCase         bool
+	LeadingCapsOnly   bool // lowercase only the leading all-caps words, overrides title case
+	Capitalize        bool // uppercase the first character instead of lowercasing it, the inverse of title case
+	Sentence          bool // convert the first character of every sentence in title case
+	GroupAware        bool // keep the leading case of lines after the first one in a group of "//" comments
+	Trim              bool // strip trailing whitespace of line comments
+	EnsureSpace       bool // insert a space after "//" if missing, except for directives
+	Format            bool
+	GofmtBin          string // gofmt binary to format with, the built-in formatter is used if empty
+	Imports           bool   // tidy imports with goimports, after gofmt if both are requested
+	Lenient           bool   // convert comments of files with syntax errors by a line-based scan
+	TabWidth          int    // tab width of the printer, 8 if not set
+	UseSpaces         bool   // printer indents and aligns with spaces instead of tabs
+	SkipPatterns      []string
+	IncludePatterns   []string // files are processed only if they match any of these, all files if empty
+	Backup            bool
+	BackupExt         string // extension of backup files, ".bak" if not set
+	BackupDir         string // directory to put backups under, next to the files if not set
+	MinWords          int
+	MinUpperRun       int
+	DensityThreshold  float64 // comments to statements ratio to report a function in density mode
+	PreviewLimit      int
+	SideBySide        bool
+	DiffFormat        string // "unified" for diffs applicable with patch, colorized simple diff otherwise
+	DiffContext       int    // unchanged lines shown around changes in diffs, the simple diff shows changed lines only if 0
+	LineEndings       string // "lf" or "crlf" to force line endings of modified sources, detected from the original otherwise
+	OnlyChanged       bool   // print mode shows only changed comments, like "grep -n"
+	ShowChurn         bool
+	ShowLocations     bool      // print each changed comment of updated files to stderr in inplace mode
+	RewriteLog        io.Writer // log of comments changed in place, accumulated across runs
+	PreCommit         bool      // exit with non-zero code if files were modified, so the hook runner re-stages them
+	FailFast          bool
+	Jobs              int // files processed in parallel while walking directories, sequentially if 1 or less
+	PackageName       string
+	SkipHidden        bool             // skip hidden directories and files starting with a dot while walking
+	MaxDepth          *int             // directory levels to walk below the root, 0 is the root only, unlimited if nil
+	SkipNestedModules bool             // don't walk into directories with their own go.mod, except the root
+	GeneratedBy       []*regexp.Regexp // banners of generators to skip, searched in the whole file header
+	QuarantineDir     string           // directory to copy files with changes to in quarantine mode
+	NewFiles          map[string]bool  // absolute paths of files to process, all files if nil
+	Stdin             io.Reader        // source for the "-" pattern, os.Stdin if nil
+	Cache             *fileCache       // files without changes in previous runs, not used if nil
+
+	BannerThreshold           float64 // symbols ratio to treat a comment as a banner, 0 disables banner detection
+	NormalizeDirectiveSpacing bool    // collapse spacing in "directive // comment" to a canonical form
+	PreserveColonHeaders      bool    // keep short section headers ending with a colon, like "Steps:"
+	PreserveExportedDocs      bool    // keep comments of exported fields and methods, they are public API docs
+	DocComments               bool    // process doc comments of declarations, the leading declared name is kept
+	DocSlash                  bool    // process "///" and "//!" doc-annotation comments, kept unchanged by default
+	PreserveDeclared          bool    // keep words matching identifiers declared in the package of the file
+	PreserveSingleCaps        bool    // keep standalone single uppercase letters, they are likely math variables
+	AssertDocsPreserved       bool    // refuse to write a file if a doc comment was changed, guards against classification bugs
+	ProperNouns               []string
+	Acronyms                  *regexp.Regexp   // acronyms kept as written in full mode, like HTTP
+	DirectivePrefixes         []string         // custom directive prefixes, like "sqlc:", preserved as is
+	KeepPrefixes              []string         // custom prefixes of comments kept unchanged, like "@TODO"
+	KeepPrefixOnly            bool             // custom prefixes replace the built-in special indicators
+	NoKeepIndicators          bool             // comments with special indicators and custom prefixes are converted too
+	ObviousPatterns           []*regexp.Regexp // standalone comments matching any of these are removed
+
+	// statistics for final summary
+	FilesAnalyzed int
+	FilesUpdated  int
+	TotalChanges  int
+	CharsChanged  int                     // characters added, removed or replaced in all changes
+	FilesNotShown int                     // changed files not shown due to preview limit
+	FilesFailed   int                     // files not written in place, refused by a safety check or failed to write
+	StyleCounts   [len(commentStyles)]int // in-function comments of each casing style in style report mode
+
+	ExportedComments []CommentRecord // comments collected in export mode
+	Changes          []Change        // changes collected in json mode
+
+	declared      map[string]bool // identifiers declared in the package of the file being processed
+	declaredCache *declaredCache  // identifiers declared in packages by directory
+}
+
+// declaredCache keeps identifiers declared in packages by directory, workers processing files in parallel share it
+type declaredCache struct {
+	mu   sync.Mutex
+	dirs map[string]map[string]bool
+}
+
+// get returns identifiers declared in the package of the directory, false if not cached
+func (c *declaredCache) get(dir string) (map[string]bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names, ok := c.dirs[dir]
+	return names, ok
+}
+
+// set caches identifiers declared in the package of the directory
+func (c *declaredCache) set(dir string, names map[string]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dirs == nil {
+		c.dirs = map[string]map[string]bool{}
+	}
+	c.dirs[dir] = names
+}
+
+// packageMatches checks if the parsed file belongs to the requested package, any package matches if not set
+func (r *ProcessRequest) packageMatches(node *ast.File) bool {
+	return r.PackageName == "" || node.Name.Name == r.PackageName
+}
+
+// fileSelected checks if the file matches include patterns, is in the set of new files and belongs to
+// the requested package, any file is selected if none of them are defined. the set has paths with symlinks
+// resolved, so has the file looked up in it
+func (r *ProcessRequest) fileSelected(fileName string) bool {
+	if len(r.IncludePatterns) > 0 && !matchesPatterns(fileName, r.IncludePatterns) {
+		return false
+	}
+	if !r.filePackageMatches(fileName) {
+		return false
+	}
+	if r.NewFiles == nil {
+		return true
+	}
+	absPath, err := filepath.Abs(fileName)
+	if err != nil {
+		return false
+	}
+	if resolved, err := filepath.EvalSymlinks(absPath); err == nil {
+		absPath = resolved
+	}
+	return r.NewFiles[absPath]
+}
+
+// filePackageMatches checks if the file belongs to the requested package by its package clause, so files
+// of other packages are not counted as analyzed. files failing to parse match, their errors are reported later
+func (r *ProcessRequest) filePackageMatches(fileName string) bool {
+	if r.PackageName == "" {
+		return true
+	}
+	node, err := parser.ParseFile(token.NewFileSet(), fileName, nil, parser.PackageClauseOnly)
+	return err != nil || r.packageMatches(node)
+}
+
+// packageDeclarations returns identifiers declared in the file and other Go files of its directory.
+// directories are parsed once, stdin source has no directory, so only its own declarations are used.
+// node may be nil, the file is parsed with its directory then
+func (r *ProcessRequest) packageDeclarations(fileName string, node *ast.File) map[string]bool {
+	if fileName == stdinFileName {
+		return declaredNames([]*ast.File{node})
+	}
+
+	if r.declaredCache == nil {
+		r.declaredCache = &declaredCache{}
+	}
+	dir := filepath.Dir(fileName)
+	if names, ok := r.declaredCache.get(dir); ok {
+		return names
+	}
+	var files []*ast.File
+	if node != nil {
+		files = append(files, node)
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, path := range paths {
+		if file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.SkipObjectResolution); err == nil {
+			files = append(files, file)
+		}
+	}
+	names := declaredNames(files)
+	r.declaredCache.set(dir, names)
+	return names
+}
+
+// packageKey returns the hash of names declared in the package of the file if they change results of the file,
+// like with --preserve-declared, empty otherwise
+func (r *ProcessRequest) packageKey(fileName string) string {
+	if !r.PreserveDeclared || fileName == stdinFileName {
+		return ""
+	}
+	names := r.packageDeclarations(fileName, nil)
+	return contentHash([]byte(strings.Join(slices.Sorted(maps.Keys(names)), "\n")))
+}
+
+// declaredWords returns words of the content matching identifiers declared in the package exactly
+func (r *ProcessRequest) declaredWords(content string) []string {
+	if len(r.declared) == 0 {
+		return nil
+	}
+	var res []string
+	for _, word := range strings.FieldsFunc(content, func(c rune) bool { return !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' }) {
+		if r.declared[word] {
+			res = append(res, word)
+		}
+	}
+	return res
+}
+
+// machineOutput checks if the output mode is machine-readable, so stdout should have nothing but the results
+func (r *ProcessRequest) machineOutput() bool {
+	return r.OutputMode == "count" || r.OutputMode == "github" || r.OutputMode == "jsonl" || r.OutputMode == "json" ||
+		r.OutputMode == "check"
+}
+
+// failFastTriggered checks if processing should stop because fail-fast is enabled and a file with changes was found.
+// it applies to the checking modes only, inplace mode always processes all files
+func (r *ProcessRequest) failFastTriggered() bool {
+	return r.FailFast && (r.OutputMode == "diff" || r.OutputMode == "count" || r.OutputMode == "check") &&
+		r.TotalChanges > 0
+}
+
+// failed checks if the run should exit with non-zero code: files failed to be written, fail fast stopped
+// on changes, verify mode found unstable comments, check mode found comments to fix, or the pre-commit hook
+// modified files
+func (r *ProcessRequest) failed() bool {
+	return r.FilesFailed > 0 || r.failFastTriggered() || (r.OutputMode == "verify" && r.TotalChanges > 0) ||
+		((r.OutputMode == "check" || r.OutputMode == "github") && r.TotalChanges > 0) ||
+		(r.PreCommit && r.OutputMode == "inplace" && r.FilesUpdated > 0)
+}
+
+// processPattern processes a single pattern
+func processPattern(pattern string, req *ProcessRequest, writers OutputWriters) {
+	// read source from stdin and write the result to stdout
+	if pattern == stdinPattern {
+		req.FilesAnalyzed++
+		if changes := processStdin(req, writers); changes > 0 {
+			req.FilesUpdated++
+			req.TotalChanges += changes
+		}
+		return
+	}
+
+	// skip vendor and testdata directories
+	normalizedPath := filepath.Clean(pattern)
+	if strings.Contains(normalizedPath, string(filepath.Separator)+"vendor"+string(filepath.Separator)) ||
+		strings.Contains(normalizedPath, string(filepath.Separator)+"testdata"+string(filepath.Separator)) ||
+		normalizedPath == "vendor" || normalizedPath == "testdata" ||
+		strings.HasPrefix(normalizedPath, "vendor"+string(filepath.Separator)) ||
+		strings.HasPrefix(normalizedPath, "testdata"+string(filepath.Separator)) {
+		writers.debugf("Skipping pattern: %s\n", pattern)
+		return
+	}
+
+	// handle recursive pattern cases
+	if isRecursivePattern(pattern) {
+		dir := extractDirectoryFromPattern(pattern)
+		walkDir(dir, req, writers)
+		return
+	}
+
+	// find files to process
+	files, err := findGoFilesFromPattern(pattern)
+	if err != nil {
+		writers.errorf("Error: %v\n", err)
+		return
+	}
+	if len(files) == 0 {
+		// keep stdout clean for machine-readable output
+		out := writers.Stdout
+		if req.machineOutput() {
+			out = writers.Stderr
+		}
+		writers.logf(slog.LevelInfo, out, "No Go files found matching pattern: %s\n", pattern)
+		return
+	}
+
+	// process each file
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".go") || shouldSkip(file, req.SkipPatterns) || !req.fileSelected(file) {
+			continue
+		}
+
+		req.FilesAnalyzed++
+		changes := processFile(file, req, writers)
+
+		if changes > 0 {
+			req.FilesUpdated++
+			req.TotalChanges += changes
+		}
+		if req.failFastTriggered() {
+			return
+		}
+	}
+}
+
+// stdinPattern is the pattern for reading the source from stdin
+const stdinPattern = "-"
+
+// stdinFileName is the synthetic file name used in messages for the stdin source
+const stdinFileName = "<stdin>"
+
+// processStdin reads Go source from stdin, processes it and writes the result to stdout.
+// the content is written even if nothing changed or parsing failed, so the tool can be used as a filter
+func processStdin(req *ProcessRequest, writers OutputWriters) int {
+	stdin := req.Stdin
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+	src, err := io.ReadAll(stdin)
+	if err != nil {
+		writers.errorf("Error reading %s: %v\n", stdinFileName, err)
+		return 0
+	}
+
+	if req.OutputMode == "compare" {
+		writers.errorf("Error: %s is not supported in compare mode\n", stdinFileName)
+		return 0
+	}
+	// run and print modes write the source to stdout, unchanged if it can't be processed, so it works as a filter
+	printContent := req.OutputMode == "inplace" || req.OutputMode == "print"
+
+	// generated sources are passed through as is
+	if bytes.HasPrefix(src, []byte("// Code generated")) {
+		if printContent {
+			_, _ = writers.Stdout.Write(src)
+		}
+		return 0
+	}
+
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, stdinFileName, src, parser.ParseComments)
+	if err != nil {
+		writers.errorf("Error parsing %s: %v\n", stdinFileName, err)
+		if printContent {
+			_, _ = writers.Stdout.Write(src)
+		}
+		return 0
+	}
+
+	// sources of other packages are passed through as is
+	if !req.packageMatches(node) {
+		if printContent {
+			_, _ = writers.Stdout.Write(src)
+		}
+		return 0
+	}
+
+	changes := processComments(fset, node, req)
+	for _, c := range changes {
+		req.CharsChanged += c.churn()
+	}
+	writeStdinResult(src, fset, node, changes, req, writers)
+	return len(changes)
+}
+
+// writeStdinResult writes the processed stdin source, its diff or its changes in the output mode of the request
+func writeStdinResult(src []byte, fset *token.FileSet, node *ast.File, changes []Change, req *ProcessRequest,
+	writers OutputWriters) {
+	printContent := req.OutputMode == "inplace" || req.OutputMode == "print"
+	switch {
+	case printContent && len(changes) == 0:
+		_, _ = writers.Stdout.Write(src)
+	case printContent:
+		handlePrintMode(fset, node, req, writers)
+	case req.OutputMode == "diff" && len(changes) > 0:
+		handleDiffMode(stdinFileName, src, fset, node, req, writers)
+	case req.OutputMode == "github":
+		printGithubAnnotations(changes, writers)
+	case req.OutputMode == "jsonl":
+		printJSONLines(changes, writers)
+	case req.OutputMode == "json":
+		req.Changes = append(req.Changes, changes...)
+	case req.OutputMode == "check" && len(changes) > 0:
+		fmt.Fprintln(writers.Stdout, stdinFileName)
+	}
+}
+
+// isRecursivePattern checks if a pattern is recursive (contains "...")
+func isRecursivePattern(pattern string) bool {
+	return pattern == "./..." || strings.HasSuffix(pattern, "/...") || strings.HasSuffix(pattern, "...")
+}
+
+// extractDirectoryFromPattern gets the directory part from a recursive pattern
+func extractDirectoryFromPattern(pattern string) string {
+	if pattern == "./..." {
+		return "."
+	}
+
+	dir := strings.TrimSuffix(pattern, "/...")
+	dir = strings.TrimSuffix(dir, "...")
+	if dir == "" {
+		dir = "."
+	}
+	return dir
+}
+
+// findGoFilesFromPattern finds Go files matching a pattern, returns an error for a malformed pattern
+func findGoFilesFromPattern(pattern string) ([]string, error) {
+	// first check if the pattern is a directory
+	fileInfo, err := os.Stat(pattern)
+	if err == nil && fileInfo.IsDir() {
+		// it's a directory, find go files in it
+		matches, err := filepath.Glob(filepath.Join(pattern, "*.go"))
+		if err != nil {
+			return nil, fmt.Errorf("find Go files in %s: %w", pattern, err)
+		}
+		return matches, nil
+	}
+
+	// not a directory, try as a glob pattern
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("glob pattern %s: %w", pattern, err)
+	}
+	return files, nil
+}
+
+// walkDir recursively processes all .go files in directory and subdirectories
+func walkDir(dir string, req *ProcessRequest, writers OutputWriters) {
+	files := walkFiles(dir, req, writers)
+
+	// preview limit and fail fast depend on files processed before, so they keep the walk order
+	if req.Jobs > 1 && req.PreviewLimit == 0 && !req.FailFast {
+		processFilesParallel(files, req, writers)
+		return
+	}
+	for _, path := range files {
+		req.FilesAnalyzed++
+		changes := processFile(path, req, writers)
+
+		if changes > 0 {
+			req.FilesUpdated++
+			req.TotalChanges += changes
+		}
+		if req.failFastTriggered() {
+			return
+		}
+	}
+}
+
+// walkFiles returns Go files of the directory to process in the walk order, skipping vendor and testdata
+// directories, hidden and nested module directories if requested, and files excluded by the request
+func walkFiles(dir string, req *ProcessRequest, writers OutputWriters) []string {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		// files removed during the walk, like temporary files of editors, are gone already
+		if errors.Is(err, fs.ErrNotExist) && path != dir {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if req.skipDir(dir, path, writers) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if req.walkSelected(dir, path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		writers.errorf("Error walking directory %s: %v\n", dir, err)
+	}
+	return files
+}
+
+// skipDir checks if the walk from the root skips the directory at path. vendor and testdata directories and
+// directories matching skip patterns are skipped, as well as directories below the maximum depth, nested modules
+// and hidden directories if requested
+func (r *ProcessRequest) skipDir(root, path string, writers OutputWriters) bool {
+	name := filepath.Base(path)
+	if name == "vendor" || strings.Contains(path, "/vendor/") || name == "testdata" || strings.Contains(path, "/testdata/") {
+		writers.debugf("Skipping directory: %s\n", path)
+		return true
+	}
+	if path == root {
+		return shouldSkip(path, r.SkipPatterns)
+	}
+
+	// don't descend below the maximum depth
+	if r.MaxDepth != nil {
+		if rel, err := filepath.Rel(root, path); err == nil && strings.Count(rel, string(filepath.Separator)) >= *r.MaxDepth {
+			return true
+		}
+	}
+
+	// nested modules are processed on their own
+	if r.SkipNestedModules {
+		if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+			return true
+		}
+	}
+
+	// hidden directories, like .config, but not the walk root itself
+	if r.SkipHidden && strings.HasPrefix(name, ".") {
+		return true
+	}
+	return shouldSkip(path, r.SkipPatterns)
+}
+
+// walkSelected checks if the walk from the root picks the file at path, a Go file which is not hidden
+// if requested, not skipped and selected
+func (r *ProcessRequest) walkSelected(root, path string) bool {
+	if !strings.HasSuffix(path, ".go") {
+		return false
+	}
+	if r.SkipHidden && path != root && strings.HasPrefix(filepath.Base(path), ".") {
+		return false
+	}
+	return !shouldSkip(path, r.SkipPatterns) && r.fileSelected(path)
+}
+
+// fileResult is the result of a file processed by a worker, with the output and statistics kept
+// aside until it is merged into the run
+type fileResult struct {
+	req            *ProcessRequest
+	changes        int
+	stdout, stderr bytes.Buffer
+	rewriteLog     bytes.Buffer
+	done           chan struct{}
+}
+
+// processFilesParallel processes files by a pool of req.Jobs workers. each file is processed with its own
+// copy of the request and buffered writers, results are merged in the order of files, so the output
+// and the summary are the same as for sequential processing
+func processFilesParallel(files []string, req *ProcessRequest, writers OutputWriters) {
+	// workers copy the template, the request itself is updated while merging results.
+	// packages parsed for declared names are shared, each directory is parsed once
+	if req.declaredCache == nil {
+		req.declaredCache = &declaredCache{}
+	}
+	tmpl := *req
+	tmpl.FilesAnalyzed, tmpl.FilesUpdated, tmpl.TotalChanges, tmpl.CharsChanged, tmpl.FilesFailed = 0, 0, 0, 0, 0
+	tmpl.Changes, tmpl.ExportedComments, tmpl.StyleCounts = nil, nil, [len(commentStyles)]int{}
+
+	results := make([]*fileResult, len(files))
+	for i := range results {
+		results[i] = &fileResult{done: make(chan struct{})}
+	}
+	indexes := make(chan int)
+	for range min(req.Jobs, len(files)) {
+		go func() {
+			for i := range indexes {
+				res := results[i]
+				fileReq := tmpl
+				if fileReq.RewriteLog != nil {
+					fileReq.RewriteLog = &res.rewriteLog
+				}
+				res.req = &fileReq
+				res.changes = processFile(files[i], res.req, OutputWriters{Stdout: &res.stdout, Stderr: &res.stderr, Level: writers.Level})
+				close(res.done)
+			}
+		}()
+	}
+	go func() {
+		for i := range files {
+			indexes <- i
+		}
+		close(indexes)
+	}()
+
+	for i, res := range results {
+		<-res.done
+		_, _ = res.stdout.WriteTo(writers.Stdout)
+		_, _ = res.stderr.WriteTo(writers.Stderr)
+		if req.RewriteLog != nil {
+			if _, err := res.rewriteLog.WriteTo(req.RewriteLog); err != nil {
+				writers.errorf("Error writing rewrite log for %s: %v\n", files[i], err)
+			}
+		}
+
+		req.FilesAnalyzed++
+		if res.changes > 0 {
+			req.FilesUpdated++
+			req.TotalChanges += res.changes
+		}
+		req.CharsChanged += res.req.CharsChanged
+		req.FilesFailed += res.req.FilesFailed
+		req.Changes = append(req.Changes, res.req.Changes...)
+		req.ExportedComments = append(req.ExportedComments, res.req.ExportedComments...)
+		for style, n := range res.req.StyleCounts {
+			req.StyleCounts[style] += n
+		}
+		results[i] = nil // release buffers of merged files
+	}
+}
+
+// shouldSkip checks if a path should be skipped based on skip patterns
+func shouldSkip(path string, skipPatterns []string) bool {
+	return matchesPatterns(path, skipPatterns)
+}
+
+// matchesPatterns checks if a path matches any of the patterns, exactly, as a file within a directory
+// of the pattern, or as a glob matched against the full path or the base name
+func matchesPatterns(path string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+
+	// normalize path
+	normalizedPath := filepath.Clean(path)
+
+	for _, pattern := range patterns {
+		// check for exact match
+		if pattern == normalizedPath {
+			return true
+		}
+
+		// check if path is within a directory of the pattern
+		if strings.HasPrefix(normalizedPath, pattern+string(filepath.Separator)) {
+			return true
+		}
+
+		// check for glob pattern match
+		matched, err := filepath.Match(pattern, normalizedPath)
+		if err == nil && matched {
+			return true
+		}
+
+		// also check just the base name for simple pattern matching
+		matched, err = filepath.Match(pattern, filepath.Base(normalizedPath))
+		if err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isGeneratedFile checks if a file is a generated file by examining the first line.
+// banners of ignored generators are searched in all lines before the package clause
+func isGeneratedFile(fileName string, generators []*regexp.Regexp) (bool, error) {
+	file, err := os.Open(fileName) //nolint:gosec // we need to open the file to check if it's generated
+	if err != nil {
+		return false, fmt.Errorf("open file %s: %w", fileName, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for first := true; scanner.Scan(); first = false {
+		line := scanner.Text()
+		if first && strings.HasPrefix(line, "// Code generated") {
+			return true, nil
+		}
+		if len(generators) == 0 || strings.HasPrefix(line, "package ") {
+			return false, nil
+		}
+		if slices.ContainsFunc(generators, func(re *regexp.Regexp) bool { return re.MatchString(line) }) {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("scan file %s: %w", fileName, err)
+	}
+	return false, nil // empty file is not generated
+}
+
+// generatorBanners are patterns of banners written by common code generators, by generator name
+var generatorBanners = map[string]string{
+	"moq":           `^// Code generated by moq\b`,
+	"mockgen":       `^// (Code|Automatically) generated by MockGen\b`,
+	"stringer":      `^// Code generated by "stringer\b`,
+	"protoc-gen-go": `^// Code generated by protoc-gen-go(-grpc)?\b`,
+	"sqlc":          `^// Code generated by sqlc\b`,
+}
+
+// generatorPatterns returns banner patterns of the named generators, from the built-in table
+func generatorPatterns(names []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(names))
+	for _, name := range names {
+		banner, ok := generatorBanners[strings.TrimSpace(name)]
+		if !ok {
+			known := slices.Sorted(maps.Keys(generatorBanners))
+			return nil, fmt.Errorf("unknown generator %q, known generators: %s", name, strings.Join(known, ", "))
+		}
+		res = append(res, regexp.MustCompile(banner))
+	}
+	return res, nil
+}
+
+// processFile processes a file using custom writers
+func processFile(fileName string, req *ProcessRequest, writers OutputWriters) int {
+	// check if file is generated
+	isGenerated, err := isGeneratedFile(fileName, req.GeneratedBy)
+	if err != nil {
+		writers.errorf("Error checking if file is generated %s: %v\n", fileName, err)
+		return 0
+	}
+	if isGenerated {
+		writers.debugf("Skipping generated file: %s\n", fileName)
+		return 0
+	}
+
+	// compare and verify modes run their own processing passes and never modify the file
+	switch req.OutputMode {
+	case "compare":
+		return compareModes(fileName, req, writers)
+	case "verify":
+		return verifyIdempotent(fileName, req, writers)
+	case "export":
+		return exportComments(fileName, req, writers)
+	case "density":
+		return reportDensity(fileName, req, writers)
+	case "style":
+		return reportStyle(fileName, req, writers)
+	}
+
+	src, skip := readUncached(fileName, req, writers)
+	if skip {
+		return 0
+	}
+
+	// parse the file, the source read for the cache check is reused
+	fset := token.NewFileSet()
+	var parserSrc any
+	if src != nil {
+		parserSrc = src
+	}
+	node, err := parser.ParseFile(fset, fileName, parserSrc, parser.ParseComments)
+	if err != nil {
+		if req.Lenient {
+			return processLenient(fileName, src, err, req, writers)
+		}
+		writers.errorf("Error parsing %s: %v\n", fileName, err)
+		return 0
+	}
+
+	// skip files of other packages if package name is set
+	if !req.packageMatches(node) {
+		writers.debugf("Skipping file of package %s: %s\n", node.Name.Name, fileName)
+		return 0
+	}
+
+	// process comments
+	changes := processComments(fset, node, req)
+
+	// if no comments were modified, no need to proceed
+	if len(changes) == 0 {
+		if req.Cache != nil {
+			req.Cache.update(fileName, src, req.packageKey(fileName))
+		}
+		return 0
+	}
+
+	// nothing is changed if the write was refused or the result is identical to the original
+	if !writeFileResult(fileName, fset, node, changes, req, writers) {
+		return 0
+	}
+	for _, c := range changes {
+		req.CharsChanged += c.churn()
+	}
+	return len(changes)
+}
+
+// readUncached reads the file if the cache is used, returns true if the file has no changes according to the cache
+// or can't be read. files without changes in previous runs with the same options are not parsed again
+func readUncached(fileName string, req *ProcessRequest, writers OutputWriters) ([]byte, bool) {
+	if req.Cache == nil {
+		return nil, false
+	}
+	src, err := os.ReadFile(fileName) //nolint:gosec
+	if err != nil {
+		writers.errorf("Error reading file %s: %v\n", fileName, err)
+		return nil, true
+	}
+	if req.Cache.unchanged(fileName, src, req.packageKey(fileName)) {
+		writers.debugf("Unchanged since the last run: %s\n", fileName)
+		return nil, true
+	}
+	return src, false
+}
+
+// writeFileResult writes the processed file in the output mode of the request: in place, to stdout, as a diff
+// or as the list of changes. returns false if the file was not written in place
+func writeFileResult(fileName string, fset *token.FileSet, node *ast.File, changes []Change, req *ProcessRequest,
+	writers OutputWriters) bool {
+	switch req.OutputMode {
+	case "inplace":
+		if !handleInplaceMode(fileName, fset, node, req, writers) {
+			return false
+		}
+		if req.Cache != nil {
+			if updated, err := os.ReadFile(fileName); err == nil { //nolint:gosec
+				req.Cache.update(fileName, updated, req.packageKey(fileName))
+			}
+		}
+		reportUpdate(fileName, changes, req, writers)
+	case "print":
+		if req.OnlyChanged {
+			printChangedComments(changes, writers)
+			break
+		}
+		handlePrintMode(fset, node, req, writers)
+	case "diff":
+		// count files beyond the preview limit without showing their diffs
+		if req.PreviewLimit > 0 && req.FilesUpdated >= req.PreviewLimit {
+			req.FilesNotShown++
+			break
+		}
+		origBytes, err := os.ReadFile(fileName) //nolint:gosec
+		if err != nil {
+			writers.errorf("Error reading original file %s: %v\n", fileName, err)
+			break
+		}
+		handleDiffMode(fileName, origBytes, fset, node, req, writers)
+	default:
+		reportChanges(fileName, changes, req, writers)
+	}
+	return true
+}
+
+// ProcessSource converts comments of the Go source with default settings and returns the modified source
+// with the list of changes, the source is returned as is if nothing was changed. it implements
+// the ProcessSource of the unfuck package
+func ProcessSource(src []byte, titleCase bool) ([]byte, []Change, error) {
+	// the request is made the way the command makes it, from options with their defaults
+	var opts Options
+	p := flags.NewParser(&opts, flags.None)
+	p.SubcommandsOptional = true
+	if _, err := p.ParseArgs(nil); err != nil {
+		return nil, nil, fmt.Errorf("default options: %w", err)
+	}
+	opts.Full = !titleCase
+	req, err := newProcessRequest(opts, "print")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, changes, err := processSource("", src, &req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(changes) == 0 {
+		return src, nil, nil
+	}
+	return []byte(res), changes, nil
+}
+
+// processSource parses the source, processes its comments and returns the modified source with the list of changes.
+// the file name is used for positions and error messages only
+func processSource(fileName string, src []byte, req *ProcessRequest) (string, []Change, error) {
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, fileName, src, parser.ParseComments)
+	if err != nil {
+		return "", nil, fmt.Errorf("parse %s: %w", fileName, err)
+	}
+	changes := processComments(fset, node, req)
+	res, err := getModifiedContent(fset, node, req.printerConfig())
+	if err != nil {
+		return "", nil, err
+	}
+	return req.matchLineEndings(string(src), res), changes, nil
+}
+
+// Change describes a single modified comment
+type Change struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// churn returns the number of characters replaced, added or removed by the change
+func (c Change) churn() int {
+	before, after := []rune(c.Before), []rune(c.After)
+	res := max(len(before), len(after)) - min(len(before), len(after))
+	for i := range min(len(before), len(after)) {
+		if before[i] != after[i] {
+			res++
+		}
+	}
+	return res
+}
+
+// handlePrintMode prints the modified content to stdout with custom writers
+func handlePrintMode(fset *token.FileSet, node *ast.File, req *ProcessRequest, writers OutputWriters) {
+	content, err := getModifiedContent(fset, node, req.printerConfig())
+	if err != nil {
+		writers.errorf("Error writing to stdout: %v\n", err)
+		return
+	}
+
+	fmt.Fprint(writers.Stdout, req.formatSource(fset.Position(node.Pos()).Filename, content, writers))
+}
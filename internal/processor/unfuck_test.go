@@ -0,0 +1,1448 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProcessFile tests the main processing function with different modes
+func TestProcessFile(t *testing.T) {
+	// create a temporary directory for test files
+	tempDir := t.TempDir()
+
+	// create a test file with comments
+	testFile := filepath.Join(tempDir, "test_file.go")
+	content := `package test
+
+func Example() {
+	// THIS COMMENT should be converted
+	x := 1 // ANOTHER COMMENT
+}`
+	err := os.WriteFile(testFile, []byte(content), 0o600)
+	require.NoError(t, err, "Failed to write test file")
+
+	// test inplace mode
+	t.Run("inplace mode", func(t *testing.T) {
+		// reset file
+		err := os.WriteFile(testFile, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to reset test file")
+
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process file in inplace mode
+		processFile(testFile, &ProcessRequest{OutputMode: "inplace"}, writers)
+
+		// verify output
+		output := stdoutBuf.String()
+		assert.Contains(t, output, "Updated:", "Should show update message")
+
+		// read the file content
+		modifiedContent, err := os.ReadFile(testFile)
+		require.NoError(t, err, "Failed to read modified file")
+		modifiedStr := string(modifiedContent)
+
+		// verify changes
+		assert.Contains(t, modifiedStr, "// this comment", "Should convert comments to lowercase")
+		assert.Contains(t, modifiedStr, "// another comment", "Should convert all comments to lowercase")
+		assert.NotContains(t, modifiedStr, "// THIS COMMENT", "Should not contain original uppercase comments")
+	})
+
+	// test diff mode
+	t.Run("diff mode", func(t *testing.T) {
+		// reset file
+		err := os.WriteFile(testFile, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to reset test file")
+
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process file in diff mode
+		processFile(testFile, &ProcessRequest{OutputMode: "diff"}, writers)
+
+		// verify diff output
+		output := stdoutBuf.String()
+		assert.Contains(t, output, "---", "Should show diff markers")
+		assert.Contains(t, output, "+++", "Should show diff markers")
+		// the exact format of the diff output depends on how diff is formatted,
+		// so check for content rather than exact format
+		assert.Contains(t, output, "THIS COMMENT", "Should show original comment")
+		assert.Contains(t, output, "this comment", "Should show converted comment")
+
+		// file should not be modified
+		modifiedContent, err := os.ReadFile(testFile)
+		require.NoError(t, err, "Failed to read file")
+		assert.Equal(t, content, string(modifiedContent), "File should not be modified in diff mode")
+	})
+
+	// test print mode
+	t.Run("print mode", func(t *testing.T) {
+		// reset file
+		err := os.WriteFile(testFile, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to reset test file")
+
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process file in print mode
+		processFile(testFile, &ProcessRequest{OutputMode: "print"}, writers)
+
+		// verify printed output
+		output := stdoutBuf.String()
+		assert.Contains(t, output, "// this comment", "Should contain converted comment")
+
+		// file should not be modified in print mode
+		unmodifiedContent, err := os.ReadFile(testFile)
+		require.NoError(t, err, "Failed to read file")
+		assert.Equal(t, content, string(unmodifiedContent), "File should not be modified in print mode")
+	})
+}
+
+// TestProcessPatternHandling tests different pattern types
+func TestProcessPatternHandling(t *testing.T) {
+	// create a temporary directory structure for tests
+	tempDir := t.TempDir()
+
+	// create subdirectories
+	subDir1 := filepath.Join(tempDir, "dir1")
+	subDir2 := filepath.Join(tempDir, "dir2")
+	err := os.Mkdir(subDir1, 0o750)
+	require.NoError(t, err, "Failed to create subdirectory 1")
+	err = os.Mkdir(subDir2, 0o750)
+	require.NoError(t, err, "Failed to create subdirectory 2")
+
+	// create test go files with comments
+	files := map[string]string{
+		filepath.Join(tempDir, "root.go"):    "package main\n\nfunc Root() {\n\t// THIS COMMENT\n}\n",
+		filepath.Join(subDir1, "file1.go"):   "package dir1\n\nfunc Dir1() {\n\t// ANOTHER COMMENT\n}\n",
+		filepath.Join(subDir2, "file2.go"):   "package dir2\n\nfunc Dir2() {\n\t// THIRD COMMENT\n}\n",
+		filepath.Join(tempDir, "notago.txt"): "This is not a go file",
+	}
+
+	for path, content := range files {
+		err := os.WriteFile(path, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to create test file: "+path)
+	}
+
+	// change to temp dir
+	t.Chdir(tempDir)
+
+	t.Run("specific file pattern", func(t *testing.T) {
+		// reset file
+		err := os.WriteFile("root.go", []byte(files[filepath.Join(tempDir, "root.go")]), 0o600)
+		require.NoError(t, err)
+
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process specific file
+		req := ProcessRequest{OutputMode: "inplace", TitleCase: false, Format: false, SkipPatterns: []string{}}
+		processPattern("root.go", &req, writers)
+
+		// verify output
+		output := stdoutBuf.String()
+		assert.Contains(t, output, "Updated:", "Should show file was updated")
+
+		// check file was modified
+		content, err := os.ReadFile("root.go")
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "// this comment", "Comment should be lowercase")
+	})
+	t.Run("glob pattern", func(t *testing.T) {
+		// reset files
+		err := os.WriteFile(filepath.Join("dir1", "file1.go"), []byte(files[filepath.Join(tempDir, "dir1", "file1.go")]), 0o600)
+		require.NoError(t, err)
+
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process glob pattern
+		req := ProcessRequest{OutputMode: "inplace", TitleCase: false, Format: false, SkipPatterns: []string{}}
+		processPattern("dir1/*.go", &req, writers)
+
+		// verify output
+		output := stdoutBuf.String()
+		assert.Contains(t, output, "Updated:", "Should show file was updated")
+
+		// check file was modified
+		content, err := os.ReadFile(filepath.Join("dir1", "file1.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "// another comment", "Comment should be lowercase")
+	})
+
+	t.Run("directory pattern", func(t *testing.T) {
+		// reset files
+		err := os.WriteFile(filepath.Join("dir2", "file2.go"), []byte(files[filepath.Join(tempDir, "dir2", "file2.go")]), 0o600)
+		require.NoError(t, err)
+
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process directory
+		req := ProcessRequest{OutputMode: "inplace", TitleCase: false, Format: false, SkipPatterns: []string{}}
+		processPattern("dir2", &req, writers)
+
+		// verify output
+		output := stdoutBuf.String()
+		assert.Contains(t, output, "Updated:", "Should show file was updated")
+
+		// check file was modified
+		content, err := os.ReadFile(filepath.Join("dir2", "file2.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "// third comment", "Comment should be lowercase")
+	})
+
+	t.Run("recursive pattern with '...'", func(t *testing.T) {
+		// reset all files
+		for path, content := range files {
+			relPath, err := filepath.Rel(tempDir, path)
+			require.NoError(t, err)
+			if strings.HasSuffix(relPath, ".go") {
+				err := os.WriteFile(relPath, []byte(content), 0o600)
+				require.NoError(t, err)
+			}
+		}
+
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process recursive pattern
+		req := ProcessRequest{OutputMode: "inplace", TitleCase: false, Format: false, SkipPatterns: []string{}}
+		processPattern("dir1...", &req, writers)
+
+		// verify file was modified
+		content, err := os.ReadFile(filepath.Join("dir1", "file1.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "// another comment", "Comment should be lowercase")
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		// capture output using a buffer writer
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process non-existent pattern
+		req := ProcessRequest{OutputMode: "inplace", TitleCase: false, Format: false, SkipPatterns: []string{}}
+		processPattern("nonexistent*.go", &req, writers)
+
+		// verify output
+		output := stdoutBuf.String()
+		assert.Contains(t, output, "No Go files found", "Should report no files found")
+
+		stdoutBuf.Reset()
+		writers.Level = slog.LevelWarn
+		processPattern("nonexistent*.go", &req, writers)
+		assert.Empty(t, stdoutBuf.String(), "not reported above the info level")
+	})
+
+	t.Run("malformed pattern", func(t *testing.T) {
+		var stdoutBuf, stderrBuf bytes.Buffer
+		req := ProcessRequest{OutputMode: "inplace"}
+		processPattern("file[.go", &req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Equal(t, "Error: glob pattern file[.go: syntax error in pattern\n", stderrBuf.String())
+		assert.Empty(t, stdoutBuf.String())
+	})
+}
+
+// TestSampleGo tests processing of testdata/sample.go to verify identifier preservation
+func TestSampleGo(t *testing.T) {
+	// get path to testdata/sample.go
+	currentDir, err := os.Getwd()
+	require.NoError(t, err, "Failed to get working directory")
+
+	samplePath := filepath.Join(currentDir, "testdata", "sample.go")
+
+	// verify the sample file exists
+	_, err = os.Stat(samplePath)
+	require.NoError(t, err, "Sample file not found at "+samplePath)
+
+	// create a temporary copy of the sample file
+	tempDir := t.TempDir()
+	tempFile := filepath.Join(tempDir, "sample_test.go")
+
+	// read the original file
+	originalContent, err := os.ReadFile(samplePath)
+	require.NoError(t, err, "Failed to read sample file")
+
+	// write to the temporary location
+	err = os.WriteFile(tempFile, originalContent, 0o600)
+	require.NoError(t, err, "Failed to write temporary sample file")
+
+	// process the file
+	var stdoutBuf, stderrBuf bytes.Buffer
+	writers := OutputWriters{
+		Stdout: &stdoutBuf,
+		Stderr: &stderrBuf,
+	}
+
+	// process in inplace mode first with FULL lowercase mode (not title case)
+	processFile(tempFile, &ProcessRequest{OutputMode: "inplace"}, writers)
+
+	// then read the processed file directly
+	modifiedContent, err := os.ReadFile(tempFile)
+	require.NoError(t, err, "Failed to read modified file")
+	processedContent := string(modifiedContent)
+
+	// check for camelCase and PascalCase preservation
+	assert.Contains(t, processedContent, "camelCase should be preserved",
+		"camelCase identifier should be preserved")
+	assert.Contains(t, processedContent, "PascalCase should be preserved",
+		"PascalCase identifier should be preserved")
+	assert.Contains(t, processedContent, "someVariableName",
+		"camelCase variable name should be preserved")
+	assert.Contains(t, processedContent, "OtherVariable",
+		"PascalCase variable name should be preserved")
+
+	// check that regular words are converted
+	assert.Contains(t, processedContent, "// example with",
+		"Regular words should be lowercase")
+
+	// verify specific comment that demonstrates both lowercase conversion and identifier preservation
+	assert.Contains(t, processedContent, "// example with camelCase and PascalCase identifiers",
+		"First word should be lowercase while identifiers preserved")
+
+	// verify comments inside var blocks are converted
+	assert.Contains(t, processedContent, "// this comment should be converted",
+		"Comment inside var block should be converted")
+	assert.Contains(t, processedContent, "// inline comment should be converted",
+		"Inline comment inside var block should be converted")
+	assert.Contains(t, processedContent, "// another comment to process",
+		"Another comment inside var block should be converted")
+	assert.Contains(t, processedContent, "// another inline comment to process",
+		"Another inline comment inside var block should be converted")
+
+	// verify documentation comments for variables are preserved
+	assert.Contains(t, processedContent, "// DocumentedVar is a variable with documentation",
+		"Documentation comment for variables following the VarName pattern should be preserved")
+
+	// verify regular comments for variables are converted
+	assert.Contains(t, processedContent, "// this comment doesn't follow the naming pattern",
+		"Regular comments for variables should be converted")
+
+	// verify comments inside const blocks are converted
+	assert.Contains(t, processedContent, "// this comment should be converted",
+		"Comment inside const block should be converted")
+	assert.Contains(t, processedContent, "// inline comment should be converted",
+		"Inline comment inside const block should be converted")
+
+	// verify documentation comments for constants are preserved
+	assert.Contains(t, processedContent, "// DocumentedConst is a documented constant",
+		"Documentation comment for constants following the ConstName pattern should be preserved")
+
+	// verify regular comments for constants are converted
+	assert.Contains(t, processedContent, "// this comment doesn't follow the naming pattern",
+		"Regular comments for constants should be converted")
+
+	// verify comments inside local var/const blocks are converted
+	assert.Contains(t, processedContent, "// this should be converted",
+		"Comment inside local var block should be converted")
+	assert.Contains(t, processedContent, "// another local comment",
+		"Comment inside local blocks should be converted")
+}
+
+// TestHelperFunctions tests the helper functions for pattern processing
+func TestHelperFunctions(t *testing.T) {
+	t.Run("isRecursivePattern", func(t *testing.T) {
+		tests := []struct {
+			pattern  string
+			expected bool
+		}{
+			{"./...", true},
+			{"dir/...", true},
+			{"dir...", true},
+			{"dir/*.go", false},
+			{"file.go", false},
+			{"/abs/path/...", true},
+		}
+
+		for _, tc := range tests {
+			result := isRecursivePattern(tc.pattern)
+			assert.Equal(t, tc.expected, result, "Pattern: %s", tc.pattern)
+		}
+	})
+
+	t.Run("extractDirectoryFromPattern", func(t *testing.T) {
+		tests := []struct {
+			pattern  string
+			expected string
+		}{
+			{"./...", "."},
+			{"dir/...", "dir"},
+			{"dir...", "dir"},
+			{"/abs/path/...", "/abs/path"},
+			{"...", "."},
+		}
+
+		for _, tc := range tests {
+			result := extractDirectoryFromPattern(tc.pattern)
+			assert.Equal(t, tc.expected, result, "Pattern: %s", tc.pattern)
+		}
+	})
+	t.Run("findGoFilesFromPattern", func(t *testing.T) {
+		// create temporary directory for testing
+		tempDir := t.TempDir()
+
+		// create subdirectory
+		err := os.MkdirAll(filepath.Join(tempDir, "subdir"), 0o750)
+		require.NoError(t, err)
+
+		// create test files
+		testFiles := []string{
+			filepath.Join(tempDir, "file1.go"),
+			filepath.Join(tempDir, "file2.go"),
+			filepath.Join(tempDir, "subdir", "file3.go"),
+		}
+
+		// create all test files
+		for _, file := range testFiles {
+			err := os.WriteFile(file, []byte("package test"), 0o600)
+			require.NoError(t, err)
+		}
+
+		// create a non-go file
+		nonGoFile := filepath.Join(tempDir, "file.txt")
+		err = os.WriteFile(nonGoFile, []byte("text file"), 0o600)
+		require.NoError(t, err)
+
+		// change to temp dir
+		t.Chdir(tempDir)
+
+		// test with directory path
+		files, err := findGoFilesFromPattern(".")
+		require.NoError(t, err)
+		assert.Len(t, files, 2, "Should find 2 .go files in the root directory")
+
+		// test with glob pattern
+		files, err = findGoFilesFromPattern("*.go")
+		require.NoError(t, err)
+		assert.Len(t, files, 2, "Should find 2 .go files matching pattern")
+
+		// test with specific file
+		files, err = findGoFilesFromPattern("file1.go")
+		require.NoError(t, err)
+		assert.Len(t, files, 1, "Should find 1 file")
+		assert.Contains(t, files[0], "file1.go", "Should find the specified file")
+
+		// test with malformed pattern
+		_, err = findGoFilesFromPattern("file[.go")
+		require.EqualError(t, err, "glob pattern file[.go: syntax error in pattern")
+	})
+
+	t.Run("hasSpecialIndicator", func(t *testing.T) {
+		tests := []struct {
+			content  string
+			expected bool
+		}{
+			{"TODO: fix this", true},
+			{"FIXME: urgent issue", true},
+			{"HACK: workaround", true},
+			{"XXX: needs attention", true},
+			{"WARNING: be careful", true},
+			{"Normal comment", false},
+			{"Contains TODO somewhere", false},
+			{"  TODO: with spaces", true},
+			{"", false},
+		}
+
+		for _, tc := range tests {
+			result := hasSpecialIndicator(tc.content)
+			assert.Equal(t, tc.expected, result, "Content: %s", tc.content)
+		}
+	})
+
+	t.Run("processLineComment", func(t *testing.T) {
+		tests := []struct {
+			name          string
+			content       string
+			fullLowercase bool
+			expected      string
+		}{
+			{
+				name:          "full lowercase conversion",
+				content:       " THIS Should BE Lowercase",
+				fullLowercase: true,
+				expected:      "// this should be lowercase",
+			},
+			{
+				name:          "title case conversion",
+				content:       " THIs Should BE Lowercase",
+				fullLowercase: false,
+				expected:      "// tHIs Should BE Lowercase",
+			},
+			{
+				name:          "special indicator preserved in full lowercase",
+				content:       " TODO: Fix this issue",
+				fullLowercase: true,
+				expected:      "// TODO: Fix this issue",
+			},
+			{
+				name:          "special indicator preserved in title case",
+				content:       " TODO: Fix this issue",
+				fullLowercase: false,
+				expected:      "// TODO: Fix this issue",
+			},
+			{
+				name:          "empty content",
+				content:       "",
+				fullLowercase: true,
+				expected:      "//",
+			},
+			{
+				name:          "only whitespace",
+				content:       "   ",
+				fullLowercase: true,
+				expected:      "//   ",
+			},
+			{
+				name:          "all uppercase first word",
+				content:       " AI is artificial intelligence",
+				fullLowercase: false,
+				expected:      "// AI is artificial intelligence",
+			},
+			{
+				name:          "all uppercase first word with punctuation",
+				content:       " CPU: high usage detected",
+				fullLowercase: false,
+				expected:      "// CPU: high usage detected",
+			},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				result := processLineComment(tc.content, &ProcessRequest{TitleCase: !tc.fullLowercase})
+				assert.Equal(t, tc.expected, result)
+			})
+		}
+	})
+}
+
+// TestIsGeneratedFile tests the isGeneratedFile function
+func TestIsGeneratedFile(t *testing.T) {
+	t.Run("detects generated file", func(t *testing.T) {
+		// create a temporary file with the generated code marker
+		tempDir := t.TempDir()
+		generatedFile := filepath.Join(tempDir, "generated.go")
+		err := os.WriteFile(generatedFile, []byte("// Code generated by mockgen. DO NOT EDIT.\npackage test\n"), 0o600)
+		require.NoError(t, err, "Failed to create test file")
+
+		// test detection
+		isGenerated, err := isGeneratedFile(generatedFile, nil)
+		require.NoError(t, err, "Failed to check if file is generated")
+		assert.True(t, isGenerated, "Should detect generated file")
+	})
+
+	t.Run("ignores normal file", func(t *testing.T) {
+		// create a temporary file without the generated code marker
+		tempDir := t.TempDir()
+		normalFile := filepath.Join(tempDir, "normal.go")
+		err := os.WriteFile(normalFile, []byte("package test\n\nfunc main() {}\n"), 0o600)
+		require.NoError(t, err, "Failed to create test file")
+
+		// test detection
+		isGenerated, err := isGeneratedFile(normalFile, nil)
+		require.NoError(t, err, "Failed to check if file is generated")
+		assert.False(t, isGenerated, "Should not identify normal file as generated")
+	})
+
+	t.Run("handles empty file", func(t *testing.T) {
+		// create an empty file
+		tempDir := t.TempDir()
+		emptyFile := filepath.Join(tempDir, "empty.go")
+		err := os.WriteFile(emptyFile, []byte(""), 0o600)
+		require.NoError(t, err, "Failed to create empty test file")
+
+		// test detection
+		isGenerated, err := isGeneratedFile(emptyFile, nil)
+		require.NoError(t, err, "Should not error on empty file")
+		assert.False(t, isGenerated, "Empty file should not be identified as generated")
+	})
+
+	t.Run("handles nonexistent file", func(t *testing.T) {
+		// test with nonexistent file
+		_, err := isGeneratedFile("/nonexistent/path/file.go", nil)
+		assert.Error(t, err, "Should return error for nonexistent file")
+	})
+}
+
+// TestShouldSkip tests the shouldSkip function
+func TestShouldSkip(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		skipPatterns []string
+		expected     bool
+	}{
+		{
+			name:         "no skip patterns",
+			path:         "/some/path/file.go",
+			skipPatterns: []string{},
+			expected:     false,
+		},
+		{
+			name:         "exact match",
+			path:         "/some/path/file.go",
+			skipPatterns: []string{"/some/path/file.go"},
+			expected:     true,
+		},
+		{
+			name:         "directory match",
+			path:         "/some/path/file.go",
+			skipPatterns: []string{"/some/path"},
+			expected:     true,
+		},
+		{
+			name:         "glob pattern match",
+			path:         "/some/path/file.go",
+			skipPatterns: []string{"*.go"},
+			expected:     true,
+		},
+		{
+			name:         "no match",
+			path:         "/some/path/file.go",
+			skipPatterns: []string{"/other/path", "*.txt"},
+			expected:     false,
+		},
+		{
+			name:         "multiple patterns with match",
+			path:         "/some/path/file.go",
+			skipPatterns: []string{"/other/path", "*.go"},
+			expected:     true,
+		},
+		{
+			name:         "invalid glob pattern",
+			path:         "/some/path/file.go",
+			skipPatterns: []string{"[invalid"},
+			expected:     false, // should not match with invalid pattern
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := shouldSkip(test.path, test.skipPatterns)
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
+
+// TestIncludePatterns tests that only files matching include patterns are processed, unless skipped
+func TestIncludePatterns(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	content := "package test\n\nfunc Example() {\n\t// Some Comment\n}\n"
+	for _, name := range []string{"user_handler.go", "user.go", filepath.Join("api", "order_handler.go"),
+		filepath.Join("api", "order.go"), filepath.Join("legacy", "old_handler.go")} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0o750))
+		require.NoError(t, os.WriteFile(name, []byte(content), 0o600))
+	}
+
+	tbl := []struct {
+		name             string
+		pattern          string
+		include, skip    []string
+		expectedAnalyzed int
+	}{
+		{name: "no include patterns", pattern: "./...", expectedAnalyzed: 5},
+		{name: "base name glob", pattern: "./...", include: []string{"*_handler.go"}, expectedAnalyzed: 3},
+		{name: "directory", pattern: "./...", include: []string{"api"}, expectedAnalyzed: 2},
+		{name: "any of patterns", pattern: "./...", include: []string{"api", "user.go"}, expectedAnalyzed: 3},
+		{name: "skip wins", pattern: "./...", include: []string{"*_handler.go"}, skip: []string{"legacy"}, expectedAnalyzed: 2},
+		{name: "non-recursive pattern", pattern: "*.go", include: []string{"*_handler.go"}, expectedAnalyzed: 1},
+		{name: "nothing matches", pattern: "./...", include: []string{"*_test.go"}, expectedAnalyzed: 0},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &ProcessRequest{OutputMode: "diff", TitleCase: true, IncludePatterns: tt.include, SkipPatterns: tt.skip}
+			processPatterns([]string{tt.pattern}, req, OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+			assert.Equal(t, tt.expectedAnalyzed, req.FilesAnalyzed)
+		})
+	}
+}
+
+// TestProcessPatternWithSkip tests the skip functionality in file processing
+func TestProcessPatternWithSkip(t *testing.T) {
+	// create a temporary directory structure for tests
+	tempDir := t.TempDir()
+
+	// create subdirectories
+	subDir1 := filepath.Join(tempDir, "dir1")
+	subDir2 := filepath.Join(tempDir, "dir2")
+	err := os.Mkdir(subDir1, 0o750)
+	require.NoError(t, err, "Failed to create subdirectory 1")
+	err = os.Mkdir(subDir2, 0o750)
+	require.NoError(t, err, "Failed to create subdirectory 2")
+
+	// create test go files with comments
+	files := map[string]string{
+		filepath.Join(tempDir, "root.go"):      "package main\n\nfunc Root() {\n\t// THIS COMMENT\n}\n",
+		filepath.Join(subDir1, "file1.go"):     "package dir1\n\nfunc Dir1() {\n\t// ANOTHER COMMENT\n}\n",
+		filepath.Join(subDir2, "file2.go"):     "package dir2\n\nfunc Dir2() {\n\t// THIRD COMMENT\n}\n",
+		filepath.Join(tempDir, "skip_this.go"): "package main\n\nfunc Skip() {\n\t// SKIPPED COMMENT\n}\n",
+	}
+
+	for path, content := range files {
+		err := os.WriteFile(path, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to create test file: "+path)
+	}
+
+	// change to temp dir
+	t.Chdir(tempDir)
+
+	t.Run("skip specific file", func(t *testing.T) {
+		// reset files
+		for path, content := range files {
+			relPath, err := filepath.Rel(tempDir, path)
+			require.NoError(t, err)
+			err = os.WriteFile(relPath, []byte(content), 0o600)
+			require.NoError(t, err)
+		}
+
+		// capture output using buffers
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process all files but skip one
+		req := ProcessRequest{
+			OutputMode:   "inplace",
+			TitleCase:    false,
+			Format:       false,
+			SkipPatterns: []string{"skip_this.go"},
+		}
+		processPattern(".", &req, writers)
+
+		// verify output
+		output := stdoutBuf.String()
+		assert.Contains(t, output, "Updated:", "Should show files were updated")
+
+		// check skipped file was not modified
+		content, err := os.ReadFile("skip_this.go")
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "// SKIPPED COMMENT", "Skipped file should not be modified")
+
+		// check other files were modified
+		content, err = os.ReadFile("root.go")
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "// this comment", "Non-skipped file should be modified")
+	})
+
+	t.Run("skip directory", func(t *testing.T) {
+		// reset files
+		for path, content := range files {
+			relPath, err := filepath.Rel(tempDir, path)
+			require.NoError(t, err)
+			err = os.WriteFile(relPath, []byte(content), 0o600)
+			require.NoError(t, err)
+		}
+
+		// capture output using buffers
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process recursively but skip dir1
+		req := ProcessRequest{
+			OutputMode:   "inplace",
+			TitleCase:    false,
+			Format:       false,
+			SkipPatterns: []string{"dir1"},
+		}
+		processPattern("./...", &req, writers)
+
+		// check dir1 file was not modified
+		content, err := os.ReadFile(filepath.Join("dir1", "file1.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "// ANOTHER COMMENT", "File in skipped directory should not be modified")
+
+		// check dir2 file was modified
+		content, err = os.ReadFile(filepath.Join("dir2", "file2.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "// third comment", "File in non-skipped directory should be modified")
+	})
+
+	t.Run("processFile on nonexistent file", func(t *testing.T) {
+		// create a non-existent file path
+		nonexistentFile := filepath.Join(tempDir, "does-not-exist.go")
+
+		// capture output using buffers
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// try to process a non-existent file
+		processFile(nonexistentFile, &ProcessRequest{OutputMode: "inplace"}, writers)
+
+		// verify error message
+		errOutput := stderrBuf.String()
+		assert.Contains(t, errOutput, "Error checking if file is generated", "Should report parsing error")
+	})
+}
+
+// TestVendorAndTestdataExclusion tests that vendor and testdata directories are automatically excluded
+func TestVendorAndTestdataExclusion(t *testing.T) {
+	// create a temporary directory structure for tests
+	tempDir := t.TempDir()
+
+	// create directories
+	rootDir := filepath.Join(tempDir, "root")
+	vendorDir := filepath.Join(rootDir, "vendor")
+	testdataDir := filepath.Join(rootDir, "testdata")
+	normalDir := filepath.Join(rootDir, "normal")
+
+	for _, dir := range []string{rootDir, vendorDir, testdataDir, normalDir} {
+		err := os.MkdirAll(dir, 0o750)
+		require.NoError(t, err, "Failed to create directory: "+dir)
+	}
+
+	// create test go files with comments
+	files := map[string]string{
+		filepath.Join(rootDir, "root.go"):         "package main\n\nfunc Root() {\n\t// THIS ROOT COMMENT\n}\n",
+		filepath.Join(vendorDir, "vendor.go"):     "package vendor\n\nfunc Vendor() {\n\t// THIS VENDOR COMMENT\n}\n",
+		filepath.Join(testdataDir, "testdata.go"): "package testdata\n\nfunc TestData() {\n\t// THIS TESTDATA COMMENT\n}\n",
+		filepath.Join(normalDir, "normal.go"):     "package normal\n\nfunc Normal() {\n\t// THIS NORMAL COMMENT\n}\n",
+	}
+
+	for path, content := range files {
+		err := os.WriteFile(path, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to create test file: "+path)
+	}
+
+	// change to root dir
+	t.Chdir(rootDir)
+
+	// capture output using buffer writers
+	var stdoutBuf, stderrBuf bytes.Buffer
+	writers := OutputWriters{
+		Stdout: &stdoutBuf,
+		Stderr: &stderrBuf,
+	}
+
+	// test case 1: recursive pattern (./...)
+	req := ProcessRequest{
+		OutputMode:   "inplace",
+		TitleCase:    false,
+		Format:       false,
+		SkipPatterns: []string{},
+	}
+	processPattern("./...", &req, writers)
+
+	// check that files in root and normal directories were processed
+	content, err := os.ReadFile("root.go")
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "// this root comment",
+		"Root directory file should be processed")
+
+	content, err = os.ReadFile(filepath.Join("normal", "normal.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "// this normal comment",
+		"Normal directory file should be processed")
+
+	// check that vendor directory files were NOT processed (should retain uppercase)
+	content, err = os.ReadFile(filepath.Join("vendor", "vendor.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "// THIS VENDOR COMMENT",
+		"Vendor directory file should NOT be processed")
+
+	// check that testdata directory files were NOT processed (should retain uppercase)
+	content, err = os.ReadFile(filepath.Join("testdata", "testdata.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "// THIS TESTDATA COMMENT",
+		"Testdata directory file should NOT be processed")
+
+	// test case 2: non-recursive pattern with vendor file
+	// reset the files to uppercase
+	for path, content := range files {
+		err := os.WriteFile(path, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to reset test file: "+path)
+	}
+
+	// process vendor file directly
+	processPattern(filepath.Join("vendor", "vendor.go"), &req, writers)
+
+	// verify vendor file was NOT processed
+	content, err = os.ReadFile(filepath.Join("vendor", "vendor.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "// THIS VENDOR COMMENT",
+		"Vendor file should NOT be processed when specified directly")
+
+	// test case 3: non-recursive pattern with testdata file
+	// process testdata file directly
+	processPattern(filepath.Join("testdata", "testdata.go"), &req, writers)
+
+	// verify testdata file was NOT processed
+	content, err = os.ReadFile(filepath.Join("testdata", "testdata.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "// THIS TESTDATA COMMENT",
+		"Testdata file should NOT be processed when specified directly")
+}
+
+// TestHiddenExclusion tests skipping hidden files and directories, unless --no-skip-hidden is set
+func TestHiddenExclusion(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		filepath.Join(tempDir, "root.go"):                "package main\n\nfunc Root() {\n\t// Root Comment\n}\n",
+		filepath.Join(tempDir, ".hidden", "foo.go"):      "package hidden\n\nfunc Foo() {\n\t// Hidden Comment\n}\n",
+		filepath.Join(tempDir, "normal", ".dotfile.go"):  "package normal\n\nfunc Dot() {\n\t// Dotfile Comment\n}\n",
+		filepath.Join(tempDir, "normal", "normal.go"):    "package normal\n\nfunc Normal() {\n\t// Normal Comment\n}\n",
+		filepath.Join(tempDir, ".config", "sub", "x.go"): "package sub\n\nfunc X() {\n\t// Nested Comment\n}\n",
+	}
+	write := func() {
+		for path, content := range files {
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		}
+	}
+	t.Chdir(tempDir)
+
+	t.Run("hidden skipped by default", func(t *testing.T) {
+		write()
+		var stdoutBuf, stderrBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "inplace", SkipHidden: true}
+		processPattern("./...", req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Equal(t, 2, req.FilesAnalyzed)
+
+		for _, path := range []string{"root.go", filepath.Join("normal", "normal.go")} {
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.NotContains(t, string(content), "// R", path)
+			assert.NotContains(t, string(content), "// N", path)
+		}
+		for _, path := range []string{filepath.Join(".hidden", "foo.go"), filepath.Join("normal", ".dotfile.go"),
+			filepath.Join(".config", "sub", "x.go")} {
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, files[filepath.Join(tempDir, path)], string(content), path)
+		}
+	})
+
+	t.Run("hidden walked with skipping disabled", func(t *testing.T) {
+		write()
+		var stdoutBuf, stderrBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "inplace"}
+		processPattern("./...", req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Equal(t, 5, req.FilesAnalyzed)
+
+		content, err := os.ReadFile(filepath.Join(".hidden", "foo.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "// hidden comment")
+	})
+
+	t.Run("hidden walk root is processed", func(t *testing.T) {
+		write()
+		var stdoutBuf, stderrBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "inplace", SkipHidden: true}
+		processPattern(".config/...", req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Equal(t, 1, req.FilesAnalyzed)
+	})
+}
+
+// TestWithSampleFile tests the tool against a sample file
+func TestWithSampleFile(t *testing.T) {
+	// create a temporary directory for tests
+	tempDir := t.TempDir()
+
+	// create a sample file for testing
+	samplePath := filepath.Join(tempDir, "sample.go")
+	sampleContent := `package sample
+
+// Remote executes commands on remote server
+// This comment should NOT be converted
+type Remote struct {
+	// comment inside struct - should now be converted
+	Addr string
+	
+	// TODO This comment should remain unchanged
+	User string
+	
+	// FIXME This comment should remain unchanged
+	Password string
+}
+
+func NewRemote() *Remote {
+	// THIS FUNCTION is not implemented yet
+	return &Remote{}
+}
+
+// This comment should NOT be converted
+func (ex *Remote) Execute(cmd string) error {
+	// TODO IMPLEMENT ME - this comment should remain unchanged
+	// ANOTHER Strange function I need to fix
+	return nil
+}
+
+func (ex *Remote) Close() error {
+	x := 1 // inline comment that should be converted
+	
+	/*
+	 * This is a multi-line comment
+	 * that should be converted
+	 */
+	 
+	if true {
+		// this is another nested comment
+		for i := 0; i < 10; i++ {
+			// comment in for loop should be converted
+		}
+	}
+	
+	// ALL CAPS COMMENT should be converted
+	return nil
+}`
+
+	err := os.WriteFile(samplePath, []byte(sampleContent), 0o600)
+	require.NoError(t, err, "Failed to write sample file")
+
+	// test different modes with the sample file
+	t.Run("process sample file in lowercase mode", func(t *testing.T) {
+		// reset file before test
+		err = os.WriteFile(samplePath, []byte(sampleContent), 0o600)
+		require.NoError(t, err, "Failed to reset sample file")
+
+		// capture output using buffers
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process file in inplace mode
+		processFile(samplePath, &ProcessRequest{OutputMode: "inplace"}, writers)
+
+		// read the processed file
+		processedContent, err := os.ReadFile(samplePath)
+		require.NoError(t, err, "Failed to read processed file")
+		processedStr := string(processedContent)
+
+		// verify function comment outside function is not converted
+		assert.Contains(t, processedStr, "// Remote executes commands on remote server",
+			"Comment outside function should not be converted")
+		assert.Contains(t, processedStr, "// This comment should NOT be converted",
+			"Comment outside function should not be converted")
+
+		// verify comments inside struct are converted (new behavior)
+		assert.Contains(t, processedStr, "// comment inside struct - should now be converted",
+			"Comments inside struct should be converted")
+
+		// verify special indicators in struct comments are preserved
+		assert.Contains(t, processedStr, "// TODO This comment should remain unchanged",
+			"TODO comments should remain completely unchanged")
+		assert.Contains(t, processedStr, "// FIXME This comment should remain unchanged",
+			"FIXME comments should remain completely unchanged")
+
+		// verify comments inside function work correctly with special indicators
+		assert.Contains(t, processedStr, "// TODO IMPLEMENT ME - this comment should remain unchanged",
+			"TODO comments should remain unchanged even inside functions")
+		assert.Contains(t, processedStr, "// this function is not implemented yet",
+			"Comment inside function should be converted to lowercase")
+		assert.Contains(t, processedStr, "// another strange function",
+			"Comment inside function should be converted to lowercase")
+
+		// verify inline comments are converted
+		assert.Contains(t, processedStr, "// inline comment that should be converted",
+			"Inline comment should be converted")
+
+		// verify nested comments are converted
+		assert.Contains(t, processedStr, "// this is another nested comment",
+			"Nested comment should be converted")
+		assert.Contains(t, processedStr, "// comment in for loop should be converted",
+			"Comment in loop should be converted")
+
+		// verify other functions' comments are converted
+		assert.Contains(t, processedStr, "// all caps comment should be converted",
+			"Comment in another function should be converted")
+	})
+}
+
+// TestPreviewLimit tests that diff output is capped by --preview-limit
+func TestPreviewLimit(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		content := "package test\n\nfunc Example() {\n\t// THIS COMMENT in " + name + "\n}\n"
+		err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o600)
+		require.NoError(t, err)
+	}
+	t.Chdir(tempDir)
+
+	t.Run("limited", func(t *testing.T) {
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+		req := ProcessRequest{OutputMode: "diff", PreviewLimit: 1}
+		processPattern(".", &req, writers)
+		printSummary(&req, writers)
+
+		output := stdoutBuf.String()
+		assert.Equal(t, 1, strings.Count(output, "(original)"), "only one diff should be shown")
+		assert.Equal(t, 2, req.FilesNotShown)
+		assert.Equal(t, 3, req.FilesUpdated)
+		assert.Contains(t, output, "... and 2 more files changed")
+		assert.Contains(t, output, "3 files updated")
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+		req := ProcessRequest{OutputMode: "diff"}
+		processPattern(".", &req, writers)
+		printSummary(&req, writers)
+
+		output := stdoutBuf.String()
+		assert.Equal(t, 3, strings.Count(output, "(original)"))
+		assert.NotContains(t, output, "more files changed")
+	})
+}
+
+// TestParallelJobs tests that files processed by parallel workers give the same output and summary as sequential processing
+func TestParallelJobs(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	for i := range 20 {
+		dir := filepath.Join("pkg", fmt.Sprint(i%3))
+		require.NoError(t, os.MkdirAll(dir, 0o750))
+		content := fmt.Sprintf("package test\n\nfunc F%d() {\n\t// Comment Number %d\n\t// clean\n}\n", i, i)
+		if i%4 == 0 {
+			content = fmt.Sprintf("package test\n\nfunc F%d() {\n\t// clean %d\n}\n", i, i)
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%02d.go", i)), []byte(content), 0o600))
+	}
+
+	run := func(mode string, jobs int) (*ProcessRequest, string) {
+		var stdoutBuf, stderrBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: mode, TitleCase: true, Jobs: jobs}
+		processPatterns([]string{"./..."}, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Empty(t, stderrBuf.String())
+		return req, stdoutBuf.String()
+	}
+
+	for _, mode := range []string{"diff", "json", "style", "check"} {
+		t.Run(mode, func(t *testing.T) {
+			seqReq, seqOut := run(mode, 1)
+			parReq, parOut := run(mode, 4)
+			assert.Equal(t, seqOut, parOut, "output should be the same in the same order")
+			assert.Equal(t, 20, parReq.FilesAnalyzed)
+			assert.Equal(t, seqReq.FilesUpdated, parReq.FilesUpdated)
+			assert.Equal(t, seqReq.TotalChanges, parReq.TotalChanges)
+			assert.Equal(t, seqReq.CharsChanged, parReq.CharsChanged)
+			assert.Equal(t, seqReq.Changes, parReq.Changes)
+			assert.Equal(t, seqReq.StyleCounts, parReq.StyleCounts)
+		})
+	}
+
+	t.Run("inplace", func(t *testing.T) {
+		var logBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "inplace", TitleCase: true, Jobs: 4, RewriteLog: &logBuf}
+		processPatterns([]string{"./..."}, req, OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		assert.Equal(t, 20, req.FilesAnalyzed)
+		assert.Equal(t, 15, req.FilesUpdated)
+		assert.Equal(t, 15, req.TotalChanges)
+		assert.Equal(t, 15, strings.Count(logBuf.String(), "\n"), "a log line per change")
+
+		res, err := os.ReadFile(filepath.Join("pkg", "1", "f13.go"))
+		require.NoError(t, err)
+		assert.Contains(t, string(res), "// comment Number 13")
+	})
+}
+
+// TestFailFast tests that --fail-fast stops at the first file with changes
+func TestFailFast(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		content := "package test\n\nfunc Example() {\n\t// THIS COMMENT in " + name + "\n}\n"
+		err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o600)
+		require.NoError(t, err)
+	}
+	t.Chdir(tempDir)
+
+	for _, pattern := range []string{".", "./..."} {
+		t.Run("diff mode "+pattern, func(t *testing.T) {
+			var stdoutBuf, stderrBuf bytes.Buffer
+			writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+			req := ProcessRequest{OutputMode: "diff", FailFast: true}
+			processPattern(pattern, &req, writers)
+
+			assert.Equal(t, 1, strings.Count(stdoutBuf.String(), "(original)"), "only the first file should be reported")
+			assert.Equal(t, 1, req.FilesAnalyzed)
+			assert.Equal(t, 1, req.FilesUpdated)
+			assert.True(t, req.failFastTriggered())
+		})
+	}
+
+	t.Run("check mode", func(t *testing.T) {
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+		req := ProcessRequest{OutputMode: "check", FailFast: true}
+		processPattern("./...", &req, writers)
+
+		assert.Equal(t, "a.go\n", stdoutBuf.String(), "only the first file should be listed")
+		assert.Equal(t, 1, req.FilesAnalyzed)
+		assert.True(t, req.failFastTriggered())
+		assert.True(t, req.failed())
+	})
+
+	t.Run("ignored in inplace mode", func(t *testing.T) {
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+		req := ProcessRequest{OutputMode: "inplace", FailFast: true}
+		processPattern(".", &req, writers)
+		assert.Equal(t, 3, req.FilesUpdated)
+		assert.False(t, req.failFastTriggered())
+	})
+}
+
+// TestStdinWithFiles tests processing files along with stdin ("-") in one invocation
+func TestStdinWithFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	fileContent := "package test\n\nfunc File() {\n\t// FILE Comment\n}\n"
+	err := os.WriteFile(filepath.Join(tempDir, "file.go"), []byte(fileContent), 0o600)
+	require.NoError(t, err)
+	t.Chdir(tempDir)
+
+	stdinContent := "package test\n\nfunc Stdin() {\n\t// Stdin Comment\n}\n"
+
+	t.Run("inplace files and stdin", func(t *testing.T) {
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+		req := ProcessRequest{OutputMode: "inplace", Stdin: strings.NewReader(stdinContent)}
+		processPatterns([]string{"file.go", "-"}, &req, writers)
+
+		assert.Equal(t, "package test\n\nfunc Stdin() {\n\t// stdin comment\n}\n", stdoutBuf.String(),
+			"stdout should contain only the processed stdin content")
+		assert.Contains(t, stderrBuf.String(), "Updated: file.go")
+		assert.Contains(t, stderrBuf.String(), "2 files analyzed, 2 files updated, 2 total changes")
+
+		res, err := os.ReadFile("file.go")
+		require.NoError(t, err)
+		assert.Contains(t, string(res), "// file comment")
+	})
+
+	t.Run("unchanged stdin passed through", func(t *testing.T) {
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+		src := "package test\n\nfunc Stdin() {\n  // already lowercase\n}"
+		req := ProcessRequest{OutputMode: "inplace", Stdin: strings.NewReader(src)}
+		processPatterns([]string{"-"}, &req, writers)
+		assert.Equal(t, src, stdoutBuf.String())
+	})
+
+	t.Run("unparsable stdin passed through", func(t *testing.T) {
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+		req := ProcessRequest{OutputMode: "inplace", Stdin: strings.NewReader("not go code")}
+		processPatterns([]string{"-"}, &req, writers)
+		assert.Equal(t, "not go code", stdoutBuf.String())
+		assert.Contains(t, stderrBuf.String(), "Error parsing <stdin>")
+	})
+
+	t.Run("count mode", func(t *testing.T) {
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+		req := ProcessRequest{OutputMode: "count", Stdin: strings.NewReader(stdinContent)}
+		processPatterns([]string{"-"}, &req, writers)
+		assert.Equal(t, "1\n", stdoutBuf.String())
+	})
+}
+
+// TestPackageFilter tests that --package restricts processing to files of the given package
+func TestPackageFilter(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"foo.go":      "package foo\n\nfunc Foo() {\n\t// Foo Comment\n}\n",
+		"foo_test.go": "package foo_test\n\nfunc TestFoo() {\n\t// Test Comment\n}\n",
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o600)
+		require.NoError(t, err)
+	}
+	t.Chdir(tempDir)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	writers := OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}
+	req := ProcessRequest{OutputMode: "inplace", PackageName: "foo_test"}
+	processPattern(".", &req, writers)
+	assert.Equal(t, 1, req.FilesUpdated)
+	assert.Equal(t, 1, req.FilesAnalyzed, "files of other packages are not counted")
+
+	res, err := os.ReadFile("foo_test.go")
+	require.NoError(t, err)
+	assert.Contains(t, string(res), "// test comment", "targeted package should change")
+	res, err = os.ReadFile("foo.go")
+	require.NoError(t, err)
+	assert.Equal(t, files["foo.go"], string(res), "other package should not change")
+
+	t.Run("explicit files", func(t *testing.T) {
+		req := ProcessRequest{OutputMode: "diff", PackageName: "foo"}
+		processPatterns([]string{"foo.go", "foo_test.go"}, &req, OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		assert.Equal(t, 1, req.FilesAnalyzed)
+		assert.Equal(t, 1, req.FilesUpdated)
+	})
+}
+
+// TestCheckMode tests that check mode lists files with comments to fix and fails without modifying files
+func TestCheckMode(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	content := "package test\n\nfunc A() {\n\t// Some Comment\n}\n"
+	require.NoError(t, os.WriteFile("a.go", []byte(content), 0o600))
+	require.NoError(t, os.WriteFile("b.go", []byte("package test\n\nfunc B() {\n\t// clean comment\n}\n"), 0o600))
+	require.NoError(t, os.WriteFile("c.go", []byte("package test\n\nfunc C() {\n\t// Other Comment\n}\n"), 0o600))
+
+	t.Run("files with changes", func(t *testing.T) {
+		var stdoutBuf, stderrBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "check", TitleCase: true}
+		processPatterns([]string{"."}, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Equal(t, "a.go\nc.go\n", stdoutBuf.String(), "only offending files, no summary")
+		assert.Empty(t, stderrBuf.String())
+		assert.True(t, req.failed())
+
+		res, err := os.ReadFile("a.go")
+		require.NoError(t, err)
+		assert.Equal(t, content, string(res), "file should not be modified")
+	})
+
+	t.Run("clean files", func(t *testing.T) {
+		var stdoutBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "check", TitleCase: true}
+		processPatterns([]string{"b.go"}, req, OutputWriters{Stdout: &stdoutBuf, Stderr: io.Discard})
+		assert.Empty(t, stdoutBuf.String())
+		assert.False(t, req.failed())
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		var stdoutBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "check", TitleCase: true, Stdin: strings.NewReader(content)}
+		processPatterns([]string{"-", "b.go"}, req, OutputWriters{Stdout: &stdoutBuf, Stderr: io.Discard})
+		assert.Equal(t, "<stdin>\n", stdoutBuf.String())
+		assert.True(t, req.failed())
+	})
+}
+
+// TestStdinModes tests that stdin processing honors the active mode
+func TestStdinModes(t *testing.T) {
+	originalNoColor := color.NoColor
+	defer func() { color.NoColor = originalNoColor }()
+	color.NoColor = true
+
+	src := "package test\n\nfunc Example() {\n\t// Some Comment\n}\n"
+	modified := "package test\n\nfunc Example() {\n\t// some Comment\n}\n"
+	clean := "package test\n\nfunc Example() {\n\t// clean comment\n}\n"
+
+	tbl := []struct {
+		mode, input, expected string
+	}{
+		{mode: "inplace", input: src, expected: modified},
+		{mode: "print", input: src, expected: modified},
+		{mode: "print", input: clean, expected: clean},
+		{mode: "diff", input: src, expected: "--- <stdin> (original)\n+++ <stdin> (modified)\n- \t// Some Comment\n+ \t// some Comment\n"},
+		{mode: "diff", input: clean, expected: ""},
+		{mode: "diff", input: "not go code", expected: ""},
+		{mode: "count", input: src, expected: ""},
+		{mode: "github", input: src,
+			expected: "::warning file=<stdin>,line=4,col=2,title=unfuck-ai-comments::comment should be \"// some Comment\", not \"// Some Comment\"\n"},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.mode, func(t *testing.T) {
+			var stdoutBuf, stderrBuf bytes.Buffer
+			req := &ProcessRequest{OutputMode: tt.mode, TitleCase: true, Stdin: strings.NewReader(tt.input)}
+			processStdin(req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+			assert.Equal(t, tt.expected, stdoutBuf.String())
+		})
+	}
+
+	t.Run("full mode without files written", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		var stdoutBuf, stderrBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "inplace", Backup: true, Stdin: strings.NewReader(src)}
+		processPatterns([]string{stdinPattern}, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Equal(t, "package test\n\nfunc Example() {\n\t// some comment\n}\n", stdoutBuf.String())
+		entries, err := os.ReadDir(".")
+		require.NoError(t, err)
+		assert.Empty(t, entries, "no backups or other files are written for stdin")
+	})
+}
+
+// TestIgnoreGeneratedBy tests skipping files with banners of the named generators
+func TestIgnoreGeneratedBy(t *testing.T) {
+	tempDir := t.TempDir()
+	moqFile := filepath.Join(tempDir, "mock.go")
+	moqSrc := "//go:build !prod\n\n// Code generated by moq; DO NOT EDIT.\n// github.com/matryer/moq\n\npackage test\n\nfunc Mock() {\n\t// Some Comment\n}\n"
+	require.NoError(t, os.WriteFile(moqFile, []byte(moqSrc), 0o600))
+	oldMockgen := filepath.Join(tempDir, "old_mock.go")
+	require.NoError(t, os.WriteFile(oldMockgen, []byte("// Automatically generated by MockGen. DO NOT EDIT!\npackage test\n"), 0o600))
+	bodyMention := filepath.Join(tempDir, "body.go")
+	require.NoError(t, os.WriteFile(bodyMention,
+		[]byte("package test\n\n// Code generated by moq; DO NOT EDIT.\nfunc f() {}\n"), 0o600))
+
+	generated, err := isGeneratedFile(moqFile, nil)
+	require.NoError(t, err)
+	assert.False(t, generated, "banner is not on the first line")
+
+	patterns, err := generatorPatterns(splitList([]string{"moq, mockgen", "stringer"}))
+	require.NoError(t, err)
+	require.Len(t, patterns, 3)
+	generated, err = isGeneratedFile(moqFile, patterns)
+	require.NoError(t, err)
+	assert.True(t, generated, "moq banner found in the header")
+	generated, err = isGeneratedFile(oldMockgen, patterns)
+	require.NoError(t, err)
+	assert.True(t, generated)
+	generated, err = isGeneratedFile(bodyMention, patterns)
+	require.NoError(t, err)
+	assert.False(t, generated, "banners after the package clause are ignored")
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	req := &ProcessRequest{OutputMode: "inplace", GeneratedBy: patterns}
+	processFile(moqFile, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+	res, err := os.ReadFile(moqFile)
+	require.NoError(t, err)
+	assert.Equal(t, moqSrc, string(res))
+
+	_, err = generatorPatterns([]string{"unknown"})
+	require.EqualError(t, err, `unknown generator "unknown", known generators: mockgen, moq, protoc-gen-go, sqlc, stringer`)
+}
@@ -0,0 +1,215 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watch command checks pending changes with the interval and processes a file once it is unchanged
+// for the debounce delay
+const (
+	watchInterval = 250 * time.Millisecond
+	watchDebounce = 200 * time.Millisecond
+)
+
+// watchDirs watches Go files of the directories until the context is canceled and processes each saved file
+// once it stays unchanged for the debounce delay, printing the summary at the end. changes are reported by
+// watchChanges, so atomic saves of editors, writing a temporary file and renaming it over the original one,
+// are seen as a change of the original file
+func watchDirs(ctx context.Context, dirs []string, req *ProcessRequest, interval, debounce time.Duration,
+	writers OutputWriters) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	changes, watched, err := watchChanges(ctx, dirs, req, writers)
+	if err != nil {
+		writers.errorf("Error: %s\n", err)
+		return
+	}
+	writers.infof("Watching %d files in %s, press Ctrl+C to stop\n", watched, strings.Join(dirs, ", "))
+
+	pending := map[string]time.Time{} // changed files with the time of the last change seen
+	written := map[string]string{}    // hashes of files rewritten by the tool, this is not a change to process
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			printSummary(req, writers)
+			return
+		case path := <-changes:
+			pending[path] = time.Now()
+			continue
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		for _, path := range slices.Sorted(maps.Keys(pending)) {
+			if now.Sub(pending[path]) < debounce {
+				continue
+			}
+			delete(pending, path)
+			src, err := os.ReadFile(path)
+			if err != nil || written[path] == contentHash(src) {
+				continue // removed before it settled or saved by the tool
+			}
+			req.FilesAnalyzed++
+			if changes := processFile(path, req, writers); changes > 0 {
+				req.FilesUpdated++
+				req.TotalChanges += changes
+			}
+			if src, err := os.ReadFile(path); err == nil {
+				written[path] = contentHash(src)
+			}
+		}
+	}
+}
+
+// dirWatcher reports changed Go files of the watched directories
+type dirWatcher struct {
+	fsw     *fsnotify.Watcher
+	roots   map[string]string // roots of the walk by watched directories
+	req     *ProcessRequest
+	writers OutputWriters
+}
+
+// watchChanges reports Go files of the directories changed after the call until the context is canceled,
+// with the number of files watched. changes come from file system events of the directories, directories
+// created later are watched as they appear
+func watchChanges(ctx context.Context, dirs []string, req *ProcessRequest,
+	writers OutputWriters) (<-chan string, int, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, 0, fmt.Errorf("init watcher: %w", err)
+	}
+
+	w := &dirWatcher{fsw: fsw, roots: map[string]string{}, req: req, writers: writers}
+	watched := 0
+	for _, dir := range dirs {
+		files, err := w.addTree(dir, dir)
+		if err != nil {
+			_ = fsw.Close()
+			return nil, 0, err
+		}
+		watched += len(files)
+	}
+
+	res := make(chan string)
+	go func() {
+		defer func() { _ = fsw.Close() }()
+		w.read(ctx, res)
+	}()
+	return res, watched, nil
+}
+
+// addTree watches the directory at path and its subdirectories, skipping directories as the walk
+// from the root does. Go files selected by the walk are returned
+func (w *dirWatcher) addTree(root, path string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		// directories removed during the walk, like temporary directories of tools, are gone already
+		if errors.Is(err, fs.ErrNotExist) && p != path {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			if w.req.walkSelected(root, p) {
+				files = append(files, p)
+			}
+			return nil
+		}
+		if w.req.skipDir(root, p, w.writers) {
+			return filepath.SkipDir
+		}
+		if err := w.fsw.Add(p); err != nil {
+			return fmt.Errorf("watch directory %s: %w", p, err)
+		}
+		w.roots[p] = root
+		return nil
+	})
+	return files, err
+}
+
+// read sends Go files written, created or renamed into the watched directories to res until the context
+// is canceled. files of a new directory are sent as well, they could be saved before the directory was watched
+func (w *dirWatcher) read(ctx context.Context, res chan<- string) {
+	send := func(path string) bool {
+		select {
+		case res <- path:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				w.writers.warnf("Too many file events, some saved files are missed\n")
+				continue
+			}
+			w.writers.errorf("Error reading file events: %v\n", err)
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			for _, path := range w.changedFiles(ev) {
+				if !send(path) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// changedFiles returns Go files changed by the event. a created or renamed directory is watched
+// and its files are returned
+func (w *dirWatcher) changedFiles(ev fsnotify.Event) []string {
+	if ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+		delete(w.roots, ev.Name) // the watch of a removed directory is dropped by fsnotify
+		return nil
+	}
+	if !ev.Has(fsnotify.Create) && !ev.Has(fsnotify.Write) {
+		return nil
+	}
+	root, ok := w.roots[filepath.Dir(ev.Name)]
+	if !ok {
+		return nil
+	}
+
+	info, err := os.Lstat(ev.Name)
+	if err != nil {
+		return nil // removed already
+	}
+	if !info.IsDir() {
+		if w.req.walkSelected(root, ev.Name) {
+			return []string{ev.Name}
+		}
+		return nil
+	}
+	if !ev.Has(fsnotify.Create) {
+		return nil
+	}
+	files, err := w.addTree(root, ev.Name)
+	if err != nil {
+		w.writers.errorf("Error: %s\n", err)
+	}
+	return files
+}
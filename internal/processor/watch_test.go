@@ -0,0 +1,97 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWatchDirs tests processing of saved files by the watch command
+func TestWatchDirs(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "vendor"), 0o750))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "skipped"), 0o750))
+	content := "package test\n\nfunc Example() {\n\t// Some Comment\n}\n"
+	files := map[string]string{
+		"edited.go":      filepath.Join(tempDir, "edited.go"),
+		"atomic.go":      filepath.Join(tempDir, "atomic.go"),
+		"untouched.go":   filepath.Join(tempDir, "untouched.go"),
+		"vendor.go":      filepath.Join(tempDir, "vendor", "vendor.go"),
+		"skipped.go":     filepath.Join(tempDir, "skipped", "skipped.go"),
+		"notes.txt":      filepath.Join(tempDir, "notes.txt"),
+		"created.go":     filepath.Join(tempDir, "created.go"),
+		"atomic.go.tmp~": filepath.Join(tempDir, "atomic.go.tmp~"),
+		"new.go":         filepath.Join(tempDir, "newdir", "new.go"),
+	}
+	for _, name := range []string{"edited.go", "atomic.go", "untouched.go", "vendor.go", "skipped.go", "notes.txt"} {
+		require.NoError(t, os.WriteFile(files[name], []byte(content), 0o600))
+	}
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	req := &ProcessRequest{OutputMode: "inplace", TitleCase: true, SkipPatterns: []string{"skipped"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watchDirs(ctx, []string{tempDir}, req, 10*time.Millisecond, 30*time.Millisecond,
+			OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond) // let the watcher see the initial state
+
+	// saved in place, as a new file and with an atomic rename of a temporary file
+	saved := strings.Replace(content, "}", "\t// Saved Comment\n}", 1)
+	for _, name := range []string{"edited.go", "created.go", "vendor.go", "skipped.go", "notes.txt"} {
+		require.NoError(t, os.WriteFile(files[name], []byte(saved), 0o600))
+	}
+	require.NoError(t, os.WriteFile(files["atomic.go.tmp~"], []byte(saved), 0o600))
+	require.NoError(t, os.Rename(files["atomic.go.tmp~"], files["atomic.go"]))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "newdir"), 0o750))
+	time.Sleep(50 * time.Millisecond) // let the watcher see the new directory
+	require.NoError(t, os.WriteFile(files["new.go"], []byte(saved), 0o600))
+
+	processed := func(name string) bool {
+		res, err := os.ReadFile(files[name])
+		return err == nil && strings.Contains(string(res), "// saved Comment")
+	}
+	assert.Eventually(t, func() bool {
+		return processed("edited.go") && processed("created.go") && processed("atomic.go") && processed("new.go")
+	}, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond) // give the watcher a chance to process excluded files by mistake
+	cancel()
+	<-done
+
+	for _, name := range []string{"vendor.go", "skipped.go", "notes.txt"} {
+		assert.False(t, processed(name), "%s is not processed", name)
+	}
+	res, err := os.ReadFile(files["untouched.go"])
+	require.NoError(t, err)
+	assert.Equal(t, content, string(res), "file not saved is not processed")
+
+	assert.Empty(t, stderrBuf.String())
+	assert.Contains(t, stdoutBuf.String(), "Watching 3 files in "+tempDir)
+	for _, name := range []string{"edited.go", "created.go", "atomic.go", "new.go"} {
+		assert.Equal(t, 1, strings.Count(stdoutBuf.String(), "Updated: "+files[name]), "%s is processed once", name)
+	}
+	assert.Equal(t, 4, req.FilesAnalyzed)
+	assert.Equal(t, 4, req.FilesUpdated)
+	assert.Equal(t, 8, req.TotalChanges)
+	assert.Contains(t, stdoutBuf.String(), "Summary: 4 files analyzed, 4 files updated, 8 total changes")
+
+	t.Run("processing mode", func(t *testing.T) {
+		opts := Options{DryRun: true}
+		opts.Watch.Args.Dirs = []string{"dir1", "dir2"}
+		p := flags.NewParser(&opts, flags.Default)
+		p.Active = p.Find("watch")
+		require.NotNil(t, p.Active)
+		assert.Equal(t, ProcessingResult{Mode: "inplace", Patterns: []string{"dir1", "dir2"}, Watch: true},
+			determineProcessingMode(opts, p))
+	})
+}
@@ -0,0 +1,91 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// processWorkspace finds Go modules in the workspace roots and processes each module separately,
+// printing a summary per module and the total summary. files of nested modules belong to those modules only.
+// moduleRequest builds the request of a module from its own config, the request is used for all modules if nil
+func processWorkspace(roots []string, req *ProcessRequest, moduleRequest func(dir string) (ProcessRequest, error),
+	writers OutputWriters) {
+	for _, root := range roots {
+		modules, err := findModules(root, req.SkipHidden)
+		if err != nil {
+			writers.errorf("Error searching for modules in %s: %v\n", root, err)
+			continue
+		}
+		if len(modules) == 0 {
+			writers.warnf("No Go modules found in %s\n", root)
+			continue
+		}
+
+		for _, module := range modules {
+			// each module gets its own request with fresh statistics
+			modReq := *req
+			if moduleRequest != nil {
+				var err error
+				if modReq, err = moduleRequest(module); err != nil {
+					writers.errorf("Error in module %s: %v\n", module, err)
+					continue
+				}
+				modReq.Changes, modReq.StyleCounts, modReq.ExportedComments = req.Changes, req.StyleCounts, req.ExportedComments
+				modReq.OutputMode, modReq.FailFast = req.OutputMode, req.FailFast // the same for all modules, like for a pre-pass
+			}
+			modReq.FilesAnalyzed, modReq.FilesUpdated, modReq.TotalChanges = 0, 0, 0
+			modReq.CharsChanged, modReq.FilesNotShown, modReq.FilesFailed = 0, 0, 0
+			modReq.SkipNestedModules = true
+			walkDir(module, &modReq, writers)
+
+			if !req.machineOutput() {
+				fmt.Fprintf(writers.Stdout, "%s: %d files analyzed, %d files updated, %d total changes\n",
+					module, modReq.FilesAnalyzed, modReq.FilesUpdated, modReq.TotalChanges)
+			}
+
+			req.FilesAnalyzed += modReq.FilesAnalyzed
+			req.FilesUpdated += modReq.FilesUpdated
+			req.TotalChanges += modReq.TotalChanges
+			req.CharsChanged += modReq.CharsChanged
+			req.FilesNotShown += modReq.FilesNotShown
+			req.FilesFailed += modReq.FilesFailed
+			// module requests start with the collected changes, style counts and comments and extend them
+			req.Changes, req.StyleCounts, req.ExportedComments = modReq.Changes, modReq.StyleCounts, modReq.ExportedComments
+			if req.failFastTriggered() {
+				break
+			}
+		}
+		if req.failFastTriggered() {
+			break
+		}
+	}
+
+	if req.OutputMode != "print" && req.OutputMode != "github" && req.OutputMode != "jsonl" && req.OutputMode != "check" {
+		printSummary(req, writers)
+	}
+}
+
+// findModules returns directories with a go.mod file under the root, skipping vendor, testdata
+// and optionally hidden directories
+func findModules(root string, skipHidden bool) ([]string, error) {
+	var res []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && (d.Name() == "vendor" || d.Name() == "testdata" ||
+			(skipHidden && strings.HasPrefix(d.Name(), "."))) {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+			res = append(res, path)
+		}
+		return nil
+	})
+	return res, err
+}
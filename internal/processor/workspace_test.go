@@ -0,0 +1,111 @@
+package processor
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProcessWorkspace tests processing each module of a workspace separately
+func TestProcessWorkspace(t *testing.T) {
+	tempDir := t.TempDir()
+	content := "package test\n\nfunc Example() {\n\t// Some Comment\n}\n"
+	files := []string{
+		filepath.Join("svc", "go.mod"), filepath.Join("svc", "main.go"), filepath.Join("svc", "pkg", "pkg.go"),
+		filepath.Join("svc", "tools", "go.mod"), filepath.Join("svc", "tools", "tools.go"),
+		filepath.Join("lib", "go.mod"), filepath.Join("lib", "lib.go"),
+		filepath.Join("lib", "vendor", "dep", "go.mod"), filepath.Join("lib", "vendor", "dep", "dep.go"),
+		filepath.Join("scripts", "script.go"),
+	}
+	for _, file := range files {
+		path := filepath.Join(tempDir, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	modules, err := findModules(tempDir, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(tempDir, "lib"), filepath.Join(tempDir, "svc"),
+		filepath.Join(tempDir, "svc", "tools")}, modules)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	req := &ProcessRequest{OutputMode: "inplace", TitleCase: true}
+	processWorkspace([]string{tempDir}, req, nil, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+
+	out := stdoutBuf.String()
+	assert.Contains(t, out, filepath.Join(tempDir, "lib")+": 1 files analyzed, 1 files updated, 1 total changes\n")
+	assert.Contains(t, out, filepath.Join(tempDir, "svc")+": 2 files analyzed, 2 files updated, 2 total changes\n")
+	assert.Contains(t, out, filepath.Join(tempDir, "svc", "tools")+": 1 files analyzed, 1 files updated, 1 total changes\n")
+	assert.Contains(t, out, "Summary: 4 files analyzed, 4 files updated, 4 total changes\n")
+
+	for _, file := range []string{filepath.Join("lib", "vendor", "dep", "dep.go"), filepath.Join("scripts", "script.go")} {
+		res, err := os.ReadFile(filepath.Join(tempDir, file))
+		require.NoError(t, err)
+		assert.Contains(t, string(res), "// Some Comment", file+" is not part of any module")
+	}
+
+	t.Run("no modules", func(t *testing.T) {
+		stdoutBuf.Reset()
+		stderrBuf.Reset()
+		processWorkspace([]string{filepath.Join(tempDir, "scripts")}, &ProcessRequest{OutputMode: "diff"}, nil,
+			OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Contains(t, stderrBuf.String(), "No Go modules found in "+filepath.Join(tempDir, "scripts"))
+		assert.Contains(t, stdoutBuf.String(), "Summary: 0 files analyzed")
+	})
+
+	t.Run("config per module", func(t *testing.T) {
+		root := t.TempDir()
+		for _, mod := range []string{"full", "title"} {
+			require.NoError(t, os.MkdirAll(filepath.Join(root, mod), 0o750))
+			require.NoError(t, os.WriteFile(filepath.Join(root, mod, "go.mod"), []byte("module "+mod+"\n"), 0o600))
+			require.NoError(t, os.WriteFile(filepath.Join(root, mod, "main.go"), []byte(content), 0o600))
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(root, "full", ".unfuck.yml"), []byte("full: true\n"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(root, "title", ".unfuck.yml"), []byte("skip: [skipped]\n"), 0o600))
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "title", "skipped"), 0o750))
+		require.NoError(t, os.WriteFile(filepath.Join(root, "title", "skipped", "skipped.go"), []byte(content), 0o600))
+
+		moduleRequest := func(args ...string) func(dir string) (ProcessRequest, error) {
+			return moduleRequestFunc(Options{}, args, "print", &ProcessRequest{})
+		}
+
+		var stdoutBuf, stderrBuf bytes.Buffer
+		processWorkspace([]string{root}, &ProcessRequest{OutputMode: "print"}, moduleRequest("workspace"),
+			OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Empty(t, stderrBuf.String())
+		out := stdoutBuf.String()
+		assert.Contains(t, out, "// some comment", "full mode from the config of the full module")
+		assert.Contains(t, out, "// some Comment", "title mode by default in the other module")
+		assert.Contains(t, out, filepath.Join(root, "title")+": 1 files analyzed", "skip from the config of the title module")
+
+		opts, err := moduleOptions(filepath.Join(root, "title"), []string{"--skip", "other", "workspace"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"other"}, opts.Skip, "command line overrides the module config")
+
+		req, err := moduleRequest("--max-depth", "-2", "workspace")(filepath.Join(root, "title"))
+		require.EqualError(t, err, "--max-depth can't be below -1, -1 means unlimited")
+		assert.Equal(t, ProcessRequest{}, req)
+
+		require.NoError(t, os.WriteFile(filepath.Join(root, "full", ".unfuck.yml"), []byte("ful: true\n"), 0o600))
+		stdoutBuf.Reset()
+		stderrBuf.Reset()
+		processWorkspace([]string{root}, &ProcessRequest{OutputMode: "print"}, moduleRequest("workspace"),
+			OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Contains(t, stderrBuf.String(), "Error in module "+filepath.Join(root, "full")+": parse config")
+		assert.Contains(t, stdoutBuf.String(), filepath.Join(root, "title")+": 1 files analyzed", "other modules are processed")
+	})
+
+	t.Run("workspace command", func(t *testing.T) {
+		var opts Options
+		p := flags.NewParser(&opts, flags.Default)
+		_, err := p.ParseArgs([]string{"--dry", "workspace", "dir1", "dir2"})
+		require.NoError(t, err)
+		result := determineProcessingMode(opts, p)
+		assert.Equal(t, ProcessingResult{Mode: "diff", Patterns: []string{"dir1", "dir2"}, Workspace: true}, result)
+	})
+}
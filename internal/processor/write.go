@@ -0,0 +1,316 @@
+package processor
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// handleInplaceMode writes modified content back to the file with custom writers, returns true if the file was written
+func handleInplaceMode(fileName string, fset *token.FileSet, node *ast.File, req *ProcessRequest, writers OutputWriters) bool {
+	origContent, err := os.ReadFile(fileName) //nolint:gosec
+	if err != nil {
+		writers.errorf("Error reading %s: %v\n", fileName, err)
+		return false
+	}
+	modifiedContent, err := getModifiedContent(fset, node, req.printerConfig())
+	if err != nil {
+		writers.errorf("Error generating modified content for %s: %v\n", fileName, err)
+		return false
+	}
+
+	if !checkWrite(fileName, string(origContent), modifiedContent, req, writers) {
+		return false
+	}
+
+	// run gofmt and goimports if requested
+	modifiedContent = req.matchLineEndings(string(origContent), req.formatSource(fileName, modifiedContent, writers))
+
+	// nothing to write if the net result is identical, keeps the file and its mtime untouched
+	if modifiedContent == string(origContent) {
+		writers.debugf("Unchanged: %s\n", fileName)
+		return false
+	}
+
+	// create backup if requested
+	if req.Backup {
+		writeBackup(fileName, origContent, req, writers)
+	}
+
+	// write the modified content to file
+	if err := writeFileAtomic(fileName, []byte(modifiedContent), writers); err != nil {
+		writers.errorf("Error writing to file %s: %v\n", fileName, err)
+		req.FilesFailed++
+		return false
+	}
+
+	writers.infof("Updated: %s\n", fileName)
+	return true
+}
+
+// checkWrite returns true if the modified content can replace the original one of the file.
+// the refused file is reported and counted as failed
+func checkWrite(fileName, original, modified string, req *ProcessRequest, writers OutputWriters) bool {
+	// refuse to write if the rewrite touched compiler directives
+	if err := checkDirectives(original, modified); err != nil {
+		writers.errorf("Error: refusing to write %s: %v\n", fileName, err)
+		req.FilesFailed++
+		return false
+	}
+
+	// doc comments are never converted, a change means the comment was misclassified
+	if req.AssertDocsPreserved {
+		if err := checkDocComments(original, modified); err != nil {
+			writers.errorf("Error: refusing to write %s: %v\n", fileName, err)
+			req.FilesFailed++
+			return false
+		}
+	}
+	return true
+}
+
+// writeFileAtomic replaces the content of the existing file with a temporary file written next to it,
+// so the file is never left partially written. permissions of the file, with setuid, setgid and sticky bits,
+// are reapplied to the new file, symlinks are followed.
+// if the temporary file can't be created or renamed, the file is written in place
+func writeFileAtomic(fileName string, data []byte, writers OutputWriters) error {
+	target, err := filepath.EvalSymlinks(fileName)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", fileName, err)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", fileName, err)
+	}
+
+	mode := info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+	err = replaceFile(target, data, mode)
+	if err == nil {
+		return nil
+	}
+	writers.warnf("Can't replace %s atomically, writing in place: %v\n", fileName, err)
+	if err := os.WriteFile(target, data, mode); err != nil { // the file exists, permissions are kept
+		return fmt.Errorf("write %s: %w", fileName, err)
+	}
+	return nil
+}
+
+// replaceFile writes the data to a temporary file in the directory of the target and renames it over the target
+func replaceFile(target string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer func() { _ = os.Remove(tmpName) }() // no-op after the rename
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temporary file: %w", err)
+	}
+	if err := os.Chmod(tmpName, mode); err != nil {
+		return fmt.Errorf("set permissions of temporary file: %w", err)
+	}
+	if err := os.Rename(tmpName, target); err != nil {
+		return fmt.Errorf("rename temporary file: %w", err)
+	}
+	return nil
+}
+
+// directivePrefixes are prefixes of comment lines the compiler and tools act on
+var directivePrefixes = []string{"//go:", "// +build", "//line ", "//export "}
+
+// directiveLine is a directive comment with the first following line which is not a comment, its target
+type directiveLine struct {
+	text   string
+	target string
+}
+
+// checkDirectives checks that compiler directives, like "//go:build", "// +build", "//line" and "//export",
+// are the same in the original and modified sources, in the same order and with the same targets.
+// it is a safety net against rewrites moving or altering directives
+func checkDirectives(original, modified string) error {
+	origDirectives, modDirectives := collectDirectives(original), collectDirectives(modified)
+	for i, d := range origDirectives {
+		if i >= len(modDirectives) {
+			return fmt.Errorf("directive %q removed", d.text)
+		}
+		if modDirectives[i].text != d.text {
+			return fmt.Errorf("directive %q changed to %q", d.text, modDirectives[i].text)
+		}
+		if modDirectives[i].target != d.target {
+			return fmt.Errorf("directive %q moved from %q to %q", d.text, d.target, modDirectives[i].target)
+		}
+	}
+	if len(modDirectives) > len(origDirectives) {
+		return fmt.Errorf("directive %q added", modDirectives[len(origDirectives)].text)
+	}
+	return nil
+}
+
+// collectDirectives returns directive lines of the source in order, with their targets
+func collectDirectives(src string) []directiveLine {
+	lines := strings.Split(src, "\n")
+	var res []directiveLine
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !slices.ContainsFunc(directivePrefixes, func(p string) bool { return strings.HasPrefix(trimmed, p) }) {
+			continue
+		}
+		d := directiveLine{text: trimmed}
+		for _, next := range lines[i+1:] {
+			// removed or converted comments between a directive and its target are fine, empty lines are not
+			if next = strings.TrimSpace(next); !strings.HasPrefix(next, "//") {
+				d.target = next
+				break
+			}
+		}
+		res = append(res, d)
+	}
+	return res
+}
+
+// docComment is a doc comment of a top-level declaration, empty if the declaration has no doc
+type docComment struct {
+	decl string
+	text string
+}
+
+// checkDocComments checks that doc comments of the package clause and top-level declarations
+// are the same in the original and modified sources
+func checkDocComments(original, modified string) error {
+	origDocs, err := collectDocComments(original)
+	if err != nil {
+		return fmt.Errorf("parse original source: %w", err)
+	}
+	modDocs, err := collectDocComments(modified)
+	if err != nil {
+		return fmt.Errorf("parse modified source: %w", err)
+	}
+	if len(origDocs) != len(modDocs) {
+		return fmt.Errorf("declarations changed, %d before and %d after", len(origDocs), len(modDocs))
+	}
+	for i, d := range origDocs {
+		if modDocs[i].text != d.text {
+			return fmt.Errorf("doc comment of %s changed from %q to %q", d.decl, d.text, modDocs[i].text)
+		}
+	}
+	return nil
+}
+
+// collectDocComments returns doc comments of the package clause, functions, declarations and type specs in order.
+// declarations are described by their names, the positions may change if in-function comments are removed
+func collectDocComments(src string) ([]docComment, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	res := []docComment{{decl: "package " + file.Name.Name, text: file.Doc.Text()}}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			res = append(res, docComment{decl: "func " + funcName(d), text: d.Doc.Text()})
+		case *ast.GenDecl:
+			res = append(res, docComment{decl: d.Tok.String() + " declaration", text: d.Doc.Text()})
+			for _, spec := range d.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok {
+					res = append(res, docComment{decl: "type " + ts.Name.Name, text: ts.Doc.Text()})
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// quarantineFile copies the file into the quarantine directory, keeping its path relative to the current directory.
+// files outside of the current directory keep their absolute path under the quarantine directory
+func quarantineFile(fileName, dir string) (string, error) {
+	target, err := pathUnder(dir, fileName)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(fileName) //nolint:gosec // file name comes from the walk or command line
+	if err != nil {
+		return "", fmt.Errorf("read file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return "", fmt.Errorf("create quarantine directory: %w", err)
+	}
+	if err := os.WriteFile(target, content, 0o600); err != nil {
+		return "", fmt.Errorf("write copy: %w", err)
+	}
+	return target, nil
+}
+
+// pathUnder returns the path of the file under the directory, keeping its path relative to the current directory.
+// files outside of the current directory keep their absolute path under the directory
+func pathUnder(dir, fileName string) (string, error) {
+	absPath, err := filepath.Abs(fileName)
+	if err != nil {
+		return "", fmt.Errorf("get absolute path: %w", err)
+	}
+	relPath := strings.TrimPrefix(absPath, filepath.VolumeName(absPath))
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, absPath); err == nil && !strings.HasPrefix(rel, "..") {
+			relPath = rel
+		}
+	}
+	return filepath.Join(dir, relPath), nil
+}
+
+// backupPath returns the path of the backup of the file, next to the file or under the backup directory if set
+func (r *ProcessRequest) backupPath(fileName string) (string, error) {
+	ext := r.BackupExt
+	if ext == "" {
+		ext = defaultBackupExt
+	}
+	if r.BackupDir == "" {
+		return fileName + ext, nil
+	}
+	path, err := pathUnder(r.BackupDir, fileName)
+	if err != nil {
+		return "", err
+	}
+	return path + ext, nil
+}
+
+// defaultBackupExt is the extension added to names of backup files
+const defaultBackupExt = ".bak"
+
+// validateBackupExt checks that the backup extension starts with a dot and doesn't make backups look like Go files
+func validateBackupExt(ext string) error {
+	if len(ext) < 2 || !strings.HasPrefix(ext, ".") {
+		return fmt.Errorf("invalid backup extension %q, should start with a dot, like .bak", ext)
+	}
+	if strings.HasSuffix(ext, ".go") {
+		return fmt.Errorf("invalid backup extension %q, backups would be processed as Go files", ext)
+	}
+	return nil
+}
+
+// writeBackup writes the original content of the file to its backup file
+func writeBackup(fileName string, origContent []byte, req *ProcessRequest, writers OutputWriters) {
+	backupFile, err := req.backupPath(fileName)
+	if err != nil {
+		writers.errorf("Error creating backup of %s: %v\n", fileName, err)
+		return
+	}
+	if req.BackupDir != "" {
+		if err := os.MkdirAll(filepath.Dir(backupFile), 0o750); err != nil {
+			writers.errorf("Error creating backup directory for %s: %v\n", fileName, err)
+			return
+		}
+	}
+	if err := os.WriteFile(backupFile, origContent, 0o600); err != nil {
+		writers.errorf("Error creating backup file %s: %v\n", backupFile, err)
+	}
+}
@@ -0,0 +1,452 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBackupFunctionality tests the backup functionality
+func TestBackupFunctionality(t *testing.T) {
+	// create a temporary directory for test files
+	tempDir := t.TempDir()
+
+	// test case 1: a file with changes that should be backed up
+	t.Run("backup created for file with changes", func(t *testing.T) {
+		// create a test file with comments that will be modified
+		testFile := filepath.Join(tempDir, "backup_test1.go")
+		content := `package test
+
+func TestFunc() {
+	// THIS COMMENT Should BE Modified
+	x := 1 // ANOTHER COMMENT To Modify
+}`
+		err := os.WriteFile(testFile, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to write test file")
+
+		// capture output using buffers
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process file with backup flag
+		processFile(testFile, &ProcessRequest{OutputMode: "inplace", Backup: true}, writers)
+
+		// verify backup file was created
+		backupFile := testFile + ".bak"
+		_, err = os.Stat(backupFile)
+		require.NoError(t, err, "Backup file should exist")
+
+		// verify backup file has original content
+		backupContent, err := os.ReadFile(backupFile)
+		require.NoError(t, err, "Failed to read backup file")
+		assert.Equal(t, content, string(backupContent), "Backup file should contain original content")
+
+		// verify main file has modified content
+		modifiedContent, err := os.ReadFile(testFile)
+		require.NoError(t, err, "Failed to read modified file")
+		assert.Contains(t, string(modifiedContent), "// this comment", "File should contain lowercase comments")
+		assert.Contains(t, string(modifiedContent), "// another comment", "File should contain lowercase comments")
+	})
+
+	// test case 2: a file with no changes should not be backed up
+	t.Run("no backup created for file without changes", func(t *testing.T) {
+		// create a test file with comments already in lowercase
+		testFile := filepath.Join(tempDir, "backup_test2.go")
+		content := `package test
+
+func TestFunc() {
+	// this comment is already lowercase
+	x := 1 // another lowercase comment
+}`
+		err := os.WriteFile(testFile, []byte(content), 0o600)
+		require.NoError(t, err, "Failed to write test file")
+
+		// make sure no backup file exists initially
+		backupFile := testFile + ".bak"
+		_ = os.Remove(backupFile) // cleanup any previous runs
+
+		// capture output using buffers
+		var stdoutBuf, stderrBuf bytes.Buffer
+		writers := OutputWriters{
+			Stdout: &stdoutBuf,
+			Stderr: &stderrBuf,
+		}
+
+		// process with backup flag
+		changes := processFile(testFile, &ProcessRequest{OutputMode: "inplace", Backup: true}, writers)
+
+		// there should be no changes since the comments are already lowercase
+		assert.Equal(t, 0, changes, "Should have no changes")
+
+		// verify backup file was not created
+		_, err = os.Stat(backupFile)
+		require.Error(t, err, "Backup file should not exist")
+		assert.True(t, os.IsNotExist(err), "Error should be 'file does not exist'")
+	})
+
+	t.Run("custom extension and directory", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		content := "package test\n\nfunc TestFunc() {\n\t// Some Comment\n}\n"
+		require.NoError(t, os.MkdirAll(filepath.Join("pkg", "sub"), 0o750))
+		file := filepath.Join("pkg", "sub", "a.go")
+
+		tbl := []struct {
+			name, ext, dir, expected string
+		}{
+			{name: "extension", ext: ".orig", expected: filepath.Join("pkg", "sub", "a.go.orig")},
+			{name: "directory", dir: "backups", expected: filepath.Join("backups", "pkg", "sub", "a.go.bak")},
+			{name: "both", ext: ".orig", dir: "backups", expected: filepath.Join("backups", "pkg", "sub", "a.go.orig")},
+		}
+		for _, tt := range tbl {
+			t.Run(tt.name, func(t *testing.T) {
+				require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+				req := &ProcessRequest{OutputMode: "inplace", TitleCase: true, Backup: true, BackupExt: tt.ext, BackupDir: tt.dir}
+				var stderrBuf bytes.Buffer
+				processFile(file, req, OutputWriters{Stdout: io.Discard, Stderr: &stderrBuf})
+				assert.Empty(t, stderrBuf.String())
+
+				res, err := os.ReadFile(tt.expected)
+				require.NoError(t, err)
+				assert.Equal(t, content, string(res))
+				require.NoError(t, os.Remove(tt.expected))
+			})
+		}
+		assert.NoFileExists(t, file+".bak", "backups are not created next to the file with a directory set")
+	})
+
+	t.Run("validate extension", func(t *testing.T) {
+		require.NoError(t, validateBackupExt(".bak"))
+		require.NoError(t, validateBackupExt(".unfuck.orig"))
+		require.Error(t, validateBackupExt("bak"))
+		require.Error(t, validateBackupExt("."))
+		require.Error(t, validateBackupExt(""))
+		require.Error(t, validateBackupExt(".go"))
+		require.Error(t, validateBackupExt(".bak.go"))
+	})
+}
+
+// TestQuarantine tests that only files with changes are copied to the quarantine directory
+func TestQuarantine(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	offending := "package test\n\nfunc A() {\n\t// Some Comment\n\t// Another Comment\n}\n"
+	clean := "package test\n\nfunc B() {\n\t// clean comment\n}\n"
+	require.NoError(t, os.MkdirAll("pkg", 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join("pkg", "a.go"), []byte(offending), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join("pkg", "b.go"), []byte(clean), 0o600))
+	require.NoError(t, os.WriteFile("c.go", []byte(offending), 0o600))
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	req := ProcessRequest{OutputMode: "quarantine", TitleCase: true, QuarantineDir: "review", SkipPatterns: []string{"review"}}
+	processPatterns([]string{"./..."}, &req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+	assert.Empty(t, stderrBuf.String())
+	assert.Equal(t, "Quarantined: c.go -> review/c.go, 2 comments\n"+
+		"Quarantined: pkg/a.go -> review/pkg/a.go, 2 comments\n"+
+		"\nSummary: 3 files analyzed, 2 files quarantined, 4 total changes\n", stdoutBuf.String())
+
+	res, err := os.ReadFile(filepath.Join("review", "pkg", "a.go"))
+	require.NoError(t, err)
+	assert.Equal(t, offending, string(res), "copy is not modified")
+	res, err = os.ReadFile(filepath.Join("pkg", "a.go"))
+	require.NoError(t, err)
+	assert.Equal(t, offending, string(res), "original is not modified")
+	assert.FileExists(t, filepath.Join("review", "c.go"))
+	assert.NoFileExists(t, filepath.Join("review", "pkg", "b.go"))
+
+	t.Run("second run skips the quarantine directory", func(t *testing.T) {
+		stdoutBuf.Reset()
+		req := ProcessRequest{OutputMode: "quarantine", TitleCase: true, QuarantineDir: "review", SkipPatterns: []string{"review"}}
+		processPatterns([]string{"./..."}, &req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Equal(t, 3, req.FilesAnalyzed)
+		assert.NoDirExists(t, filepath.Join("review", "review"))
+	})
+
+	t.Run("file outside of the current directory", func(t *testing.T) {
+		outside := filepath.Join(t.TempDir(), "d.go")
+		require.NoError(t, os.WriteFile(outside, []byte(offending), 0o600))
+		target, err := quarantineFile(outside, "review")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("review", outside), target)
+		assert.FileExists(t, target)
+	})
+}
+
+// TestCheckDirectives tests the guard against rewrites moving or altering compiler directives
+func TestCheckDirectives(t *testing.T) {
+	src := "//go:build linux\n\npackage test\n\n//go:noinline\nfunc Example() {\n\t//go:generate stringer -type=T\n\t// Some Comment\n\tx := 1\n}\n"
+
+	tbl := []struct {
+		name     string
+		modified string
+		err      string
+	}{
+		{name: "comment converted", modified: strings.Replace(src, "Some", "some", 1)},
+		{name: "comment removed", modified: strings.Replace(src, "\t// Some Comment\n", "", 1)},
+		{name: "directive altered", modified: strings.Replace(src, "//go:noinline", "//go:Noinline", 1),
+			err: `directive "//go:noinline" changed to "//go:Noinline"`},
+		{name: "empty line after build constraint removed", modified: strings.Replace(src, "linux\n\n", "linux\n", 1),
+			err: `directive "//go:build linux" moved from "" to "package test"`},
+		{name: "directive separated from target", modified: strings.Replace(src, "//go:noinline\nfunc", "//go:noinline\n\nfunc", 1),
+			err: `directive "//go:noinline" moved from "func Example() {" to ""`},
+		{name: "directive removed", modified: strings.Replace(src, "\t//go:generate stringer -type=T\n", "", 1),
+			err: `directive "//go:generate stringer -type=T" removed`},
+		{name: "directive added", modified: src + "//line foo.go:1\n", err: `directive "//line foo.go:1" added`},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDirectives(src, tt.modified)
+			if tt.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.err)
+		})
+	}
+
+	t.Run("buggy rewrite not written", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "test.go")
+		require.NoError(t, os.WriteFile(file, []byte(src), 0o600))
+		fset := token.NewFileSet()
+		node, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		require.NoError(t, err)
+
+		// simulate a rewrite that lowercases everything, directives included
+		for _, group := range node.Comments {
+			for _, comment := range group.List {
+				comment.Text = strings.ToLower(comment.Text)
+			}
+		}
+
+		var stdoutBuf, stderrBuf bytes.Buffer
+		handleInplaceMode(file, fset, node, &ProcessRequest{}, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Contains(t, stderrBuf.String(), "Error: refusing to write "+file+": directive \"//go:generate stringer -type=T\" changed")
+		assert.NotContains(t, stdoutBuf.String(), "Updated:")
+
+		res, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, src, string(res))
+	})
+
+	t.Run("refused file is a failure", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "test.go")
+		blockSrc := "package test\n\nfunc F() {\n\t/*\n\t//go:generate Stringer -type=T\n\t*/\n}\n"
+		require.NoError(t, os.WriteFile(file, []byte(blockSrc), 0o600))
+
+		var stdoutBuf, stderrBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "inplace", TitleCase: false}
+		processPatterns([]string{file}, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Contains(t, stderrBuf.String(), "Error: refusing to write "+file)
+		assert.Equal(t, 1, req.FilesFailed)
+		assert.Equal(t, 0, req.FilesUpdated)
+		assert.Equal(t, 0, req.TotalChanges)
+		assert.True(t, req.failed(), "refused write should fail the run")
+		assert.Contains(t, stdoutBuf.String(), "Summary: 1 files analyzed, 0 files updated, 0 total changes")
+
+		res, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, blockSrc, string(res))
+	})
+}
+
+// TestInplaceModeKeepsUnchangedFiles tests that a rewrite with identical result doesn't touch the file
+func TestInplaceModeKeepsUnchangedFiles(t *testing.T) {
+	src := "package test\n\nfunc Example() {\n\t// Some Comment\n\tx := 1\n\t_ = x\n}\n"
+	oldTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	prepare := func(t *testing.T) (string, *token.FileSet, *ast.File) {
+		t.Helper()
+		file := filepath.Join(t.TempDir(), "test.go")
+		require.NoError(t, os.WriteFile(file, []byte(src), 0o600))
+		require.NoError(t, os.Chtimes(file, oldTime, oldTime))
+		fset := token.NewFileSet()
+		node, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		require.NoError(t, err)
+		return file, fset, node
+	}
+
+	for _, format := range []bool{false, true} {
+		t.Run(fmt.Sprintf("identical, format=%v", format), func(t *testing.T) {
+			file, fset, node := prepare(t)
+			var stdoutBuf, stderrBuf bytes.Buffer
+			req := &ProcessRequest{Format: format, Backup: true}
+			assert.False(t, handleInplaceMode(file, fset, node, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}))
+			assert.Zero(t, req.FilesFailed, "identical result is not a failure")
+			assert.NotContains(t, stdoutBuf.String(), "Updated:")
+			assert.Empty(t, stderrBuf.String())
+
+			info, err := os.Stat(file)
+			require.NoError(t, err)
+			assert.True(t, info.ModTime().Equal(oldTime), "mtime should be preserved, got %v", info.ModTime())
+			assert.NoFileExists(t, file+".bak")
+		})
+	}
+
+	t.Run("modified", func(t *testing.T) {
+		file, fset, node := prepare(t)
+		node.Comments[0].List[0].Text = "// some comment"
+		var stdoutBuf bytes.Buffer
+		handleInplaceMode(file, fset, node, &ProcessRequest{}, OutputWriters{Stdout: &stdoutBuf, Stderr: io.Discard})
+		assert.Contains(t, stdoutBuf.String(), "Updated: "+file)
+
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		assert.False(t, info.ModTime().Equal(oldTime))
+	})
+}
+
+// TestWriteFileAtomic tests that files are replaced by a renamed temporary file, keeping permissions and symlinks
+func TestWriteFileAtomic(t *testing.T) {
+	t.Run("replaced", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "test.go")
+		require.NoError(t, os.WriteFile(file, []byte("old content"), 0o600))
+		require.NoError(t, os.Chmod(file, 0o640))
+
+		var stderrBuf bytes.Buffer
+		require.NoError(t, writeFileAtomic(file, []byte("new content"), OutputWriters{Stdout: io.Discard, Stderr: &stderrBuf}))
+		assert.Empty(t, stderrBuf.String())
+
+		res, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, "new content", string(res))
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o640), info.Mode().Perm(), "permissions should be kept")
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "no temporary files left")
+	})
+
+	t.Run("symlink", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "real.go")
+		link := filepath.Join(dir, "link.go")
+		require.NoError(t, os.WriteFile(file, []byte("old content"), 0o600))
+		require.NoError(t, os.Symlink(file, link))
+
+		require.NoError(t, writeFileAtomic(link, []byte("new content"), OutputWriters{Stdout: io.Discard, Stderr: io.Discard}))
+		info, err := os.Lstat(link)
+		require.NoError(t, err)
+		assert.NotZero(t, info.Mode()&os.ModeSymlink, "link should stay a symlink")
+		res, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, "new content", string(res), "link target should be updated")
+	})
+
+	t.Run("fallback to writing in place", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("directory permissions are not enforced for root")
+		}
+		dir := t.TempDir()
+		file := filepath.Join(dir, "test.go")
+		require.NoError(t, os.WriteFile(file, []byte("old content"), 0o600))
+		require.NoError(t, os.Chmod(dir, 0o500)) // temporary file can't be created
+		t.Cleanup(func() { _ = os.Chmod(dir, 0o700) })
+
+		var stderrBuf bytes.Buffer
+		require.NoError(t, writeFileAtomic(file, []byte("new content"), OutputWriters{Stdout: io.Discard, Stderr: &stderrBuf}))
+		assert.Contains(t, stderrBuf.String(), "Can't replace "+file+" atomically, writing in place")
+		res, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, "new content", string(res))
+	})
+
+	t.Run("processed files keep permissions", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		for name, mode := range map[string]os.FileMode{"restricted.go": 0o640, "executable.go": 0o755, "setgid.go": 0o750 | os.ModeSetgid} {
+			require.NoError(t, os.WriteFile(name, []byte("package test\n\nfunc A() {\n\t// Some Comment\n}\n"), 0o600))
+			require.NoError(t, os.Chmod(name, mode))
+
+			req := &ProcessRequest{OutputMode: "inplace", TitleCase: true}
+			assert.Equal(t, 1, processFile(name, req, OutputWriters{Stdout: io.Discard, Stderr: io.Discard}))
+			res, err := os.ReadFile(name)
+			require.NoError(t, err)
+			assert.Contains(t, string(res), "// some Comment", name)
+
+			info, err := os.Stat(name)
+			require.NoError(t, err)
+			assert.Equal(t, mode, info.Mode()&(os.ModePerm|os.ModeSetgid), name)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		err := writeFileAtomic(filepath.Join(t.TempDir(), "missing.go"), []byte("content"), OutputWriters{Stdout: io.Discard, Stderr: io.Discard})
+		require.Error(t, err)
+	})
+}
+
+// TestAssertDocsPreserved tests refusing to write files with changed doc comments
+func TestAssertDocsPreserved(t *testing.T) {
+	src := "// Package test Is Documented\npackage test\n\n// Example Does Things\nfunc Example() {\n\t// Some Comment\n}\n\n" +
+		"type (\n\t// Foo Is A Type\n\tFoo int\n)\n"
+
+	prepare := func(t *testing.T) (string, *token.FileSet, *ast.File) {
+		t.Helper()
+		file := filepath.Join(t.TempDir(), "test.go")
+		require.NoError(t, os.WriteFile(file, []byte(src), 0o600))
+		fset := token.NewFileSet()
+		node, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		require.NoError(t, err)
+		return file, fset, node
+	}
+
+	t.Run("regular processing passes", func(t *testing.T) {
+		file, _, _ := prepare(t)
+		var stdoutBuf, stderrBuf bytes.Buffer
+		req := &ProcessRequest{OutputMode: "inplace", TitleCase: true, AssertDocsPreserved: true}
+		processFile(file, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf})
+		assert.Empty(t, stderrBuf.String())
+		res, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, strings.Replace(src, "// Some Comment", "// some Comment", 1), string(res))
+	})
+
+	t.Run("broken classification refused", func(t *testing.T) {
+		file, fset, node := prepare(t)
+		// simulate a classification bug converting the type doc as an in-function comment
+		node.Comments[3].List[0].Text = "// foo Is A Type"
+		var stdoutBuf, stderrBuf bytes.Buffer
+		req := &ProcessRequest{AssertDocsPreserved: true}
+		assert.False(t, handleInplaceMode(file, fset, node, req, OutputWriters{Stdout: &stdoutBuf, Stderr: &stderrBuf}))
+		assert.Equal(t, 1, req.FilesFailed, "refused write is a failure")
+		assert.Equal(t, "Error: refusing to write "+file+`: doc comment of type Foo changed from "Foo Is A Type\n" to "foo Is A Type\n"`+"\n",
+			stderrBuf.String())
+		assert.NotContains(t, stdoutBuf.String(), "Updated:")
+		res, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, src, string(res))
+	})
+
+	t.Run("broken classification written without the option", func(t *testing.T) {
+		file, fset, node := prepare(t)
+		node.Comments[1].List[0].Text = "// example Does Things"
+		var stdoutBuf bytes.Buffer
+		handleInplaceMode(file, fset, node, &ProcessRequest{}, OutputWriters{Stdout: &stdoutBuf, Stderr: io.Discard})
+		assert.Contains(t, stdoutBuf.String(), "Updated:")
+	})
+
+	t.Run("check", func(t *testing.T) {
+		require.NoError(t, checkDocComments(src, strings.Replace(src, "\t// Some Comment\n", "", 1)))
+		require.EqualError(t, checkDocComments(src, strings.Replace(src, "Is Documented", "is documented", 1)),
+			`doc comment of package test changed from "Package test Is Documented\n" to "Package test is documented\n"`)
+		require.EqualError(t, checkDocComments(src, strings.Replace(src, "// Example Does Things\n", "", 1)),
+			`doc comment of func Example changed from "Example Does Things\n" to ""`)
+		require.ErrorContains(t, checkDocComments(src, "package"), "parse modified source")
+	})
+}
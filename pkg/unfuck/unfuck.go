@@ -0,0 +1,37 @@
+// Package unfuck converts in-function comments of Go sources to lowercase, like the unfuck-ai-comments
+// command does, for tools embedding the conversion
+package unfuck
+
+import (
+	"fmt"
+
+	"github.com/umputun/unfuck-ai-comments/internal/processor"
+)
+
+// Change describes a single modified comment
+type Change struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// ProcessSource converts comments of the Go source, the first character only with title case, the whole
+// comment otherwise, and returns the modified source with the list of changes. it works on the source only,
+// without file I/O and command line options, the rest of settings are defaults. changes have no file name,
+// the source is returned as is if nothing was changed
+func ProcessSource(src []byte, titleCase bool) ([]byte, []Change, error) {
+	res, changes, err := processor.ProcessSource(src, titleCase)
+	if err != nil {
+		return nil, nil, fmt.Errorf("process source: %w", err)
+	}
+	if len(changes) == 0 {
+		return res, nil, nil
+	}
+	converted := make([]Change, len(changes))
+	for i, c := range changes {
+		converted[i] = Change(c)
+	}
+	return res, converted, nil
+}
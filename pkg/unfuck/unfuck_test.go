@@ -0,0 +1,48 @@
+package unfuck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProcessSource tests the conversion of a source without files and command line options
+func TestProcessSource(t *testing.T) {
+	src := []byte("package test\n\n// Config Is Not Changed\ntype Config struct{}\n\nfunc Example() {\n\t// Some Comment\n}\n")
+
+	t.Run("title case", func(t *testing.T) {
+		res, changes, err := ProcessSource(src, true)
+		require.NoError(t, err)
+		assert.Equal(t, "package test\n\n// Config Is Not Changed\ntype Config struct{}\n\nfunc Example() {\n\t// some Comment\n}\n", string(res))
+		assert.Equal(t, []Change{{Line: 7, Column: 2, Before: "// Some Comment", After: "// some Comment"}}, changes)
+	})
+
+	t.Run("full", func(t *testing.T) {
+		res, changes, err := ProcessSource(src, false)
+		require.NoError(t, err)
+		assert.Contains(t, string(res), "\t// some comment\n")
+		assert.Len(t, changes, 1)
+	})
+
+	t.Run("defaults of the command", func(t *testing.T) {
+		res, _, err := ProcessSource([]byte("package test\n\nfunc Example() {\n\t// ===== Section Header =====\n"+
+			"\t// Send HTTP Request To API\n}\n"), false)
+		require.NoError(t, err)
+		assert.Contains(t, string(res), "\t// ===== Section Header =====\n", "banners are skipped")
+		assert.Contains(t, string(res), "\t// send HTTP request to API\n", "acronyms are kept")
+	})
+
+	t.Run("nothing to change", func(t *testing.T) {
+		clean := []byte("package test\n\nfunc Example() {\n\t// clean\n\tx  :=  1\n\t_ = x\n}\n")
+		res, changes, err := ProcessSource(clean, true)
+		require.NoError(t, err)
+		assert.Equal(t, clean, res, "returned as is, not reformatted")
+		assert.Empty(t, changes)
+	})
+
+	t.Run("invalid source", func(t *testing.T) {
+		_, _, err := ProcessSource([]byte("package"), true)
+		require.Error(t, err)
+	})
+}